@@ -4,9 +4,12 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/anthropics/abytetracker/pkg/audio"
 	"github.com/anthropics/abytetracker/pkg/format"
 	"github.com/anthropics/abytetracker/pkg/tracker"
 	"github.com/anthropics/abytetracker/pkg/tui"
@@ -14,10 +17,32 @@ import (
 
 func main() {
 	channels := flag.Int("channels", 6, "Number of channels (1-16)")
+	lufs := flag.Float64("lufs", 0, "Normalize exports to this integrated loudness in LUFS (0 = off)")
+	loops := flag.Int("loops", 0, "Extra passes through the loop when exporting looping songs, ending in a fade")
+	exportFlag := flag.String("format", "wav", "Export format: wav, wav24, wav32f, flac, oga (FLAC in Ogg) or ogg (Vorbis)")
+	export := flag.Bool("export", false, "Export the song to _export and exit instead of starting the editor")
+	stems := flag.Bool("stems", false, "With -export, write one file per channel")
+	stemMaster := flag.Bool("stem-master", true, "Write the full mix alongside stems")
+	audioFlag := flag.String("audio", "oto", "Audio output: oto (sound card), null (silent) or pcm (raw PCM to -pcm-out)")
+	pcmOut := flag.String("pcm-out", "-", "File or pipe for -audio pcm, as signed 16-bit little-endian stereo (- = stdout)")
+	bufferFrames := flag.Int("buffer", 512, "Audio block size in frames (also the sound card buffer)")
+	latency := flag.Duration("latency", 100*time.Millisecond, "Audio output latency")
+	configPath := flag.String("config", tui.DefaultConfigPath(), "Editor config file (key bindings, note layout, theme)")
 	flag.Parse()
 
+	// Keep stdout clean when it carries audio
+	info := os.Stdout
+	if *audioFlag == "pcm" && *pcmOut == "-" {
+		info = os.Stderr
+	}
+
+	exportFormat, err := audio.ParseFormat(*exportFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
 	var song *tracker.Song
-	var err error
 	var filename string
 
 	// Check if a file was provided
@@ -34,7 +59,7 @@ func main() {
 			fmt.Fprintf(os.Stderr, "Error loading file: %v\n", err)
 			os.Exit(1)
 		}
-		fmt.Printf("Loaded: %s by %s (%d channels)\n", song.Title, song.Author, song.Channels)
+		fmt.Fprintf(info, "Loaded: %s by %s (%d channels)\n", song.Title, song.Author, song.Channels)
 	} else {
 		// Create a new song
 		if *channels < 1 {
@@ -63,12 +88,114 @@ func main() {
 	}
 	_ = err
 
+	opts := audio.ExportOptions{Format: exportFormat, TargetLUFS: *lufs, Loops: *loops}
+	if *export {
+		status, err := tui.ExportFiles(song, filename, opts, *stems, *stemMaster)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Export failed: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(status)
+		return
+	}
+
 	// Start TUI
+	config, err := tui.LoadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	keys, err := tui.NewKeymap(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error in config %s: %v\n", *configPath, err)
+		os.Exit(1)
+	}
+
+	theme, err := tui.NewTheme(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error in config %s: %v\n", *configPath, err)
+		os.Exit(1)
+	}
+	theme.Apply()
+
 	model := tui.NewModel(song, filename)
-	p := tea.NewProgram(model)
+	model.Keys = keys
+	model.Theme = theme
+	if unbound := keys.Unbound(); len(unbound) > 0 {
+		names := make([]string, len(unbound))
+		for i, act := range unbound {
+			names[i] = string(act)
+		}
+		model.StatusMsg = fmt.Sprintf("No key left for %s; bind them in %s", strings.Join(names, ", "), *configPath)
+	}
+	model.ExportFormat = exportFormat
+	model.ExportLUFS = *lufs
+	model.ExportLoops = *loops
+	model.ExportStemMaster = *stemMaster
+
+	cfg := audio.SinkConfig{BufferFrames: *bufferFrames, Latency: *latency}
+	sink, err := openSink(*audioFlag, *pcmOut, model.Player, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	model.Audio = sink
+
+	var teaOpts []tea.ProgramOption
+	if info != os.Stdout {
+		// Audio goes to stdout, so draw the UI on the terminal directly
+		tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: no terminal for the UI: %v\n", err)
+			os.Exit(1)
+		}
+		defer tty.Close()
+		teaOpts = append(teaOpts, tea.WithInput(tty), tea.WithOutput(tty))
+	}
+	p := tea.NewProgram(model, teaOpts...)
 
 	if _, err := p.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
+
+// openSink creates the audio sink selected on the command line
+func openSink(kind, pcmOut string, player *audio.Player, cfg audio.SinkConfig) (audio.AudioSink, error) {
+	switch kind {
+	case "oto":
+		sink, err := audio.NewRealtimeOutput(player, cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Audio unavailable (%v), continuing without sound\n", err)
+			return audio.NewNullSink(player, cfg), nil
+		}
+		return sink, nil
+	case "null":
+		return audio.NewNullSink(player, cfg), nil
+	case "pcm":
+		if pcmOut == "-" {
+			return audio.NewPCMSink(player, os.Stdout, cfg), nil
+		}
+		// Opening a named pipe blocks until a reader connects
+		f, err := os.OpenFile(pcmOut, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			return nil, err
+		}
+		return fileSink{audio.NewPCMSink(player, f, cfg), f}, nil
+	}
+	return nil, fmt.Errorf("unknown audio output %q", kind)
+}
+
+// fileSink closes the output file after its sink
+type fileSink struct {
+	audio.AudioSink
+	f *os.File
+}
+
+func (s fileSink) Close() error {
+	err := s.AudioSink.Close()
+	if ferr := s.f.Close(); err == nil {
+		err = ferr
+	}
+	return err
+}
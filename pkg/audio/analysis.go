@@ -0,0 +1,59 @@
+package audio
+
+import (
+	"math"
+
+	"github.com/anthropics/abytetracker/pkg/tracker"
+)
+
+// SongInfo describes one pass through a song, as the player would play it
+type SongInfo struct {
+	Samples int     // Length of one pass in samples
+	Seconds float64 // Length of one pass in seconds
+
+	// Where playback continues after one pass. A song loops when a Bxx
+	// jump leads back to a row already played; otherwise it ends by
+	// running off the order and the player restarts at position 0.
+	Loops       bool
+	LoopPos     int
+	LoopRow     int
+	LoopSamples int // Length of the repeating section in samples
+}
+
+// AnalyzeSong runs the sequencer over the song without rendering audio,
+// following Fxx speed/tempo changes, Bxx jumps and Dxx pattern breaks,
+// until a row is about to play for the second time
+func AnalyzeSong(song *tracker.Song) SongInfo {
+	var info SongInfo
+	if len(song.Order) == 0 {
+		return info
+	}
+
+	p := NewPlayer(song)
+	p.Playing = true
+	p.Pattern = int(song.Order[0])
+
+	// Samples elapsed at the start of each row played so far. Ticks start
+	// on the sample after the fractional clock runs out, as in step.
+	visited := make(map[[2]int]float64)
+	var elapsed float64
+	for {
+		if p.Tick == 0 {
+			key := [2]int{p.Position, p.Row}
+			if start, ok := visited[key]; ok {
+				info.Samples = int(math.Ceil(elapsed))
+				info.Loops = !p.wrapped
+				info.LoopPos = p.Position
+				info.LoopRow = p.Row
+				info.LoopSamples = info.Samples - int(math.Ceil(start))
+				break
+			}
+			visited[key] = elapsed
+		}
+		p.tick()
+		elapsed += p.TickSamples
+	}
+
+	info.Seconds = float64(info.Samples) / float64(song.SampleRate)
+	return info
+}
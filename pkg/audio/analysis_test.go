@@ -0,0 +1,155 @@
+package audio
+
+import (
+	"math"
+	"testing"
+
+	"github.com/anthropics/abytetracker/pkg/tracker"
+)
+
+// rowSamples is the length of one row at the default speed and tempo
+const rowSamples = 6 * 882
+
+// seqSong returns a song playing patterns 0..n-1 of four rows in order,
+// with the effects in fx placed as {position, row, channel, effect}
+func seqSong(n int, fx ...[4]int) *tracker.Song {
+	song := tracker.NewSong(2)
+	song.Patterns = nil
+	song.Order = nil
+	for i := range n {
+		song.Patterns = append(song.Patterns, tracker.NewPattern(4, 2))
+		song.Order = append(song.Order, uint8(i))
+	}
+	for _, f := range fx {
+		song.Patterns[f[0]].Notes[f[1]][f[2]].Effect = tracker.Effect{Type: uint8(f[3] >> 8), Param: uint8(f[3])}
+	}
+	return song
+}
+
+// effect packs an effect for seqSong
+func effect(typ, param uint8) int {
+	return int(typ)<<8 | int(param)
+}
+
+func TestAnalyzeSong(t *testing.T) {
+	tests := []struct {
+		name             string
+		song             *tracker.Song
+		samples          int
+		loops            bool
+		loopPos, loopRow int
+		loopSamples      int
+	}{
+		{
+			name:        "runs off the order",
+			song:        seqSong(2),
+			samples:     8 * rowSamples,
+			loopSamples: 8 * rowSamples,
+		},
+		{
+			name:        "Bxx back to an earlier position",
+			song:        seqSong(3, [4]int{2, 3, 0, effect(tracker.FxJump, 1)}),
+			samples:     12 * rowSamples,
+			loops:       true,
+			loopPos:     1,
+			loopSamples: 8 * rowSamples,
+		},
+		{
+			name:        "Dxx skips rows",
+			song:        seqSong(2, [4]int{0, 1, 0, effect(tracker.FxBreak, 2)}),
+			samples:     4 * rowSamples,
+			loopSamples: 4 * rowSamples,
+		},
+		{
+			name: "Bxx and Dxx into the middle of a pattern",
+			song: seqSong(2,
+				[4]int{1, 2, 0, effect(tracker.FxJump, 0)},
+				[4]int{1, 2, 1, effect(tracker.FxBreak, 2)}),
+			samples:     7 * rowSamples,
+			loops:       true,
+			loopRow:     2,
+			loopSamples: 5 * rowSamples,
+		},
+		{
+			name:        "Dxx off the end of the order wraps without looping",
+			song:        seqSong(2, [4]int{1, 2, 0, effect(tracker.FxBreak, 2)}),
+			samples:     7 * rowSamples,
+			loopRow:     2,
+			loopSamples: 5 * rowSamples,
+		},
+		{
+			name:        "Fxx speed change",
+			song:        seqSong(1, [4]int{0, 0, 0, effect(tracker.FxSpeed, 3)}),
+			samples:     4 * 3 * 882,
+			loopSamples: 4 * 3 * 882,
+		},
+	}
+	for _, tt := range tests {
+		info := AnalyzeSong(tt.song)
+		if info.Samples != tt.samples || info.Loops != tt.loops ||
+			info.LoopPos != tt.loopPos || info.LoopRow != tt.loopRow || info.LoopSamples != tt.loopSamples {
+			t.Errorf("%s: got %d samples, loops %v at %d:%d, loop %d samples; want %d, %v at %d:%d, %d",
+				tt.name, info.Samples, info.Loops, info.LoopPos, info.LoopRow, info.LoopSamples,
+				tt.samples, tt.loops, tt.loopPos, tt.loopRow, tt.loopSamples)
+		}
+		if want := float64(tt.samples) / 44100; info.Seconds != want {
+			t.Errorf("%s: %g seconds, want %g", tt.name, info.Seconds, want)
+		}
+	}
+
+	if info := AnalyzeSong(&tracker.Song{}); info.Samples != 0 {
+		t.Errorf("empty order: %d samples", info.Samples)
+	}
+}
+
+func TestChaseFollowsJumps(t *testing.T) {
+	// Position 1 is skipped by a Bxx, so its F03 must not be chased when
+	// starting at position 2; starting inside position 1 still chases it
+	song := seqSong(3,
+		[4]int{0, 3, 0, effect(tracker.FxJump, 2)},
+		[4]int{1, 0, 0, effect(tracker.FxSpeed, 3)})
+	p := NewPlayer(song)
+
+	p.SetPosition(2, 0)
+	if p.Speed != 6 {
+		t.Errorf("at 02:00, speed %d chased from a skipped position, want 6", p.Speed)
+	}
+	p.SetPosition(1, 1)
+	if p.Speed != 3 {
+		t.Errorf("at 01:01, speed %d, want 3", p.Speed)
+	}
+	if _, _, row, _, _ := p.GetPlaybackInfo(); row != 1 {
+		t.Errorf("chase moved the row to %d", row)
+	}
+}
+
+func TestExportLengthAndLoudness(t *testing.T) {
+	// An edit still waiting for the audio thread sets the export length
+	p := NewPlayer(seqSong(1))
+	p.UpdateSong(seqSong(2))
+	f := &memFile{}
+	if err := Export(p, f, ExportOptions{Format: FormatWAVFloat}); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(wavFloats(t, f.data)) / 2; n != 8*rowSamples {
+		t.Errorf("exported %d frames, want %d", n, 8*rowSamples)
+	}
+
+	// Normalization measures the faded-out audio that is written
+	song := seqSong(2, [4]int{1, 3, 0, effect(tracker.FxJump, 0)})
+	song.Patterns[0].Notes[0][0] = tracker.Note{Pitch: 48, Instrument: 1, Volume: -1}
+	opts := ExportOptions{Format: FormatWAVFloat, TargetLUFS: -20, Loops: 3, Fade: 3}
+	f = &memFile{}
+	if err := Export(NewPlayer(song), f, opts); err != nil {
+		t.Fatal(err)
+	}
+	out := wavFloats(t, f.data)
+	meter := NewLoudnessMeter(44100)
+	meter.Gated = true
+	for i := 0; i+1 < len(out); i += 2 {
+		meter.Process(out[i], out[i+1])
+	}
+	if got := meter.Integrated(); math.Abs(got-opts.TargetLUFS) > 0.5 {
+		t.Errorf("export is %.2f LUFS, want %g", got, opts.TargetLUFS)
+	}
+}
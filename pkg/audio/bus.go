@@ -0,0 +1,195 @@
+package audio
+
+import (
+	"math"
+
+	"github.com/anthropics/abytetracker/pkg/tracker"
+)
+
+// delayLine is a ring buffer that grows on demand, so long delays
+// never wrap around onto recent samples
+type delayLine struct {
+	buf []float64
+	pos int // Next write position
+}
+
+func newDelayLine(size int) *delayLine {
+	if size < 1 {
+		size = 1
+	}
+	return &delayLine{buf: make([]float64, size)}
+}
+
+// write stores the next sample
+func (d *delayLine) write(x float64) {
+	d.buf[d.pos] = x
+	d.pos++
+	if d.pos >= len(d.buf) {
+		d.pos = 0
+	}
+}
+
+// read returns the sample written delay samples ago (1 = last written)
+func (d *delayLine) read(delay int) float64 {
+	if delay <= 0 || delay > len(d.buf) {
+		return 0
+	}
+	idx := d.pos - delay
+	if idx < 0 {
+		idx += len(d.buf)
+	}
+	return d.buf[idx]
+}
+
+// grow makes room for at least size samples, keeping the history in order
+func (d *delayLine) grow(size int) {
+	if size <= len(d.buf) {
+		return
+	}
+	buf := make([]float64, size)
+	// Oldest samples first: buf[pos:] then buf[:pos]
+	n := copy(buf, d.buf[d.pos:])
+	copy(buf[n:], d.buf[:d.pos])
+	d.pos = len(d.buf)
+	d.buf = buf
+}
+
+// FeedbackDelay is a tempo-synced stereo delay with damping and ping-pong
+type FeedbackDelay struct {
+	left, right *delayLine
+	dampL       float64 // One-pole low-pass state in the feedback path
+	dampR       float64
+	Samples     int // Current delay time in samples
+}
+
+// NewFeedbackDelay creates a delay sized for the given sample rate
+func NewFeedbackDelay(sampleRate int) *FeedbackDelay {
+	return &FeedbackDelay{
+		left:  newDelayLine(sampleRate),
+		right: newDelayLine(sampleRate),
+	}
+}
+
+// SetDelay sets the delay time, growing the buffers if needed
+func (d *FeedbackDelay) SetDelay(samples int) {
+	d.left.grow(samples)
+	d.right.grow(samples)
+	d.Samples = samples
+}
+
+// Process feeds one stereo input frame and returns the wet output
+func (d *FeedbackDelay) Process(inL, inR float64, cfg *tracker.BusConfig) (float64, float64) {
+	if d.Samples <= 0 {
+		return 0, 0
+	}
+	outL := d.left.read(d.Samples)
+	outR := d.right.read(d.Samples)
+
+	// Damp the repeats
+	damp := float64(cfg.DelayDamping) / 64.0 * 0.9
+	d.dampL += (outL - d.dampL) * (1.0 - damp)
+	d.dampR += (outR - d.dampR) * (1.0 - damp)
+
+	fb := float64(cfg.DelayFeedback) / 64.0 * 0.95
+	if cfg.PingPong {
+		// Mono input enters on the left, repeats bounce between sides
+		d.left.write((inL+inR)/2 + d.dampR*fb)
+		d.right.write(d.dampL * fb)
+	} else {
+		d.left.write(inL + d.dampL*fb)
+		d.right.write(inR + d.dampR*fb)
+	}
+
+	level := float64(cfg.DelayLevel) / 64.0
+	return outL * level, outR * level
+}
+
+// Comb and all-pass tunings (samples at 44.1 kHz), after Freeverb
+var (
+	reverbCombTuning    = []int{1116, 1188, 1277, 1356}
+	reverbAllpassTuning = []int{556, 441}
+)
+
+// reverbStereoSpread offsets the right channel tunings
+const reverbStereoSpread = 23
+
+type reverbComb struct {
+	line  *delayLine
+	size  int
+	store float64
+}
+
+type reverbAllpass struct {
+	line *delayLine
+	size int
+}
+
+// Reverb is a small Schroeder/Freeverb-style stereo reverb
+type Reverb struct {
+	combL, combR       []reverbComb
+	allpassL, allpassR []reverbAllpass
+}
+
+// NewReverb creates a reverb scaled for the given sample rate
+func NewReverb(sampleRate int) *Reverb {
+	scale := float64(sampleRate) / 44100.0
+	r := &Reverb{}
+	for _, t := range reverbCombTuning {
+		l := int(float64(t) * scale)
+		rr := int(float64(t+reverbStereoSpread) * scale)
+		r.combL = append(r.combL, reverbComb{line: newDelayLine(l), size: l})
+		r.combR = append(r.combR, reverbComb{line: newDelayLine(rr), size: rr})
+	}
+	for _, t := range reverbAllpassTuning {
+		l := int(float64(t) * scale)
+		rr := int(float64(t+reverbStereoSpread) * scale)
+		r.allpassL = append(r.allpassL, reverbAllpass{line: newDelayLine(l), size: l})
+		r.allpassR = append(r.allpassR, reverbAllpass{line: newDelayLine(rr), size: rr})
+	}
+	return r
+}
+
+// Process feeds one stereo input frame and returns the wet output
+func (r *Reverb) Process(inL, inR float64, cfg *tracker.BusConfig) (float64, float64) {
+	feedback := 0.7 + float64(cfg.ReverbSize)/64.0*0.28
+	damp := float64(cfg.ReverbDamping) / 64.0 * 0.7
+	in := (inL + inR) * 0.5 * 0.2 // Fixed input gain keeps the tail in range
+
+	outL := processReverbSide(r.combL, r.allpassL, in, feedback, damp)
+	outR := processReverbSide(r.combR, r.allpassR, in, feedback, damp)
+
+	level := float64(cfg.ReverbLevel) / 64.0
+	return outL * level, outR * level
+}
+
+func processReverbSide(combs []reverbComb, allpasses []reverbAllpass, in, feedback, damp float64) float64 {
+	var out float64
+	for i := range combs {
+		c := &combs[i]
+		y := c.line.read(c.size)
+		c.store = y*(1-damp) + c.store*damp
+		c.line.write(in + c.store*feedback)
+		out += y
+	}
+	for i := range allpasses {
+		a := &allpasses[i]
+		buffered := a.line.read(a.size)
+		a.line.write(out + buffered*0.5)
+		out = buffered - out
+	}
+	return out
+}
+
+// panGains returns left/right gains for a pan value (-64 left to +64 right).
+// Center pan gives unity on both sides.
+func panGains(pan int8) (float64, float64) {
+	p := float64(pan)
+	if p < -64 {
+		p = -64
+	}
+	if p > 64 {
+		p = 64
+	}
+	angle := (p + 64) / 128 * math.Pi / 2
+	return math.Cos(angle) * math.Sqrt2, math.Sin(angle) * math.Sqrt2
+}
@@ -0,0 +1,281 @@
+package audio
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Bytebeat formulas are C-style integer expressions of t: numbers
+// (decimal or 0x hex), t, parentheses, the unary operators - ~ !, the
+// binary operators * / % + - << >> < <= > >= == != & ^ | && || and
+// a ? b : c. Arithmetic is on 32-bit integers; division by zero gives 0
+// and shift counts use their low 5 bits, as in JavaScript bytebeat.
+
+// bytebeatFunc evaluates a compiled formula at time t
+type bytebeatFunc func(t int32) int32
+
+// bytebeatCache holds compiled formulas by source (nil if invalid), so
+// notes do not parse them again on the audio thread
+var bytebeatCache sync.Map
+
+// cachedBytebeat returns the compiled formula, or nil if it is invalid
+func cachedBytebeat(src string) bytebeatFunc {
+	if f, ok := bytebeatCache.Load(src); ok {
+		return f.(bytebeatFunc)
+	}
+	f, _ := compileBytebeat(src)
+	bytebeatCache.Store(src, f)
+	return f
+}
+
+// CheckFormula reports whether a bytebeat formula compiles
+func CheckFormula(src string) error {
+	_, err := compileBytebeat(src)
+	return err
+}
+
+// compileBytebeat parses a formula into a function of t
+func compileBytebeat(src string) (bytebeatFunc, error) {
+	toks, err := tokenizeBytebeat(src)
+	if err != nil {
+		return nil, err
+	}
+	if len(toks) == 0 {
+		return nil, fmt.Errorf("empty formula")
+	}
+	p := &bytebeatParser{toks: toks}
+	f, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.toks) {
+		return nil, fmt.Errorf("unexpected %q", p.toks[p.pos])
+	}
+	return f, nil
+}
+
+// bytebeatOps lists the operators, longest first so "<<" is not read as "<"
+var bytebeatOps = []string{
+	"<<", ">>", "<=", ">=", "==", "!=", "&&", "||",
+	"+", "-", "*", "/", "%", "&", "|", "^", "~", "!", "<", ">", "?", ":", "(", ")",
+}
+
+// tokenizeBytebeat splits a formula into numbers, t and operators
+func tokenizeBytebeat(src string) ([]string, error) {
+	var toks []string
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == 't':
+			toks = append(toks, "t")
+			i++
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(src) && (isDigit(src[j]) || src[j] == 'x' || src[j] == 'X' ||
+				(src[j]|0x20 >= 'a' && src[j]|0x20 <= 'f')) {
+				j++
+			}
+			toks = append(toks, src[i:j])
+			i = j
+		default:
+			op := ""
+			for _, o := range bytebeatOps {
+				if strings.HasPrefix(src[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected %q in formula", c)
+			}
+			toks = append(toks, op)
+			i += len(op)
+		}
+	}
+	return toks, nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// bytebeatParser is a recursive descent parser over formula tokens
+type bytebeatParser struct {
+	toks []string
+	pos  int
+}
+
+// bytebeatLevels are the binary operators from lowest to highest precedence
+var bytebeatLevels = [][]string{
+	{"||"}, {"&&"}, {"|"}, {"^"}, {"&"}, {"==", "!="},
+	{"<", "<=", ">", ">="}, {"<<", ">>"}, {"+", "-"}, {"*", "/", "%"},
+}
+
+// peek returns the next token, or "" at the end
+func (p *bytebeatParser) peek() string {
+	if p.pos < len(p.toks) {
+		return p.toks[p.pos]
+	}
+	return ""
+}
+
+// ternary parses a ? b : c (right associative)
+func (p *bytebeatParser) ternary() (bytebeatFunc, error) {
+	cond, err := p.binary(0)
+	if err != nil || p.peek() != "?" {
+		return cond, err
+	}
+	p.pos++
+	a, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() != ":" {
+		return nil, fmt.Errorf("missing : after ?")
+	}
+	p.pos++
+	b, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	return func(t int32) int32 {
+		if cond(t) != 0 {
+			return a(t)
+		}
+		return b(t)
+	}, nil
+}
+
+// binary parses the left-associative operators of a precedence level
+func (p *bytebeatParser) binary(level int) (bytebeatFunc, error) {
+	if level == len(bytebeatLevels) {
+		return p.unary()
+	}
+	left, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek()
+		found := false
+		for _, o := range bytebeatLevels[level] {
+			if op == o {
+				found = true
+			}
+		}
+		if !found {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = bytebeatBinary(op, left, right)
+	}
+}
+
+// unary parses prefix operators, parentheses, numbers and t
+func (p *bytebeatParser) unary() (bytebeatFunc, error) {
+	tok := p.peek()
+	p.pos++
+	switch tok {
+	case "":
+		return nil, fmt.Errorf("formula ends early")
+	case "-", "~", "!", "+":
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		switch tok {
+		case "-":
+			return func(t int32) int32 { return -x(t) }, nil
+		case "~":
+			return func(t int32) int32 { return ^x(t) }, nil
+		case "!":
+			return func(t int32) int32 { return boolInt(x(t) == 0) }, nil
+		}
+		return x, nil
+	case "(":
+		x, err := p.ternary()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return x, nil
+	case "t":
+		return func(t int32) int32 { return t }, nil
+	}
+	n, err := strconv.ParseInt(tok, 0, 64)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected %q", tok)
+	}
+	v := int32(n)
+	return func(int32) int32 { return v }, nil
+}
+
+// bytebeatBinary combines two operands with a binary operator
+func bytebeatBinary(op string, a, b bytebeatFunc) bytebeatFunc {
+	switch op {
+	case "*":
+		return func(t int32) int32 { return a(t) * b(t) }
+	case "/":
+		return func(t int32) int32 {
+			if d := b(t); d != 0 {
+				return a(t) / d
+			}
+			return 0
+		}
+	case "%":
+		return func(t int32) int32 {
+			if d := b(t); d != 0 {
+				return a(t) % d
+			}
+			return 0
+		}
+	case "+":
+		return func(t int32) int32 { return a(t) + b(t) }
+	case "-":
+		return func(t int32) int32 { return a(t) - b(t) }
+	case "<<":
+		return func(t int32) int32 { return a(t) << (uint32(b(t)) & 31) }
+	case ">>":
+		return func(t int32) int32 { return a(t) >> (uint32(b(t)) & 31) }
+	case "<":
+		return func(t int32) int32 { return boolInt(a(t) < b(t)) }
+	case "<=":
+		return func(t int32) int32 { return boolInt(a(t) <= b(t)) }
+	case ">":
+		return func(t int32) int32 { return boolInt(a(t) > b(t)) }
+	case ">=":
+		return func(t int32) int32 { return boolInt(a(t) >= b(t)) }
+	case "==":
+		return func(t int32) int32 { return boolInt(a(t) == b(t)) }
+	case "!=":
+		return func(t int32) int32 { return boolInt(a(t) != b(t)) }
+	case "&":
+		return func(t int32) int32 { return a(t) & b(t) }
+	case "^":
+		return func(t int32) int32 { return a(t) ^ b(t) }
+	case "|":
+		return func(t int32) int32 { return a(t) | b(t) }
+	case "&&":
+		return func(t int32) int32 { return boolInt(a(t) != 0 && b(t) != 0) }
+	default: // "||"
+		return func(t int32) int32 { return boolInt(a(t) != 0 || b(t) != 0) }
+	}
+}
+
+func boolInt(b bool) int32 {
+	if b {
+		return 1
+	}
+	return 0
+}
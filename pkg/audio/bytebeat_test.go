@@ -0,0 +1,118 @@
+package audio
+
+import (
+	"math"
+	"testing"
+
+	"github.com/anthropics/abytetracker/pkg/tracker"
+)
+
+func TestCompileBytebeat(t *testing.T) {
+	tests := []struct {
+		formula string
+		t       int32
+		want    int32
+	}{
+		{"t", 300, 300},
+		{"0x1F & t", 100, 4},
+		{"t*(t>>5|t>>8)", 1000, 1000 * (1000>>5 | 1000>>8)},
+		{"1+2*3", 0, 7},
+		{"(1+2)*3", 0, 9},
+		{"t>>2&3", 13, 3},  // >> binds tighter than &
+		{"1|2^3&4", 0, 3},  // & before ^ before |
+		{"t<3 == 1", 2, 1}, // < before ==
+		{"-t%7", 10, -3},   // Go and C truncate towards zero
+		{"~t", 0, -1},
+		{"!t + !0", 5, 1},
+		{"t/0 + t%0", 9, 0}, // division by zero gives 0
+		{"1<<33", 0, 2},     // shift counts wrap at 32
+		{"t>64 ? t>128 ? 2 : 1 : 0", 100, 1},
+		{"t && 0 || 4", 1, 1},
+		{"0x7FFFFFFF+1", 0, math.MinInt32},
+	}
+	for _, tt := range tests {
+		f, err := compileBytebeat(tt.formula)
+		if err != nil {
+			t.Errorf("%q: %v", tt.formula, err)
+			continue
+		}
+		if got := f(tt.t); got != tt.want {
+			t.Errorf("%q at t=%d = %d, want %d", tt.formula, tt.t, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "t+", "(t", "t)", "t ? 1", "x", "1 2", "0xZZ", "sin(t)"} {
+		if err := CheckFormula(bad); err == nil {
+			t.Errorf("%q compiled", bad)
+		}
+	}
+}
+
+func TestBytebeatGenerator(t *testing.T) {
+	// "t" is a sawtooth at the note's pitch: 256 steps per cycle
+	cs := NewChannelState(44100)
+	inst := tracker.DefaultInstrument()
+	inst.Generator = tracker.GenBytebeat
+	inst.Formula = "t"
+	cs.TriggerNote(57, &inst, -1)
+	cs.Oscillator.Frequency = 441 // 100 samples per cycle
+
+	var prev float64
+	for i := range 200 {
+		s := cs.Oscillator.Sample()
+		if i%100 != 0 && s < prev {
+			t.Fatalf("sample %d: %g falls from %g inside a cycle", i, s, prev)
+		}
+		prev = s
+	}
+
+	inst.Formula = "t+"
+	cs.TriggerNote(57, &inst, -1)
+	if s := cs.Oscillator.Sample(); s != 0 {
+		t.Errorf("invalid formula plays %g, want silence", s)
+	}
+}
+
+func TestInstrumentDetuneAndLoop(t *testing.T) {
+	cs := NewChannelState(44100)
+	inst := tracker.DefaultInstrument()
+	inst.Detune = 32 // Half a semitone up
+	cs.TriggerNote(57, &inst, -1)
+	if want := 440 * math.Pow(2, 0.5/12); math.Abs(cs.Frequency-want) > 1e-9 {
+		t.Errorf("detuned A-4 at %g Hz, want %g", cs.Frequency, want)
+	}
+	cs.ProcessOrnament(&tracker.Ornament{Loop: 0, Values: []int8{12}})
+	if want := 880 * math.Pow(2, 0.5/12); math.Abs(cs.Frequency-want) > 1e-9 {
+		t.Errorf("ornament ignores the detune: %g Hz, want %g", cs.Frequency, want)
+	}
+
+	// A looping envelope rises from the sustain level again after the
+	// decay, instead of holding it
+	inst.Envelope = tracker.Envelope{Attack: 4, Decay: 4, Sustain: 16, Release: 10, Loop: true}
+	cs.TriggerNote(57, &inst, -1)
+	var levels []float64
+	for range 24 {
+		levels = append(levels, cs.ProcessEnvelope(&inst.Envelope, 882))
+	}
+	peaks := 0
+	for _, v := range levels {
+		if v == 1 {
+			peaks++
+		}
+	}
+	if peaks < 3 {
+		t.Errorf("looping envelope peaked %d times in 24 ticks: %v", peaks, levels)
+	}
+	if levels[len(levels)-1] < 0.25-1e-9 {
+		t.Errorf("looping envelope fell below its sustain level: %v", levels)
+	}
+
+	inst.Envelope.Loop = false
+	cs.TriggerNote(57, &inst, -1)
+	for range 24 {
+		cs.ProcessEnvelope(&inst.Envelope, 882)
+	}
+	if cs.EnvPhase != 2 || cs.Volume != 0.25 {
+		t.Errorf("envelope without loop in phase %d at %g, want sustain at 0.25", cs.EnvPhase, cs.Volume)
+	}
+}
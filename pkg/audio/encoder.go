@@ -0,0 +1,168 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/anthropics/abytetracker/pkg/tracker"
+)
+
+// Encoder writes interleaved float samples (-1..1) to an audio file.
+// Encoders stream: headers are written up front with placeholder sizes
+// and patched by Close when the destination is an io.WriteSeeker.
+type Encoder interface {
+	WriteSamples(samples []float64) error
+	Close() error
+}
+
+// Tags is the metadata written into exported files
+type Tags struct {
+	Title  string
+	Author string
+}
+
+// SongTags returns the tags for a song
+func SongTags(song *tracker.Song) Tags {
+	return Tags{Title: song.Title, Author: song.Author}
+}
+
+// vendor identifies the encoder in FLAC and Vorbis comment headers
+const vendor = "abytetracker"
+
+// vorbisComment returns a Vorbis comment block (as used by FLAC and
+// Vorbis) with the tags
+func vorbisComment(tags Tags) []byte {
+	var fields []string
+	if tags.Title != "" {
+		fields = append(fields, "TITLE="+tags.Title)
+	}
+	if tags.Author != "" {
+		fields = append(fields, "ARTIST="+tags.Author)
+	}
+	le := binary.LittleEndian
+	b := le.AppendUint32(nil, uint32(len(vendor)))
+	b = append(b, vendor...)
+	b = le.AppendUint32(b, uint32(len(fields)))
+	for _, f := range fields {
+		b = le.AppendUint32(b, uint32(len(f)))
+		b = append(b, f...)
+	}
+	return b
+}
+
+// Format selects an export file format
+type Format int
+
+const (
+	FormatWAV16    Format = iota // 16-bit PCM WAV
+	FormatWAV24                  // 24-bit PCM WAV
+	FormatWAVFloat               // 32-bit float WAV
+	FormatFLAC                   // 16-bit FLAC
+	FormatOggFLAC                // 16-bit FLAC in an Ogg container
+	FormatVorbis                 // Ogg Vorbis (lossy)
+)
+
+var formatNames = map[Format]string{
+	FormatWAV16:    "wav",
+	FormatWAV24:    "wav24",
+	FormatWAVFloat: "wav32f",
+	FormatFLAC:     "flac",
+	FormatOggFLAC:  "oga",
+	FormatVorbis:   "ogg",
+}
+
+// String returns the format name used on the command line
+func (f Format) String() string {
+	if name, ok := formatNames[f]; ok {
+		return name
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// Ext returns the file extension for the format
+func (f Format) Ext() string {
+	switch f {
+	case FormatFLAC:
+		return ".flac"
+	case FormatOggFLAC:
+		return ".oga"
+	case FormatVorbis:
+		return ".ogg"
+	default:
+		return ".wav"
+	}
+}
+
+// ParseFormat parses a format name (wav, wav24, wav32f, flac, oga, ogg)
+func ParseFormat(name string) (Format, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for f, n := range formatNames {
+		if n == name {
+			return f, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown export format %q", name)
+}
+
+// NewEncoder creates an encoder for the given format
+func NewEncoder(format Format, w io.Writer, sampleRate, channels int, tags Tags) (Encoder, error) {
+	switch format {
+	case FormatWAV16:
+		return newWAVEncoder(w, sampleRate, channels, 16, false, tags), nil
+	case FormatWAV24:
+		return newWAVEncoder(w, sampleRate, channels, 24, false, tags), nil
+	case FormatWAVFloat:
+		return newWAVEncoder(w, sampleRate, channels, 32, true, tags), nil
+	case FormatFLAC:
+		return newFLACEncoder(w, sampleRate, channels, 16, tags, false)
+	case FormatOggFLAC:
+		return newFLACEncoder(w, sampleRate, channels, 16, tags, true)
+	case FormatVorbis:
+		return newVorbisEncoder(w, sampleRate, channels, tags)
+	}
+	return nil, fmt.Errorf("unknown export format %d", int(format))
+}
+
+// quantize converts a float sample to a signed integer of the given width
+func quantize(s float64, bits int) int32 {
+	if s > 1.0 {
+		s = 1.0
+	}
+	if s < -1.0 {
+		s = -1.0
+	}
+	return int32(s * float64(int32(1)<<(bits-1)-1))
+}
+
+// tell returns the current offset of w, or -1 if w cannot seek
+func tell(w io.Writer) int64 {
+	ws, ok := w.(io.WriteSeeker)
+	if !ok {
+		return -1
+	}
+	pos, err := ws.Seek(0, io.SeekCurrent)
+	if err != nil {
+		// Not actually seekable (e.g. a pipe)
+		return -1
+	}
+	return pos
+}
+
+// patch overwrites data at offset in a seekable writer, then returns to the end
+func patch(w io.Writer, offset int64, data []byte) error {
+	ws := w.(io.WriteSeeker)
+	end, err := ws.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := ws.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := ws.Write(data); err != nil {
+		return err
+	}
+	_, err = ws.Seek(end, io.SeekStart)
+	return err
+}
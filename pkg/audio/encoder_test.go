@@ -0,0 +1,552 @@
+package audio
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"io"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// memFile is an in-memory io.WriteSeeker
+type memFile struct {
+	data []byte
+	pos  int
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	if end := f.pos + len(p); end > len(f.data) {
+		f.data = append(f.data, make([]byte, end-len(f.data))...)
+	}
+	copy(f.data[f.pos:], p)
+	f.pos += len(p)
+	return len(p), nil
+}
+
+func (f *memFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += int64(f.pos)
+	case io.SeekEnd:
+		offset += int64(len(f.data))
+	}
+	f.pos = int(offset)
+	return offset, nil
+}
+
+// testSignal returns interleaved samples with a tone on channel 0, and on
+// channel 1 a block of silence followed by noise, so every FLAC subframe
+// type is used
+func testSignal(frames, channels int) []float64 {
+	rng := rand.New(rand.NewSource(1))
+	s := make([]float64, frames*channels)
+	for i := 0; i < frames; i++ {
+		s[i*channels] = 0.8 * math.Sin(float64(i)*0.05)
+		if channels > 1 && i >= flacBlockSize {
+			s[i*channels+1] = rng.Float64()*2 - 1
+		}
+	}
+	return s
+}
+
+func encode(t *testing.T, format Format, w io.Writer, channels int, samples []float64, tags Tags) {
+	t.Helper()
+	enc, err := NewEncoder(format, w, 44100, channels, tags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Uneven writes, so blocks straddle calls
+	for len(samples) > 0 {
+		n := min(len(samples), 1000*channels)
+		if err := enc.WriteSamples(samples[:n]); err != nil {
+			t.Fatal(err)
+		}
+		samples = samples[n:]
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWAVRoundTrip(t *testing.T) {
+	tags := Tags{Title: "Song", Author: "Me"}
+	tests := []struct {
+		format   Format
+		channels int
+		bits     int
+		code     uint16
+		step     float64 // Largest error allowed per sample
+	}{
+		{FormatWAV16, 2, 16, 1, 1.0 / 32767},
+		{FormatWAV24, 1, 24, 1, 1.0 / 8388607}, // Odd data size, padded
+		{FormatWAVFloat, 2, 32, 3, 1e-7},
+	}
+	le := binary.LittleEndian
+	for _, tt := range tests {
+		const frames = 1001
+		in := testSignal(frames, tt.channels)
+		f := &memFile{}
+		encode(t, tt.format, f, tt.channels, in, tags)
+		data := f.data
+
+		if string(data[:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+			t.Fatalf("%v: not a RIFF/WAVE file", tt.format)
+		}
+		if size := le.Uint32(data[4:]); int(size) != len(data)-8 {
+			t.Errorf("%v: RIFF size %d, file is %d bytes", tt.format, size, len(data))
+		}
+
+		blockAlign := tt.channels * tt.bits / 8
+		chunks := map[string][]byte{}
+		for p := 12; p+8 <= len(data); {
+			id, size := string(data[p:p+4]), int(le.Uint32(data[p+4:]))
+			chunks[id] = data[p+8 : p+8+size]
+			p += 8 + size + size%2
+		}
+
+		fmtc := chunks["fmt "]
+		if le.Uint16(fmtc[0:]) != tt.code || int(le.Uint16(fmtc[2:])) != tt.channels ||
+			le.Uint32(fmtc[4:]) != 44100 || int(le.Uint32(fmtc[8:])) != 44100*blockAlign ||
+			int(le.Uint16(fmtc[12:])) != blockAlign || int(le.Uint16(fmtc[14:])) != tt.bits {
+			t.Errorf("%v: bad fmt chunk % x", tt.format, fmtc)
+		}
+		if fact, ok := chunks["fact"]; tt.format == FormatWAVFloat && (!ok || le.Uint32(fact) != frames) {
+			t.Errorf("%v: fact chunk %v, want %d frames", tt.format, fact, frames)
+		}
+		info := chunks["LIST"]
+		if !bytes.HasPrefix(info, []byte("INFO")) || !bytes.Contains(info, []byte("INAM\x05\x00\x00\x00Song\x00")) ||
+			!bytes.Contains(info, []byte("IART\x03\x00\x00\x00Me\x00")) {
+			t.Errorf("%v: bad LIST/INFO chunk %q", tt.format, info)
+		}
+
+		pcm := chunks["data"]
+		if len(pcm) != frames*blockAlign {
+			t.Fatalf("%v: data chunk %d bytes, want %d", tt.format, len(pcm), frames*blockAlign)
+		}
+		for i, want := range in {
+			var got float64
+			switch tt.bits {
+			case 16:
+				got = float64(int16(le.Uint16(pcm[i*2:]))) / 32767
+			case 24:
+				v := int32(pcm[i*3]) | int32(pcm[i*3+1])<<8 | int32(int8(pcm[i*3+2]))<<16
+				got = float64(v) / 8388607
+			case 32:
+				got = float64(math.Float32frombits(le.Uint32(pcm[i*4:])))
+			}
+			if math.Abs(got-want) > tt.step {
+				t.Fatalf("%v: sample %d = %g, want %g", tt.format, i, got, want)
+			}
+		}
+	}
+
+	// Without seeking the sizes stay unknown
+	var buf bytes.Buffer
+	encode(t, FormatWAV16, &buf, 2, testSignal(10, 2), Tags{})
+	if le.Uint32(buf.Bytes()[4:]) != 0xFFFFFFFF || le.Uint32(buf.Bytes()[40:]) != 0xFFFFFFFF {
+		t.Error("streamed WAV without placeholder sizes")
+	}
+}
+
+// bitReader reads big-endian bit fields
+type bitReader struct {
+	b   []byte
+	pos int // In bits
+}
+
+func (r *bitReader) read(n int) uint64 {
+	var v uint64
+	for ; n > 0; n-- {
+		v = v<<1 | uint64(r.b[r.pos/8]>>(7-r.pos%8)&1)
+		r.pos++
+	}
+	return v
+}
+
+func (r *bitReader) signed(n int) int64 {
+	return int64(r.read(n)<<(64-n)) >> (64 - n)
+}
+
+func (r *bitReader) align() {
+	r.pos = (r.pos + 7) / 8 * 8
+}
+
+// decodeFLACFrame decodes one frame of the subset the encoder writes,
+// checking both CRCs. It returns the samples per channel and the frame
+// length in bytes.
+func decodeFLACFrame(t *testing.T, data []byte, channels, bps int, frameNum uint64) ([][]int32, int) {
+	t.Helper()
+	r := &bitReader{b: data}
+	if r.read(14) != 0x3FFE || r.read(2) != 0 {
+		t.Fatalf("frame %d: bad sync", frameNum)
+	}
+	sizeCode := r.read(4)
+	if r.read(4) != flacRateCodes[44100] || int(r.read(4)) != channels-1 ||
+		r.read(3) != flacSizeCodes[bps] || r.read(1) != 0 {
+		t.Fatalf("frame %d: bad header", frameNum)
+	}
+	num := r.read(8)
+	if ones := leadingOnes(byte(num)); ones > 0 {
+		num &= 0xFF >> (ones + 1)
+		for ; ones > 1; ones-- {
+			num = num<<6 | r.read(8)&0x3F
+		}
+	}
+	if num != frameNum {
+		t.Fatalf("frame number %d, want %d", num, frameNum)
+	}
+	n := flacBlockSize
+	switch sizeCode {
+	case 6:
+		n = int(r.read(8)) + 1
+	case 7:
+		n = int(r.read(16)) + 1
+	}
+	if crc := crc8(data[:r.pos/8]); uint8(r.read(8)) != crc {
+		t.Fatalf("frame %d: header CRC mismatch", frameNum)
+	}
+
+	out := make([][]int32, channels)
+	for ch := range out {
+		x := make([]int32, n)
+		r.read(1)
+		typ := int(r.read(6))
+		r.read(1)
+		switch {
+		case typ == 0:
+			v := int32(r.signed(bps))
+			for i := range x {
+				x[i] = v
+			}
+		case typ == 1:
+			for i := range x {
+				x[i] = int32(r.signed(bps))
+			}
+		case typ >= 8 && typ <= 12:
+			order := typ - 8
+			for i := 0; i < order; i++ {
+				x[i] = int32(r.signed(bps))
+			}
+			if r.read(2) != 0 || r.read(4) != 0 {
+				t.Fatalf("frame %d: unexpected residual coding", frameNum)
+			}
+			k := int(r.read(4))
+			res := make([]int64, n)
+			for i := order; i < n; i++ {
+				var q uint64
+				for r.read(1) == 0 {
+					q++
+				}
+				u := q<<k | r.read(k)
+				res[i] = int64(u>>1) ^ -int64(u&1)
+			}
+			for i := order; i < n; i++ {
+				var p int64
+				switch order {
+				case 1:
+					p = int64(x[i-1])
+				case 2:
+					p = 2*int64(x[i-1]) - int64(x[i-2])
+				case 3:
+					p = 3*int64(x[i-1]) - 3*int64(x[i-2]) + int64(x[i-3])
+				case 4:
+					p = 4*int64(x[i-1]) - 6*int64(x[i-2]) + 4*int64(x[i-3]) - int64(x[i-4])
+				}
+				x[i] = int32(p + res[i])
+			}
+		default:
+			t.Fatalf("frame %d: subframe type %d", frameNum, typ)
+		}
+		out[ch] = x
+	}
+	r.align()
+	if crc := crc16(data[:r.pos/8]); uint16(r.read(16)) != crc {
+		t.Fatalf("frame %d: frame CRC mismatch", frameNum)
+	}
+	return out, r.pos / 8
+}
+
+// leadingOnes counts the leading one bits of a UTF-8 style length byte
+func leadingOnes(b byte) int {
+	n := 0
+	for b&0x80 != 0 {
+		n++
+		b <<= 1
+	}
+	return n
+}
+
+// checkFLACStream checks STREAMINFO against the decoded frames and the
+// samples that were encoded
+func checkFLACStream(t *testing.T, info []byte, frames [][]byte, in []float64, channels int) {
+	t.Helper()
+	be := binary.BigEndian
+	var pcm []byte
+	var decoded []int32
+	minFrame, maxFrame := 0, 0
+	for i, data := range frames {
+		samples, n := decodeFLACFrame(t, data, channels, 16, uint64(i))
+		if n != len(data) {
+			t.Fatalf("frame %d: %d bytes decoded of %d", i, n, len(data))
+		}
+		if minFrame == 0 || n < minFrame {
+			minFrame = n
+		}
+		maxFrame = max(maxFrame, n)
+		for j := range samples[0] {
+			for ch := range samples {
+				decoded = append(decoded, samples[ch][j])
+				pcm = binary.LittleEndian.AppendUint16(pcm, uint16(samples[ch][j]))
+			}
+		}
+	}
+	if len(decoded) != len(in) {
+		t.Fatalf("decoded %d samples, want %d", len(decoded), len(in))
+	}
+	for i, s := range in {
+		if decoded[i] != quantize(s, 16) {
+			t.Fatalf("sample %d = %d, want %d", i, decoded[i], quantize(s, 16))
+		}
+	}
+
+	packed := be.Uint64(info[10:])
+	if be.Uint16(info[0:]) != flacBlockSize || be.Uint16(info[2:]) != flacBlockSize ||
+		int(info[4])<<16|int(info[5])<<8|int(info[6]) != minFrame ||
+		int(info[7])<<16|int(info[8])<<8|int(info[9]) != maxFrame {
+		t.Errorf("STREAMINFO block/frame sizes % x, want frames %d..%d", info[:10], minFrame, maxFrame)
+	}
+	if packed>>44 != 44100 || int(packed>>41&7) != channels-1 || packed>>36&31 != 15 ||
+		int(packed&(1<<36-1)) != len(in)/channels {
+		t.Errorf("STREAMINFO %x: wrong rate, channels, bits or total", packed)
+	}
+	if sum := md5.Sum(pcm); !bytes.Equal(info[18:34], sum[:]) {
+		t.Errorf("STREAMINFO MD5 %x, want %x", info[18:34], sum)
+	}
+}
+
+// checkVorbisComment checks the vendor string and tags of a comment block
+func checkVorbisComment(t *testing.T, c []byte, tags ...string) {
+	t.Helper()
+	le := binary.LittleEndian
+	n := int(le.Uint32(c))
+	if string(c[4:4+n]) != vendor {
+		t.Errorf("vendor %q", c[4:4+n])
+	}
+	c = c[4+n:]
+	if int(le.Uint32(c)) != len(tags) {
+		t.Fatalf("%d comments, want %d", le.Uint32(c), len(tags))
+	}
+	c = c[4:]
+	for _, tag := range tags {
+		n := int(le.Uint32(c))
+		if string(c[4:4+n]) != tag {
+			t.Errorf("comment %q, want %q", c[4:4+n], tag)
+		}
+		c = c[4+n:]
+	}
+}
+
+func TestFLACRoundTrip(t *testing.T) {
+	const channels = 2
+	in := testSignal(2*flacBlockSize+100, channels)
+	f := &memFile{}
+	encode(t, FormatFLAC, f, channels, in, Tags{Title: "Song", Author: "Me"})
+	data := f.data
+
+	if string(data[:4]) != "fLaC" {
+		t.Fatal("missing fLaC marker")
+	}
+	blockLen := func(h []byte) int { return int(h[1])<<16 | int(h[2])<<8 | int(h[3]) }
+	info := data[8 : 8+blockLen(data[4:])]
+	if data[4] != flacStreamInfo || len(info) != 34 {
+		t.Fatalf("first metadata block %x of %d bytes", data[4], len(info))
+	}
+	p := 8 + len(info)
+	if data[p] != 0x80|flacVorbisComment {
+		t.Fatalf("second metadata block %x, want the last, a comment", data[p])
+	}
+	comment := data[p+4 : p+4+blockLen(data[p:])]
+	checkVorbisComment(t, comment, "TITLE=Song", "ARTIST=Me")
+
+	// Split the stream into frames by decoding them in turn
+	var frames [][]byte
+	for rest := data[p+4+len(comment):]; len(rest) > 0; {
+		_, n := decodeFLACFrame(t, rest, channels, 16, uint64(len(frames)))
+		frames = append(frames, rest[:n])
+		rest = rest[n:]
+	}
+	if len(frames) != 3 {
+		t.Fatalf("%d frames, want 3", len(frames))
+	}
+	checkFLACStream(t, info, frames, in, channels)
+}
+
+// oggPackets checks the Ogg framing of a single stream and returns its
+// packets and the final granule position
+func oggPackets(t *testing.T, data []byte) ([][]byte, int64) {
+	t.Helper()
+	le := binary.LittleEndian
+	var packets [][]byte
+	var packet []byte
+	var pages int
+	var lastGranule int64
+	for p := 0; p < len(data); pages++ {
+		if string(data[p:p+4]) != "OggS" || data[p+4] != 0 {
+			t.Fatalf("page %d: bad capture pattern or version", pages)
+		}
+		flags := data[p+5]
+		granule := int64(le.Uint64(data[p+6:]))
+		if le.Uint32(data[p+14:]) != oggSerial || int(le.Uint32(data[p+18:])) != pages {
+			t.Errorf("page %d: serial %x, sequence %d", pages, le.Uint32(data[p+14:]), le.Uint32(data[p+18:]))
+		}
+		nsegs := int(data[p+26])
+		segs := data[p+27 : p+27+nsegs]
+		size := 27 + nsegs
+		for _, s := range segs {
+			size += int(s)
+		}
+		page := append([]byte(nil), data[p:p+size]...)
+		crc := le.Uint32(page[22:])
+		le.PutUint32(page[22:], 0)
+		if oggCRC(page) != crc {
+			t.Errorf("page %d: CRC mismatch", pages)
+		}
+
+		last := p+size == len(data)
+		if (flags&oggBOS != 0) != (pages == 0) || (flags&oggEOS != 0) != last {
+			t.Errorf("page %d: flags %x", pages, flags)
+		}
+		body := data[p+27+nsegs : p+size]
+		for _, s := range segs {
+			packet = append(packet, body[:s]...)
+			body = body[s:]
+			if s < 255 {
+				packets = append(packets, packet)
+				packet = nil
+			}
+		}
+		lastGranule = granule
+		p += size
+	}
+	return packets, lastGranule
+}
+
+func TestOggFLACRoundTrip(t *testing.T) {
+	const channels = 2
+	in := testSignal(2*flacBlockSize+100, channels)
+	f := &memFile{}
+	encode(t, FormatOggFLAC, f, channels, in, Tags{Title: "Song"})
+	packets, lastGranule := oggPackets(t, f.data)
+	if lastGranule != int64(len(in)/channels) {
+		t.Errorf("final granule %d, want %d", lastGranule, len(in)/channels)
+	}
+
+	head := packets[0]
+	if !bytes.HasPrefix(head, []byte("\x7FFLAC\x01\x00\x00\x01fLaC")) || head[13] != flacStreamInfo {
+		t.Fatalf("bad Ogg FLAC header packet % x", head[:17])
+	}
+	if packets[1][0] != 0x80|flacVorbisComment {
+		t.Fatalf("second packet is block type %x, want the last, a comment", packets[1][0])
+	}
+	checkVorbisComment(t, packets[1][4:], "TITLE=Song")
+	checkFLACStream(t, head[17:], packets[2:], in, channels)
+}
+
+func TestOggVorbisStream(t *testing.T) {
+	for _, channels := range []int{1, 2} {
+		in := testSignal(3*vorbisHalf+100, channels)
+		var buf bytes.Buffer
+		encode(t, FormatVorbis, &buf, channels, in, Tags{Title: "Song"})
+		packets, lastGranule := oggPackets(t, buf.Bytes())
+		if lastGranule != int64(len(in)/channels) {
+			t.Errorf("%d channels: final granule %d, want %d", channels, lastGranule, len(in)/channels)
+		}
+
+		le := binary.LittleEndian
+		id := packets[0]
+		if len(id) != 30 || !bytes.HasPrefix(id, []byte("\x01vorbis")) || le.Uint32(id[7:]) != 0 ||
+			int(id[11]) != channels || le.Uint32(id[12:]) != 44100 || id[28] != 0xB8 || id[29] != 1 {
+			t.Fatalf("%d channels: bad identification header % x", channels, id)
+		}
+		comment := packets[1]
+		if !bytes.HasPrefix(comment, []byte("\x03vorbis")) || comment[len(comment)-1] != 1 {
+			t.Fatalf("%d channels: bad comment header", channels)
+		}
+		checkVorbisComment(t, comment[7:], "TITLE=Song")
+		setup := packets[2]
+		if !bytes.HasPrefix(setup, []byte("\x05vorbis")) || int(setup[7])+1 != len(vorbisBooks) || string(setup[8:11]) != "BCV" {
+			t.Fatalf("%d channels: bad setup header % x", channels, setup[:11])
+		}
+
+		// A priming packet, then one per half block including the last,
+		// partial one
+		if audio := packets[3:]; len(audio) != 5 {
+			t.Errorf("%d channels: %d audio packets, want 5", channels, len(audio))
+		}
+		for i, p := range packets[3:] {
+			if p[0]&1 != 0 {
+				t.Errorf("%d channels: audio packet %d marked as a header", channels, i)
+			}
+		}
+	}
+}
+
+// TestVorbisCoupling checks that the decoder's square polar inverse
+// restores every stereo residue pair
+func TestVorbisCoupling(t *testing.T) {
+	e, err := newVorbisEncoder(io.Discard, 44100, 2, Tags{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var left, right []int
+	for l := -3; l <= 3; l++ {
+		for r := -3; r <= 3; r++ {
+			left, right = append(left, l), append(right, r)
+		}
+	}
+	copy(e.res[0], left)
+	copy(e.res[1], right)
+	e.used[0] = true
+	e.couple()
+	if !e.used[1] {
+		t.Error("coupled channel not marked in use")
+	}
+	for i := range left {
+		m, a := e.res[0][i], e.res[1][i]
+		switch {
+		case m > 0 && a > 0:
+			a = m - a
+		case m > 0:
+			m, a = m+a, m
+		case a > 0:
+			a = m + a
+		default:
+			m, a = m-a, m
+		}
+		if m != left[i] || a != right[i] {
+			t.Errorf("(%d, %d) decodes as (%d, %d)", left[i], right[i], m, a)
+		}
+	}
+}
+
+func TestMDCT(t *testing.T) {
+	const m = 64
+	rng := rand.New(rand.NewSource(1))
+	x := make([]float64, 2*m)
+	for i := range x {
+		x[i] = rng.Float64()*2 - 1
+	}
+	out := make([]float64, m)
+	newMDCT(m, 0.5).forward(x, out)
+	for k := range out {
+		want := 0.0
+		for n, v := range x {
+			want += v * math.Cos(math.Pi/m*(float64(n)+0.5+m/2)*(float64(k)+0.5))
+		}
+		if want *= 0.5; math.Abs(out[k]-want) > 1e-9 {
+			t.Errorf("X[%d] = %g, want %g", k, out[k], want)
+		}
+	}
+}
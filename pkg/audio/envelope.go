@@ -0,0 +1,76 @@
+package audio
+
+import "github.com/anthropics/abytetracker/pkg/tracker"
+
+// envState runs a tick-based ADSR envelope with a 0.0-1.0 level,
+// used for FM operators and the filter envelope
+type envState struct {
+	level float64
+	phase int // 0=attack, 1=decay, 2=sustain, 3=release, 4=off
+	pos   float64
+}
+
+// release moves the envelope to its release phase
+func (e *envState) release() {
+	if e.phase < 3 {
+		e.phase = 3
+		e.pos = 0
+	}
+}
+
+// tick advances the envelope by one tracker tick
+func (e *envState) tick(env *tracker.Envelope) {
+	sustain := float64(env.Sustain) / 64.0
+	switch e.phase {
+	case 0: // Attack
+		if env.Attack == 0 {
+			e.level = 1.0
+			e.phase = 1
+		} else {
+			e.pos += 1.0 / float64(env.Attack)
+			e.level = e.pos
+			if e.pos >= 1.0 {
+				e.level = 1.0
+				e.phase = 1
+				e.pos = 0
+			}
+		}
+	case 1: // Decay
+		if env.Decay == 0 {
+			e.level = sustain
+			e.endDecay(env)
+		} else {
+			e.pos += 1.0 / float64(env.Decay)
+			e.level = 1.0 - (1.0-sustain)*e.pos
+			if e.pos >= 1.0 {
+				e.level = sustain
+				e.endDecay(env)
+			}
+		}
+	case 2: // Sustain
+		e.level = sustain
+	case 3: // Release
+		if env.Release == 0 {
+			e.level = 0
+			e.phase = 4
+		} else {
+			e.level -= 1.0 / float64(env.Release)
+			if e.level <= 0 {
+				e.level = 0
+				e.phase = 4
+			}
+		}
+	}
+}
+
+// endDecay moves on to sustain, or for a looping envelope back to the
+// attack, rising again from the sustain level
+func (e *envState) endDecay(env *tracker.Envelope) {
+	if env.Loop {
+		e.phase = 0
+		e.pos = float64(env.Sustain) / 64.0
+		return
+	}
+	e.phase = 2
+	e.pos = 0
+}
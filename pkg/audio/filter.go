@@ -0,0 +1,121 @@
+package audio
+
+import (
+	"math"
+
+	"github.com/anthropics/abytetracker/pkg/tracker"
+)
+
+// FilterState runs a resonant state-variable filter (LP/HP/BP) for one channel
+type FilterState struct {
+	Type       tracker.FilterType
+	Cutoff     float64 // Current cutoff in 0-255 units (before envelope)
+	Resonance  float64 // Current resonance in 0-255 units
+	SweepSpeed float64 // Cutoff change per tick (Nxy)
+	SampleRate float64
+
+	envAmount float64
+	envelope  *tracker.Envelope
+	env       envState
+
+	// TPT state-variable filter coefficients and integrator state
+	a1, a2, a3, k float64
+	ic1, ic2      float64
+}
+
+// NewFilterState creates a disabled filter
+func NewFilterState(sampleRate float64) *FilterState {
+	return &FilterState{SampleRate: sampleRate, Cutoff: 255}
+}
+
+// Trigger loads filter settings on a new note and restarts the filter envelope
+func (f *FilterState) Trigger(cfg *tracker.Filter) {
+	f.Type = cfg.Type
+	f.Cutoff = float64(cfg.Cutoff)
+	f.Resonance = float64(cfg.Resonance)
+	f.SweepSpeed = 0
+	f.envAmount = float64(cfg.EnvAmount)
+	f.envelope = &cfg.Envelope
+	f.env = envState{}
+	f.update()
+}
+
+// Release moves the filter envelope to its release phase
+func (f *FilterState) Release() {
+	f.env.release()
+}
+
+// SetCutoff sets the cutoff (Lxx), enabling a low-pass filter if none is active
+func (f *FilterState) SetCutoff(cutoff uint8) {
+	if f.Type == tracker.FilterOff {
+		f.Type = tracker.FilterLowPass
+	}
+	f.Cutoff = float64(cutoff)
+	f.update()
+}
+
+// SetResonance sets the resonance (Mxx), enabling a low-pass filter if none is active
+func (f *FilterState) SetResonance(res uint8) {
+	if f.Type == tracker.FilterOff {
+		f.Type = tracker.FilterLowPass
+	}
+	f.Resonance = float64(res)
+	f.update()
+}
+
+// Tick applies the cutoff sweep and filter envelope, once per tick
+func (f *FilterState) Tick() {
+	if f.Type == tracker.FilterOff {
+		return
+	}
+	if f.SweepSpeed != 0 {
+		f.Cutoff = math.Max(0, math.Min(255, f.Cutoff+f.SweepSpeed))
+	}
+	if f.envAmount != 0 && f.envelope != nil {
+		f.env.tick(f.envelope)
+	}
+	f.update()
+}
+
+// update recalculates the filter coefficients
+func (f *FilterState) update() {
+	cutoff := f.Cutoff
+	if f.envAmount != 0 {
+		cutoff += f.envAmount * f.env.level
+	}
+	cutoff = math.Max(0, math.Min(255, cutoff))
+
+	// 0-255 maps exponentially to 20 Hz - 20 kHz
+	freq := 20.0 * math.Pow(1000.0, cutoff/255.0)
+	if max := f.SampleRate * 0.45; freq > max {
+		freq = max
+	}
+
+	g := math.Tan(math.Pi * freq / f.SampleRate)
+	f.k = 2.0 - 1.96*f.Resonance/255.0
+	f.a1 = 1.0 / (1.0 + g*(g+f.k))
+	f.a2 = g * f.a1
+	f.a3 = g * f.a2
+}
+
+// Process filters one sample
+func (f *FilterState) Process(in float64) float64 {
+	if f.Type == tracker.FilterOff {
+		return in
+	}
+
+	v3 := in - f.ic2
+	v1 := f.a1*f.ic1 + f.a2*v3
+	v2 := f.ic2 + f.a2*f.ic1 + f.a3*v3
+	f.ic1 = 2*v1 - f.ic1
+	f.ic2 = 2*v2 - f.ic2
+
+	switch f.Type {
+	case tracker.FilterHighPass:
+		return in - f.k*v1 - v2
+	case tracker.FilterBandPass:
+		return v1
+	default:
+		return v2
+	}
+}
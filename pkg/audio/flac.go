@@ -0,0 +1,445 @@
+package audio
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"io"
+	"math/bits"
+)
+
+// FLAC encoding: fixed 4096-sample blocks, independent channels,
+// constant/fixed-predictor/verbatim subframes with Rice-coded residuals
+const flacBlockSize = 4096
+
+// Metadata block types
+const (
+	flacStreamInfo    = 0
+	flacVorbisComment = 4
+)
+
+var flacRateCodes = map[int]uint64{
+	88200: 1, 176400: 2, 192000: 3, 8000: 4, 16000: 5, 22050: 6,
+	24000: 7, 32000: 8, 44100: 9, 48000: 10, 96000: 11,
+}
+
+var flacSizeCodes = map[int]uint64{8: 1, 12: 2, 16: 4, 20: 5, 24: 6}
+
+// flacEncoder writes a native FLAC stream, or Ogg FLAC when ogg is set
+type flacEncoder struct {
+	w          io.Writer
+	ogg        *oggWriter
+	sampleRate int
+	channels   int
+	bits       int
+	tags       Tags
+
+	block    [][]int32 // Per-channel samples of the current block
+	n        int       // Samples in the current block
+	frameNum uint64
+	total    uint64
+	minFrame int
+	maxFrame int
+	md5      hash.Hash
+	pcm      []byte
+
+	start int64 // Offset of the stream, -1 if not seekable
+	bw    bitWriter
+	res   []int64
+}
+
+func newFLACEncoder(w io.Writer, sampleRate, channels, bits int, tags Tags, ogg bool) (*flacEncoder, error) {
+	if channels < 1 || channels > 8 {
+		return nil, errors.New("flac: 1 to 8 channels supported")
+	}
+	if sampleRate < 1 || sampleRate >= 1<<20 {
+		return nil, errors.New("flac: unsupported sample rate")
+	}
+	e := &flacEncoder{
+		w:          w,
+		sampleRate: sampleRate,
+		channels:   channels,
+		bits:       bits,
+		tags:       tags,
+		block:      make([][]int32, channels),
+		md5:        md5.New(),
+		start:      tell(w),
+		res:        make([]int64, flacBlockSize),
+	}
+	for i := range e.block {
+		e.block[i] = make([]int32, flacBlockSize)
+	}
+
+	comment := flacBlockHeader(true, flacVorbisComment, vorbisComment(e.tags))
+	if ogg {
+		e.ogg = newOggWriter(w)
+		if err := e.ogg.writePacket(e.oggHeader(), 0); err != nil {
+			return nil, err
+		}
+		if err := e.ogg.writePacket(comment, 0); err != nil {
+			return nil, err
+		}
+		return e, nil
+	}
+
+	h := []byte("fLaC")
+	h = append(h, flacBlockHeader(false, flacStreamInfo, e.streamInfo())...)
+	h = append(h, comment...)
+	if _, err := w.Write(h); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// flacBlockHeader prefixes a metadata block with its header
+func flacBlockHeader(last bool, typ byte, data []byte) []byte {
+	if last {
+		typ |= 0x80
+	}
+	n := len(data)
+	return append([]byte{typ, byte(n >> 16), byte(n >> 8), byte(n)}, data...)
+}
+
+// streamInfo returns the STREAMINFO block body; totals and MD5 are zero
+// until Close
+func (e *flacEncoder) streamInfo() []byte {
+	minBlock, maxBlock := flacBlockSize, flacBlockSize
+	if e.total > 0 && e.total < flacBlockSize {
+		minBlock, maxBlock = int(e.total), int(e.total)
+	}
+	be := binary.BigEndian
+	b := be.AppendUint16(nil, uint16(minBlock))
+	b = be.AppendUint16(b, uint16(maxBlock))
+	b = append(b, byte(e.minFrame>>16), byte(e.minFrame>>8), byte(e.minFrame))
+	b = append(b, byte(e.maxFrame>>16), byte(e.maxFrame>>8), byte(e.maxFrame))
+	packed := uint64(e.sampleRate)<<44 | uint64(e.channels-1)<<41 | uint64(e.bits-1)<<36 | e.total&(1<<36-1)
+	b = be.AppendUint64(b, packed)
+	if e.total > 0 {
+		return e.md5.Sum(b)
+	}
+	return append(b, make([]byte, md5.Size)...)
+}
+
+// oggHeader returns the first packet of the Ogg FLAC mapping
+func (e *flacEncoder) oggHeader() []byte {
+	b := []byte{0x7F, 'F', 'L', 'A', 'C', 1, 0, 0, 1} // Version 1.0, one more header packet
+	b = append(b, "fLaC"...)
+	return append(b, flacBlockHeader(false, flacStreamInfo, e.streamInfo())...)
+}
+
+// WriteSamples encodes interleaved float samples
+func (e *flacEncoder) WriteSamples(samples []float64) error {
+	for i := 0; i+e.channels <= len(samples); i += e.channels {
+		for ch := 0; ch < e.channels; ch++ {
+			e.block[ch][e.n] = quantize(samples[i+ch], e.bits)
+		}
+		e.n++
+		if e.n == flacBlockSize {
+			if err := e.flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// flush encodes the current block as one frame
+func (e *flacEncoder) flush() error {
+	if e.n == 0 {
+		return nil
+	}
+
+	// MD5 covers the little-endian interleaved samples
+	e.pcm = e.pcm[:0]
+	for i := 0; i < e.n; i++ {
+		for ch := 0; ch < e.channels; ch++ {
+			v := e.block[ch][i]
+			for b := 0; b < e.bits; b += 8 {
+				e.pcm = append(e.pcm, byte(v>>b))
+			}
+		}
+	}
+	e.md5.Write(e.pcm)
+
+	frame := e.encodeFrame()
+	e.total += uint64(e.n)
+	e.frameNum++
+	e.n = 0
+	if e.minFrame == 0 || len(frame) < e.minFrame {
+		e.minFrame = len(frame)
+	}
+	if len(frame) > e.maxFrame {
+		e.maxFrame = len(frame)
+	}
+
+	if e.ogg != nil {
+		return e.ogg.writePacket(frame, int64(e.total))
+	}
+	_, err := e.w.Write(frame)
+	return err
+}
+
+// Close writes the last block and patches STREAMINFO when the writer can seek
+func (e *flacEncoder) Close() error {
+	if err := e.flush(); err != nil {
+		return err
+	}
+	if e.ogg != nil {
+		if err := e.ogg.close(); err != nil {
+			return err
+		}
+		if e.start < 0 {
+			return nil
+		}
+		return patch(e.w, e.start, e.ogg.firstPage(e.oggHeader()))
+	}
+	if e.start < 0 {
+		return nil
+	}
+	return patch(e.w, e.start+8, e.streamInfo())
+}
+
+func (e *flacEncoder) encodeFrame() []byte {
+	bw := &e.bw
+	bw.reset()
+
+	// Frame header: sync, fixed blocking strategy
+	bw.write(0x3FFE, 14)
+	bw.write(0, 2)
+	switch {
+	case e.n == flacBlockSize:
+		bw.write(12, 4) // 256 * 2^(12-8)
+	case e.n <= 256:
+		bw.write(6, 4) // 8-bit size follows
+	default:
+		bw.write(7, 4) // 16-bit size follows
+	}
+	bw.write(flacRateCodes[e.sampleRate], 4) // 0 = from STREAMINFO
+	bw.write(uint64(e.channels-1), 4)        // Independent channels
+	bw.write(flacSizeCodes[e.bits], 3)
+	bw.write(0, 1)
+	for _, b := range utf8Number(e.frameNum) {
+		bw.write(uint64(b), 8)
+	}
+	switch {
+	case e.n == flacBlockSize:
+	case e.n <= 256:
+		bw.write(uint64(e.n-1), 8)
+	default:
+		bw.write(uint64(e.n-1), 16)
+	}
+	bw.write(uint64(crc8(bw.buf)), 8)
+
+	for ch := 0; ch < e.channels; ch++ {
+		e.encodeSubframe(e.block[ch][:e.n])
+	}
+
+	bw.align()
+	crc := crc16(bw.buf)
+	bw.write(uint64(crc), 16)
+	return append([]byte(nil), bw.buf...)
+}
+
+// fixedResidual computes the fixed predictor residual of the given order
+func fixedResidual(x []int32, order int, res []int64) {
+	for i := order; i < len(x); i++ {
+		a := int64(x[i])
+		switch order {
+		case 0:
+			res[i] = a
+		case 1:
+			res[i] = a - int64(x[i-1])
+		case 2:
+			res[i] = a - 2*int64(x[i-1]) + int64(x[i-2])
+		case 3:
+			res[i] = a - 3*int64(x[i-1]) + 3*int64(x[i-2]) - int64(x[i-3])
+		case 4:
+			res[i] = a - 4*int64(x[i-1]) + 6*int64(x[i-2]) - 4*int64(x[i-3]) + int64(x[i-4])
+		}
+	}
+}
+
+func (e *flacEncoder) encodeSubframe(x []int32) {
+	bw := &e.bw
+	bps := uint(e.bits)
+	mask := uint64(1)<<bps - 1
+
+	constant := true
+	for _, v := range x[1:] {
+		if v != x[0] {
+			constant = false
+			break
+		}
+	}
+	if constant {
+		bw.write(0, 8) // Padding bit, CONSTANT, no wasted bits
+		bw.write(uint64(x[0])&mask, bps)
+		return
+	}
+
+	// Pick the fixed predictor with the smallest residual
+	bestOrder := 0
+	var bestSum uint64
+	for order := 0; order <= 4 && order < len(x); order++ {
+		fixedResidual(x, order, e.res)
+		var sum uint64
+		for _, r := range e.res[order:len(x)] {
+			sum += uint64(r<<1 ^ r>>63)
+		}
+		if order == 0 || sum < bestSum {
+			bestOrder, bestSum = order, sum
+		}
+	}
+	res := e.res[bestOrder:len(x)]
+	fixedResidual(x, bestOrder, e.res)
+	k, cost := riceParam(res, bestSum)
+
+	// Fall back to verbatim if prediction does not pay off
+	if uint64(bestOrder)*uint64(bps)+10+cost >= uint64(len(x))*uint64(bps) {
+		bw.write(0x02, 8) // Padding bit, VERBATIM, no wasted bits
+		for _, v := range x {
+			bw.write(uint64(v)&mask, bps)
+		}
+		return
+	}
+
+	bw.write(uint64(0x08|bestOrder)<<1, 8) // Padding bit, FIXED order, no wasted bits
+	for _, v := range x[:bestOrder] {
+		bw.write(uint64(v)&mask, bps)
+	}
+	bw.write(0, 2) // Rice coding, 4-bit parameter
+	bw.write(0, 4) // Partition order 0
+	bw.write(uint64(k), 4)
+	for _, r := range res {
+		u := uint64(r<<1 ^ r>>63)
+		q := u >> k
+		for q >= 32 {
+			bw.write(0, 32)
+			q -= 32
+		}
+		bw.write(1, uint(q)+1)
+		if k > 0 {
+			bw.write(u&(1<<k-1), k)
+		}
+	}
+}
+
+// riceParam picks the Rice parameter for the residuals and returns it
+// with the coded size in bits
+func riceParam(res []int64, sum uint64) (uint, uint64) {
+	if len(res) == 0 {
+		return 0, 0
+	}
+	guess := 0
+	if mean := sum / uint64(len(res)); mean > 0 {
+		guess = bits.Len64(mean) - 1
+	}
+
+	bestK, bestCost := uint(0), uint64(0)
+	for k := guess - 1; k <= guess+1; k++ {
+		if k < 0 || k > 14 {
+			continue
+		}
+		cost := uint64(len(res)) * uint64(k+1)
+		for _, r := range res {
+			cost += uint64(r<<1^r>>63) >> uint(k)
+		}
+		if bestCost == 0 || cost < bestCost {
+			bestK, bestCost = uint(k), cost
+		}
+	}
+	return bestK, bestCost
+}
+
+// utf8Number encodes a frame number the way FLAC frame headers do
+func utf8Number(n uint64) []byte {
+	if n < 0x80 {
+		return []byte{byte(n)}
+	}
+	// Count continuation bytes (6 bits each)
+	extra := 1
+	for n >= 1<<(6*extra+6-extra) {
+		extra++
+	}
+	b := make([]byte, extra+1)
+	for i := extra; i > 0; i-- {
+		b[i] = 0x80 | byte(n&0x3F)
+		n >>= 6
+	}
+	b[0] = byte(0xFF<<(7-extra)) | byte(n)
+	return b
+}
+
+// bitWriter packs big-endian bit fields
+type bitWriter struct {
+	buf []byte
+	acc uint64
+	n   uint // Bits pending in acc (< 8 between writes)
+}
+
+func (b *bitWriter) reset() {
+	b.buf = b.buf[:0]
+	b.acc = 0
+	b.n = 0
+}
+
+// write appends the low bits of v (at most 32 bits)
+func (b *bitWriter) write(v uint64, bits uint) {
+	if bits == 0 {
+		return
+	}
+	b.acc = b.acc<<bits | v&(1<<bits-1)
+	b.n += bits
+	for b.n >= 8 {
+		b.n -= 8
+		b.buf = append(b.buf, byte(b.acc>>b.n))
+	}
+}
+
+// align pads with zero bits to a byte boundary
+func (b *bitWriter) align() {
+	if b.n > 0 {
+		b.write(0, 8-b.n)
+	}
+}
+
+var crc8Table, crc16Table = func() ([256]uint8, [256]uint16) {
+	var t8 [256]uint8
+	var t16 [256]uint16
+	for i := 0; i < 256; i++ {
+		c8 := uint8(i)
+		c16 := uint16(i) << 8
+		for j := 0; j < 8; j++ {
+			if c8&0x80 != 0 {
+				c8 = c8<<1 ^ 0x07
+			} else {
+				c8 <<= 1
+			}
+			if c16&0x8000 != 0 {
+				c16 = c16<<1 ^ 0x8005
+			} else {
+				c16 <<= 1
+			}
+		}
+		t8[i] = c8
+		t16[i] = c16
+	}
+	return t8, t16
+}()
+
+func crc8(data []byte) uint8 {
+	var crc uint8
+	for _, b := range data {
+		crc = crc8Table[crc^b]
+	}
+	return crc
+}
+
+func crc16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc = crc<<8 ^ crc16Table[byte(crc>>8)^b]
+	}
+	return crc
+}
@@ -0,0 +1,142 @@
+package audio
+
+import (
+	"math"
+
+	"github.com/anthropics/abytetracker/pkg/tracker"
+)
+
+// fmMaxOperators is the maximum number of operators in a patch
+const fmMaxOperators = 4
+
+// fmModDepth scales modulator output (in radians) at full level
+const fmModDepth = 4.0
+
+// fmRouting lists, per algorithm, which operator each operator
+// modulates (-1 = carrier). Targets always have a lower index so
+// operators can be evaluated from last to first.
+var fmRouting = [tracker.FMAlgCount][fmMaxOperators]int{
+	tracker.FMAlgSerial:   {-1, 0, 1, 2},
+	tracker.FMAlgPairs:    {-1, 0, -1, 2},
+	tracker.FMAlgStack:    {-1, 0, 0, 0},
+	tracker.FMAlgAdditive: {-1, -1, -1, -1},
+}
+
+// defaultFMPatch is used when a GenFM instrument has no operators
+var defaultFMPatch = tracker.FMPatch{
+	Algorithm: tracker.FMAlgSerial,
+	Operators: []tracker.FMOperator{
+		{Ratio: 1, Level: 64, Envelope: tracker.Envelope{Decay: 20, Sustain: 40, Release: 10}},
+		{Ratio: 2, Level: 40, Feedback: 2, Envelope: tracker.Envelope{Decay: 10, Sustain: 16, Release: 10}},
+	},
+}
+
+// DefaultFMPatch returns a copy of the patch a GenFM instrument with no
+// operators plays
+func DefaultFMPatch() tracker.FMPatch {
+	patch := defaultFMPatch
+	patch.Operators = append([]tracker.FMOperator(nil), defaultFMPatch.Operators...)
+	return patch
+}
+
+// fmOperator holds the runtime state of one operator
+type fmOperator struct {
+	phase float64
+	out   [2]float64 // Last two outputs (for feedback)
+	mod   float64    // Modulation input for the current sample
+	env   envState
+}
+
+// FMVoice renders a tracker.FMPatch
+type FMVoice struct {
+	Patch *tracker.FMPatch
+	ops   [fmMaxOperators]fmOperator
+}
+
+// NewFMVoice creates a new FM voice
+func NewFMVoice() *FMVoice {
+	return &FMVoice{Patch: &defaultFMPatch}
+}
+
+// NoteOn sets the patch and restarts all operators
+func (v *FMVoice) NoteOn(patch *tracker.FMPatch) {
+	if patch == nil || len(patch.Operators) == 0 {
+		patch = &defaultFMPatch
+	}
+	v.Patch = patch
+	for i := range v.ops {
+		v.ops[i] = fmOperator{}
+	}
+}
+
+// NoteOff moves all operators to their release phase
+func (v *FMVoice) NoteOff() {
+	for i := range v.ops {
+		v.ops[i].env.release()
+	}
+}
+
+// Tick advances the operator envelopes by one tracker tick
+func (v *FMVoice) Tick() {
+	for i := 0; i < v.numOps(); i++ {
+		v.ops[i].env.tick(&v.Patch.Operators[i].Envelope)
+	}
+}
+
+func (v *FMVoice) numOps() int {
+	n := len(v.Patch.Operators)
+	if n > fmMaxOperators {
+		n = fmMaxOperators
+	}
+	return n
+}
+
+// Sample generates the next sample (-1.0 to 1.0) at the given base frequency
+func (v *FMVoice) Sample(freq, sampleRate float64) float64 {
+	n := v.numOps()
+	alg := int(v.Patch.Algorithm)
+	if alg >= len(fmRouting) {
+		alg = int(tracker.FMAlgSerial)
+	}
+	routing := fmRouting[alg]
+
+	for i := 0; i < n; i++ {
+		v.ops[i].mod = 0
+	}
+
+	var out float64
+	carriers := 0
+	for i := n - 1; i >= 0; i-- {
+		op := &v.ops[i]
+		cfg := &v.Patch.Operators[i]
+
+		ratio := float64(cfg.Ratio)
+		if cfg.Ratio == 0 {
+			ratio = 0.5
+		}
+		op.phase += freq * ratio / sampleRate
+		op.phase -= math.Floor(op.phase)
+
+		mod := op.mod * fmModDepth
+		if cfg.Feedback > 0 {
+			fb := float64(cfg.Feedback) / 7.0 * math.Pi
+			mod += (op.out[0] + op.out[1]) / 2 * fb
+		}
+
+		s := math.Sin(2*math.Pi*op.phase+mod) * float64(cfg.Level) / 64.0 * op.env.level
+		op.out[1] = op.out[0]
+		op.out[0] = s
+
+		if target := routing[i]; target >= 0 && target < n {
+			v.ops[target].mod += s
+		} else {
+			out += s
+			carriers++
+		}
+	}
+
+	if carriers > 1 {
+		out /= float64(carriers)
+	}
+	return out
+}
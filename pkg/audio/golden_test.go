@@ -0,0 +1,343 @@
+package audio
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"math"
+	"math/cmplx"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/anthropics/abytetracker/pkg/format"
+	"github.com/anthropics/abytetracker/pkg/tracker"
+)
+
+// Golden renders are stored as coarse spectra: for every block of
+// goldenBlock frames, the level of each band of the mono mix and the
+// left/right RMS, all in dB. Run `go test ./pkg/audio -update` to
+// rewrite them after an intended change to the engine.
+var update = flag.Bool("update", false, "rewrite golden files")
+
+const (
+	goldenBlock     = 4096
+	goldenTolerance = 1.0 // dB
+	goldenFloor     = -80 // dB; quieter values are not compared
+)
+
+// Band edges in Hz
+var goldenBands = []float64{0, 100, 200, 400, 800, 1600, 3200, 6400, 22050}
+
+type goldenCase struct {
+	name string
+	song func(t *testing.T) *tracker.Song
+}
+
+func goldenCases() []goldenCase {
+	cases := []goldenCase{
+		{"bossabeat", func(t *testing.T) *tracker.Song {
+			f, err := os.Open("../../songs/bossabeat.abt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			song, err := format.Load(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return song
+		}},
+	}
+
+	gens := []struct {
+		name string
+		gen  tracker.Generator
+	}{
+		{"triangle", tracker.GenTriangle},
+		{"sawtooth", tracker.GenSawtooth},
+		{"square", tracker.GenSquare},
+		{"sawbig", tracker.GenSawBig},
+		{"noise", tracker.GenNoise},
+		{"fm", tracker.GenFM},
+		{"wavetable", tracker.GenWavetable},
+	}
+	for _, g := range gens {
+		gen := g.gen
+		cases = append(cases, goldenCase{"gen_" + g.name, func(t *testing.T) *tracker.Song {
+			song := fxSong(nil)
+			inst := &song.Instruments[0]
+			inst.Generator = gen
+			song.ChanConfig[0].Generator = gen
+			inst.FM = tracker.FMPatch{Algorithm: tracker.FMAlgSerial, Operators: []tracker.FMOperator{
+				{Ratio: 1, Level: 64, Envelope: tracker.Envelope{Sustain: 64}},
+				{Ratio: 2, Level: 32, Feedback: 3, Envelope: tracker.Envelope{Decay: 20, Sustain: 16}},
+			}}
+			inst.Wavetable = tracker.NewWavetable(32, 4)
+			return song
+		}})
+	}
+
+	effects := []struct {
+		name string
+		set  func(pat *tracker.Pattern)
+	}{
+		{"slide_up", func(pat *tracker.Pattern) { pat.Notes[0][0].Effect = fx(tracker.FxSlideUp, 0x08) }},
+		{"slide_down", func(pat *tracker.Pattern) { pat.Notes[0][0].Effect = fx(tracker.FxSlideDown, 0x08) }},
+		{"portamento", func(pat *tracker.Pattern) {
+			pat.Notes[8][0] = tracker.Note{Pitch: 55, Volume: -1, Effect: fx(tracker.FxPortamento, 0x10)}
+		}},
+		{"vibrato", func(pat *tracker.Pattern) { pat.Notes[0][0].Effect = fx(tracker.FxVibrato, 0x48) }},
+		{"volume", func(pat *tracker.Pattern) { pat.Notes[4][0].Effect = fx(tracker.FxVolume, 0x10) }},
+		{"speed", func(pat *tracker.Pattern) { pat.Notes[0][0].Effect = fx(tracker.FxSpeed, 0x03) }},
+		{"tempo", func(pat *tracker.Pattern) { pat.Notes[0][0].Effect = fx(tracker.FxSpeed, 0xA0) }},
+		{"ornament", func(pat *tracker.Pattern) { pat.Notes[0][0].Effect = fx(tracker.FxOrnament, 0x01) }},
+		{"duty", func(pat *tracker.Pattern) { pat.Notes[0][0].Effect = fx(tracker.FxDuty, 0x20) }},
+		{"cutoff", func(pat *tracker.Pattern) { pat.Notes[0][0].Effect = fx(tracker.FxCutoff, 0x60) }},
+		{"resonance", func(pat *tracker.Pattern) {
+			pat.Notes[0][0].Effect = fx(tracker.FxCutoff, 0x80)
+			pat.Notes[1][0].Effect = fx(tracker.FxResonance, 0xE0)
+		}},
+		{"filter_sweep", func(pat *tracker.Pattern) {
+			pat.Notes[0][0].Effect = fx(tracker.FxCutoff, 0xF0)
+			pat.Notes[1][0].Effect = fx(tracker.FxFiltSweep, 0x04)
+		}},
+		{"break", func(pat *tracker.Pattern) { pat.Notes[7][0].Effect = fx(tracker.FxBreak, 0x0C) }},
+	}
+	for _, e := range effects {
+		set := e.set
+		cases = append(cases, goldenCase{"fx_" + e.name, func(t *testing.T) *tracker.Song {
+			song := fxSong(set)
+			if song.Instruments[0].Generator == tracker.GenTriangle {
+				// Richer harmonics make filter and duty changes visible
+				song.Instruments[0].Generator = tracker.GenSquare
+			}
+			return song
+		}})
+	}
+
+	cases = append(cases,
+		goldenCase{"fx_echo", func(t *testing.T) *tracker.Song {
+			song := tracker.NewSong(2)
+			song.Patterns[0] = tracker.NewPattern(16, 2)
+			song.Patterns[0].Notes[0][0] = tracker.Note{Pitch: 48, Instrument: 4, Volume: -1}
+			song.Patterns[0].Notes[0][1].Effect = fx(tracker.FxEcho, 0x02)
+			return song
+		}},
+		goldenCase{"bus_sends", func(t *testing.T) *tracker.Song {
+			song := fxSong(nil)
+			song.ChanConfig[0].DelaySend = 40
+			song.ChanConfig[0].ReverbSend = 40
+			song.ChanConfig[0].Pan = -32
+			return song
+		}},
+		goldenCase{"master_limiter", func(t *testing.T) *tracker.Song {
+			song := fxSong(nil)
+			song.Master.Gain = 255
+			song.Master.Limiter = true
+			return song
+		}},
+	)
+	return cases
+}
+
+func fx(typ, param uint8) tracker.Effect {
+	return tracker.Effect{Type: typ, Param: param}
+}
+
+// fxSong returns a one-channel, 16-row song playing C-4 on row 0
+func fxSong(set func(pat *tracker.Pattern)) *tracker.Song {
+	song := tracker.NewSong(1)
+	pat := tracker.NewPattern(16, 1)
+	pat.Notes[0][0] = tracker.Note{Pitch: 48, Instrument: 1, Volume: -1}
+	if set != nil {
+		set(pat)
+	}
+	song.Patterns = []*tracker.Pattern{pat}
+	song.Order = []uint8{0}
+	return song
+}
+
+// goldenSpectrum summarizes a render, one row per block
+func goldenSpectrum(samples []float64, sampleRate int) [][]float64 {
+	n := goldenBlock
+	window := make([]float64, n)
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(n))
+	}
+
+	var rows [][]float64
+	buf := make([]complex128, n)
+	for start := 0; start < len(samples)/2; start += n {
+		var sumL, sumR float64
+		for i := range buf {
+			var l, r float64
+			if j := (start + i) * 2; j+1 < len(samples) {
+				l, r = samples[j], samples[j+1]
+			}
+			sumL += l * l
+			sumR += r * r
+			buf[i] = complex((l+r)/2*window[i], 0)
+		}
+		fft(buf)
+
+		row := make([]float64, 0, len(goldenBands)+1)
+		for b := 0; b+1 < len(goldenBands); b++ {
+			var energy float64
+			for k := 0; k < n/2; k++ {
+				freq := float64(k) * float64(sampleRate) / float64(n)
+				if freq >= goldenBands[b] && freq < goldenBands[b+1] {
+					energy += math.Pow(cmplx.Abs(buf[k]), 2)
+				}
+			}
+			row = append(row, toDB(energy/float64(n*n)))
+		}
+		row = append(row, toDB(sumL/float64(n)), toDB(sumR/float64(n)))
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func toDB(power float64) float64 {
+	db := 10 * math.Log10(power+1e-14)
+	return math.Round(db*10) / 10
+}
+
+// fft is an in-place radix-2 FFT; len(x) must be a power of two
+func fft(x []complex128) {
+	n := len(x)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j |= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				a, b := x[start+k], x[start+k+size/2]*w
+				x[start+k], x[start+k+size/2] = a+b, a-b
+				w *= step
+			}
+		}
+	}
+}
+
+func writeGolden(path string, frames int, rows [][]float64) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "frames %d\n", frames)
+	for _, row := range rows {
+		for i, v := range row {
+			if i > 0 {
+				sb.WriteByte(' ')
+			}
+			sb.WriteString(strconv.FormatFloat(v, 'f', 1, 64))
+		}
+		sb.WriteByte('\n')
+	}
+	return os.WriteFile(path, []byte(sb.String()), 0644)
+}
+
+func readGolden(path string) (int, [][]float64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer f.Close()
+
+	var frames int
+	var rows [][]float64
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.HasPrefix(line, "frames ") {
+			frames, err = strconv.Atoi(strings.TrimPrefix(line, "frames "))
+			if err != nil {
+				return 0, nil, err
+			}
+			continue
+		}
+		var row []float64
+		for _, field := range strings.Fields(line) {
+			v, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return 0, nil, err
+			}
+			row = append(row, v)
+		}
+		rows = append(rows, row)
+	}
+	return frames, rows, sc.Err()
+}
+
+func TestGoldenRenders(t *testing.T) {
+	for _, tc := range goldenCases() {
+		t.Run(tc.name, func(t *testing.T) {
+			song := tc.song(t)
+			samples := Render(song, RenderOptions{})
+			frames := len(samples) / 2
+			rows := goldenSpectrum(samples, song.SampleRate)
+
+			path := filepath.Join("testdata", "golden", tc.name+".golden")
+			if *update {
+				if err := writeGolden(path, frames, rows); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			wantFrames, want, err := readGolden(path)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create)", err)
+			}
+			if frames != wantFrames || len(rows) != len(want) {
+				t.Fatalf("rendered %d frames in %d blocks, want %d frames in %d blocks",
+					frames, len(rows), wantFrames, len(want))
+			}
+			for b := range rows {
+				for i, v := range rows[b] {
+					w := want[b][i]
+					if v < goldenFloor && w < goldenFloor {
+						continue
+					}
+					if math.Abs(v-w) > goldenTolerance {
+						t.Fatalf("block %d column %d: %.1f dB, want %.1f dB", b, i, v, w)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRenderDeterministic(t *testing.T) {
+	// Same song and seed must give bit-identical output; a different
+	// seed only changes the noise
+	song := fxSong(nil)
+	song.Instruments[0].Generator = tracker.GenNoise
+	a := Render(song, RenderOptions{})
+	b := Render(song, RenderOptions{})
+	c := Render(song, RenderOptions{Seed: 1234})
+	same, differs := true, false
+	for i := range a {
+		if a[i] != b[i] {
+			same = false
+		}
+		if a[i] != c[i] {
+			differs = true
+		}
+	}
+	if !same {
+		t.Error("two renders with the same seed differ")
+	}
+	if !differs {
+		t.Error("changing the seed did not change the noise")
+	}
+}
@@ -0,0 +1,166 @@
+package audio
+
+import "math"
+
+// biquad is a direct-form I biquad filter
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	x1, x2, y1, y2     float64
+}
+
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.b1*f.x1 + f.b2*f.x2 - f.a1*f.y1 - f.a2*f.y2
+	f.x2, f.x1 = f.x1, x
+	f.y2, f.y1 = f.y1, y
+	return y
+}
+
+// kWeighting returns the two ITU-R BS.1770 K-weighting stages
+// (high shelf + high pass) designed for the given sample rate
+func kWeighting(sampleRate float64) [2]biquad {
+	var stages [2]biquad
+
+	// Stage 1: +4 dB high shelf around 1.68 kHz
+	a := math.Pow(10, 3.999843853973347/40)
+	w0 := 2 * math.Pi * 1681.974450955533 / sampleRate
+	cosw, alpha := math.Cos(w0), math.Sin(w0)/(2*0.7071752369554196)
+	sqa := 2 * math.Sqrt(a) * alpha
+	a0 := (a + 1) - (a-1)*cosw + sqa
+	stages[0] = biquad{
+		b0: a * ((a + 1) + (a-1)*cosw + sqa) / a0,
+		b1: -2 * a * ((a - 1) + (a+1)*cosw) / a0,
+		b2: a * ((a + 1) + (a-1)*cosw - sqa) / a0,
+		a1: 2 * ((a - 1) - (a+1)*cosw) / a0,
+		a2: ((a + 1) - (a-1)*cosw - sqa) / a0,
+	}
+
+	// Stage 2: high pass around 38 Hz
+	w0 = 2 * math.Pi * 38.13547087602444 / sampleRate
+	cosw, alpha = math.Cos(w0), math.Sin(w0)/(2*0.5003270373238773)
+	a0 = 1 + alpha
+	stages[1] = biquad{
+		b0: (1 + cosw) / 2 / a0,
+		b1: -(1 + cosw) / a0,
+		b2: (1 + cosw) / 2 / a0,
+		a1: -2 * cosw / a0,
+		a2: (1 - alpha) / a0,
+	}
+	return stages
+}
+
+// LoudnessMeter measures peak, RMS and LUFS (BS.1770) of a stereo signal
+type LoudnessMeter struct {
+	sampleRate float64
+	kL, kR     [2]biquad
+
+	// Live meters
+	PeakL, PeakR float64 // Peak with decay (linear)
+	msL, msR     float64 // Mean square for RMS (300 ms)
+	msK          float64 // K-weighted mean square (400 ms, momentary)
+
+	// Gated integrated loudness: 100 ms sub-blocks, collected only
+	// when Gated is set (live meters run indefinitely)
+	Gated     bool
+	subBlock  []float64
+	subSum    float64
+	subCount  int
+	subLength int
+}
+
+// NewLoudnessMeter creates a meter for the given sample rate
+func NewLoudnessMeter(sampleRate int) *LoudnessMeter {
+	fs := float64(sampleRate)
+	return &LoudnessMeter{
+		sampleRate: fs,
+		kL:         kWeighting(fs),
+		kR:         kWeighting(fs),
+		subLength:  sampleRate / 10,
+	}
+}
+
+// Process feeds one stereo frame
+func (m *LoudnessMeter) Process(left, right float64) {
+	// Peak with ~1.5 s fall time
+	decay := 1.0 - 1.0/(1.5*m.sampleRate)
+	m.PeakL = math.Max(math.Abs(left), m.PeakL*decay)
+	m.PeakR = math.Max(math.Abs(right), m.PeakR*decay)
+
+	rmsCoef := 1.0 / (0.3 * m.sampleRate)
+	m.msL += (left*left - m.msL) * rmsCoef
+	m.msR += (right*right - m.msR) * rmsCoef
+
+	zl := m.kL[1].process(m.kL[0].process(left))
+	zr := m.kR[1].process(m.kR[0].process(right))
+	z := zl*zl + zr*zr
+	m.msK += (z - m.msK) / (0.4 * m.sampleRate)
+
+	if !m.Gated {
+		return
+	}
+	m.subSum += z
+	m.subCount++
+	if m.subCount >= m.subLength {
+		m.subBlock = append(m.subBlock, m.subSum/float64(m.subCount))
+		m.subSum = 0
+		m.subCount = 0
+	}
+}
+
+// RMS returns the left/right RMS levels (linear)
+func (m *LoudnessMeter) RMS() (float64, float64) {
+	return math.Sqrt(m.msL), math.Sqrt(m.msR)
+}
+
+// Momentary returns the momentary loudness in LUFS
+func (m *LoudnessMeter) Momentary() float64 {
+	return meanSquareToLUFS(m.msK)
+}
+
+// Integrated returns the gated integrated loudness in LUFS
+// (400 ms blocks with 75% overlap, -70 LUFS absolute and -10 LU relative gates)
+func (m *LoudnessMeter) Integrated() float64 {
+	var blocks []float64
+	for i := 0; i+4 <= len(m.subBlock); i++ {
+		ms := (m.subBlock[i] + m.subBlock[i+1] + m.subBlock[i+2] + m.subBlock[i+3]) / 4
+		if meanSquareToLUFS(ms) > -70 {
+			blocks = append(blocks, ms)
+		}
+	}
+	if len(blocks) == 0 {
+		return math.Inf(-1)
+	}
+
+	var sum float64
+	for _, ms := range blocks {
+		sum += ms
+	}
+	gate := meanSquareToLUFS(sum/float64(len(blocks))) - 10
+
+	sum = 0
+	n := 0
+	for _, ms := range blocks {
+		if meanSquareToLUFS(ms) > gate {
+			sum += ms
+			n++
+		}
+	}
+	if n == 0 {
+		return math.Inf(-1)
+	}
+	return meanSquareToLUFS(sum / float64(n))
+}
+
+func meanSquareToLUFS(ms float64) float64 {
+	if ms <= 0 {
+		return math.Inf(-1)
+	}
+	return -0.691 + 10*math.Log10(ms)
+}
+
+// LinearToDB converts a linear level to decibels
+func LinearToDB(v float64) float64 {
+	if v <= 0 {
+		return math.Inf(-1)
+	}
+	return 20 * math.Log10(v)
+}
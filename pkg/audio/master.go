@@ -0,0 +1,160 @@
+package audio
+
+import (
+	"math"
+
+	"github.com/anthropics/abytetracker/pkg/tracker"
+)
+
+// Limiter settings
+const (
+	limiterLookahead = 0.005 // Seconds
+	limiterRelease   = 0.08  // Seconds
+	limiterCeiling   = 0.98
+)
+
+// MasterBus is the final mix stage: headroom, master gain,
+// soft clipper or look-ahead limiter, and metering
+type MasterBus struct {
+	Meter *LoudnessMeter
+	Trim  float64 // Extra linear gain (used for loudness normalization)
+
+	// Look-ahead limiter state
+	delayL, delayR *delayLine
+	lookahead      int
+	window         []float64 // Required gain for samples in the look-ahead window
+	windowPos      int
+	gain           float64
+	attack         float64
+	release        float64
+
+	// Gain applied to each side of the frame last output, relative to
+	// the mix that went in (used to scale stems)
+	appliedL, appliedR float64
+}
+
+// MeterReading is a snapshot of the master meters
+type MeterReading struct {
+	PeakL, PeakR float64 // Linear
+	RMSL, RMSR   float64 // Linear
+	LUFS         float64 // Momentary loudness
+}
+
+// NewMasterBus creates a master bus for the given sample rate
+func NewMasterBus(sampleRate int) *MasterBus {
+	lookahead := int(limiterLookahead * float64(sampleRate))
+	if lookahead < 1 {
+		lookahead = 1
+	}
+	mb := &MasterBus{
+		Meter:     NewLoudnessMeter(sampleRate),
+		Trim:      1.0,
+		delayL:    newDelayLine(lookahead),
+		delayR:    newDelayLine(lookahead),
+		lookahead: lookahead,
+		window:    make([]float64, lookahead),
+		gain:      1.0,
+		attack:    1.0 - math.Exp(-3.0/float64(lookahead)),
+		release:   1.0 - math.Exp(-1.0/(limiterRelease*float64(sampleRate))),
+	}
+	for i := range mb.window {
+		mb.window[i] = 1.0
+	}
+	return mb
+}
+
+// Process mixes down one stereo frame from the given number of channels
+func (mb *MasterBus) Process(left, right float64, channels int, cfg *tracker.MasterConfig) (float64, float64) {
+	// Mix down with headroom (divide by sqrt of channels for proper gain staging)
+	gain := float64(cfg.Gain) / 64.0 * mb.Trim
+	if channels > 1 {
+		gain /= math.Sqrt(float64(channels))
+	}
+	left *= gain
+	right *= gain
+
+	if cfg.Limiter {
+		var g float64
+		left, right, g = mb.limit(left, right)
+		mb.appliedL, mb.appliedR = gain*g, gain*g
+	} else {
+		mb.appliedL, mb.appliedR = gain*clipGain(left), gain*clipGain(right)
+		left = softClip(left)
+		right = softClip(right)
+	}
+
+	mb.Meter.Process(left, right)
+	return left, right
+}
+
+// Latency returns how many samples the master bus delays its output
+func (mb *MasterBus) Latency(cfg *tracker.MasterConfig) int {
+	if cfg.Limiter {
+		return mb.lookahead
+	}
+	return 0
+}
+
+// softClip is a tanh-style soft limiter to avoid hard clipping
+func softClip(sample float64) float64 {
+	if sample > 0.9 {
+		sample = 0.9 + 0.1*math.Tanh((sample-0.9)*10)
+	} else if sample < -0.9 {
+		sample = -0.9 + 0.1*math.Tanh((sample+0.9)*10)
+	}
+	return sample
+}
+
+// clipGain returns the gain softClip applies to a sample
+func clipGain(sample float64) float64 {
+	if sample == 0 {
+		return 1
+	}
+	return softClip(sample) / sample
+}
+
+// limit runs the stereo-linked look-ahead limiter. Output is delayed
+// by the look-ahead time so gain reduction starts before a peak arrives.
+// It also returns the gain applied to the delayed frame.
+func (mb *MasterBus) limit(left, right float64) (float64, float64, float64) {
+	need := 1.0
+	if peak := math.Max(math.Abs(left), math.Abs(right)); peak > limiterCeiling {
+		need = limiterCeiling / peak
+	}
+	mb.window[mb.windowPos] = need
+	mb.windowPos = (mb.windowPos + 1) % len(mb.window)
+
+	target := 1.0
+	for _, g := range mb.window {
+		target = math.Min(target, g)
+	}
+	if target < mb.gain {
+		mb.gain += (target - mb.gain) * mb.attack
+	} else {
+		mb.gain += (target - mb.gain) * mb.release
+	}
+
+	outL := mb.delayL.read(mb.lookahead)
+	outR := mb.delayR.read(mb.lookahead)
+	mb.delayL.write(left)
+	mb.delayR.write(right)
+
+	// Never let the smoothed gain overshoot on the sample leaving the delay
+	g := mb.gain
+	if peak := math.Max(math.Abs(outL), math.Abs(outR)); peak*g > limiterCeiling {
+		g = limiterCeiling / peak
+	}
+	return outL * g, outR * g, g
+}
+
+// Reading returns the current meter values
+func (mb *MasterBus) Reading() MeterReading {
+	rmsL, rmsR := mb.Meter.RMS()
+	return MeterReading{
+		PeakL: mb.Meter.PeakL,
+		PeakR: mb.Meter.PeakR,
+		RMSL:  rmsL,
+		RMSR:  rmsR,
+		LUFS:  mb.Meter.Momentary(),
+	}
+}
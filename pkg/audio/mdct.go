@@ -0,0 +1,107 @@
+package audio
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// mdct computes the forward modified discrete cosine transform of 2M
+// samples into M coefficients:
+//
+//	X[k] = scale * sum(x[n] * cos(pi/M * (n + 1/2 + M/2) * (k + 1/2)))
+//
+// It folds the input into a DCT-IV of length M, computed with an M/2
+// point complex FFT.
+type mdct struct {
+	m     int
+	scale float64
+	pre   []complex128 // Twiddles before the FFT
+	post  []complex128 // Twiddles after the FFT
+	fft   *fftPlan
+	u     []float64
+	z     []complex128
+}
+
+func newMDCT(m int, scale float64) *mdct {
+	t := &mdct{
+		m:     m,
+		scale: scale,
+		pre:   make([]complex128, m/2),
+		post:  make([]complex128, m/2),
+		fft:   newFFTPlan(m / 2),
+		u:     make([]float64, m),
+		z:     make([]complex128, m/2),
+	}
+	for n := range t.pre {
+		t.pre[n] = cmplx.Exp(complex(0, -math.Pi*(4*float64(n)+1)/(4*float64(m))))
+		t.post[n] = cmplx.Exp(complex(0, -math.Pi*float64(n)/float64(m)))
+	}
+	return t
+}
+
+// forward transforms x (2M samples) into out (M coefficients)
+func (t *mdct) forward(x, out []float64) {
+	m, h := t.m, t.m/2
+	// With x split into quarters a, b, c, d the MDCT is the DCT-IV of
+	// (-c reversed - d, a - b reversed)
+	for n := 0; n < h; n++ {
+		t.u[n] = -x[3*h-1-n] - x[3*h+n]
+		t.u[h+n] = x[n] - x[m-1-n]
+	}
+
+	// DCT-IV of u: pair even samples with reversed odd ones, rotate,
+	// transform, rotate back
+	for n := 0; n < h; n++ {
+		t.z[n] = complex(t.u[2*n], t.u[m-1-2*n]) * t.pre[n]
+	}
+	t.fft.transform(t.z)
+	for k := 0; k < h; k++ {
+		c := t.z[k] * t.post[k]
+		out[2*k] = real(c) * t.scale
+		out[m-1-2*k] = -imag(c) * t.scale
+	}
+}
+
+// fftPlan is an in-place radix-2 complex FFT of a fixed power-of-two size
+type fftPlan struct {
+	n       int
+	twiddle []complex128
+	rev     []int
+}
+
+func newFFTPlan(n int) *fftPlan {
+	f := &fftPlan{n: n, twiddle: make([]complex128, n/2), rev: make([]int, n)}
+	for i := range f.twiddle {
+		f.twiddle[i] = cmplx.Exp(complex(0, -2*math.Pi*float64(i)/float64(n)))
+	}
+	bits := 0
+	for 1<<bits < n {
+		bits++
+	}
+	for i := range f.rev {
+		r := 0
+		for b := 0; b < bits; b++ {
+			r |= (i >> b & 1) << (bits - 1 - b)
+		}
+		f.rev[i] = r
+	}
+	return f
+}
+
+// transform replaces z with its discrete Fourier transform
+func (f *fftPlan) transform(z []complex128) {
+	for i, r := range f.rev {
+		if i < r {
+			z[i], z[r] = z[r], z[i]
+		}
+	}
+	for size := 2; size <= f.n; size <<= 1 {
+		half, step := size/2, f.n/size
+		for start := 0; start < f.n; start += size {
+			for k := 0; k < half; k++ {
+				a, b := z[start+k], z[start+k+half]*f.twiddle[k*step]
+				z[start+k], z[start+k+half] = a+b, a-b
+			}
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package audio
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// Page header flags
+const (
+	oggContinued = 0x01
+	oggBOS       = 0x02
+	oggEOS       = 0x04
+)
+
+// oggSerial is the logical stream serial; fixed so exports are reproducible
+const oggSerial = 0x41425431 // "ABT1"
+
+// oggWriter packs packets into Ogg pages. The newest packet is held back
+// so the final page can be flagged end-of-stream on close.
+type oggWriter struct {
+	w   io.Writer
+	seq uint32
+
+	pending        []byte
+	pendingGranule int64
+	hasPending     bool
+}
+
+func newOggWriter(w io.Writer) *oggWriter {
+	return &oggWriter{w: w}
+}
+
+// writePacket queues a packet ending at the given granule position
+func (o *oggWriter) writePacket(packet []byte, granule int64) error {
+	if o.hasPending {
+		if err := o.writePages(o.pending, o.pendingGranule, false); err != nil {
+			return err
+		}
+	}
+	o.pending = append(o.pending[:0], packet...)
+	o.pendingGranule = granule
+	o.hasPending = true
+	return nil
+}
+
+// close writes the held-back packet as the end of the stream
+func (o *oggWriter) close() error {
+	if !o.hasPending {
+		return nil
+	}
+	o.hasPending = false
+	return o.writePages(o.pending, o.pendingGranule, true)
+}
+
+// writePages writes one packet, split over as many pages as needed
+func (o *oggWriter) writePages(packet []byte, granule int64, eos bool) error {
+	// Lacing: 255 for every full segment, then the remainder (maybe 0)
+	lacing := make([]byte, 0, len(packet)/255+1)
+	for n := len(packet); ; n -= 255 {
+		if n < 255 {
+			lacing = append(lacing, byte(n))
+			break
+		}
+		lacing = append(lacing, 255)
+	}
+
+	var flags byte
+	for first := true; len(lacing) > 0; first = false {
+		segs := lacing
+		if len(segs) > 255 {
+			segs = segs[:255]
+		}
+		lacing = lacing[len(segs):]
+
+		size := 0
+		for _, s := range segs {
+			size += int(s)
+		}
+		data := packet[:size]
+		packet = packet[size:]
+
+		flags = 0
+		if !first {
+			flags |= oggContinued
+		}
+		if o.seq == 0 {
+			flags |= oggBOS
+		}
+		g := int64(-1) // No packet ends on this page
+		if len(lacing) == 0 {
+			g = granule
+			if eos {
+				flags |= oggEOS
+			}
+		}
+		if _, err := o.w.Write(oggPage(flags, g, o.seq, segs, data)); err != nil {
+			return err
+		}
+		o.seq++
+	}
+	return nil
+}
+
+// firstPage rebuilds the first page (sequence 0) with a new packet of the
+// same size, used to patch headers once the stream is complete
+func (o *oggWriter) firstPage(packet []byte) []byte {
+	return oggPage(oggBOS, 0, 0, []byte{byte(len(packet))}, packet)
+}
+
+// oggPage builds a page with its checksum
+func oggPage(flags byte, granule int64, seq uint32, segs, data []byte) []byte {
+	le := binary.LittleEndian
+	p := []byte("OggS")
+	p = append(p, 0, flags) // Version, flags
+	p = le.AppendUint64(p, uint64(granule))
+	p = le.AppendUint32(p, oggSerial)
+	p = le.AppendUint32(p, seq)
+	p = le.AppendUint32(p, 0) // Checksum, filled below
+	p = append(p, byte(len(segs)))
+	p = append(p, segs...)
+	p = append(p, data...)
+	le.PutUint32(p[22:], oggCRC(p))
+	return p
+}
+
+var oggCRCTable = func() [256]uint32 {
+	var t [256]uint32
+	for i := range t {
+		c := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if c&0x80000000 != 0 {
+				c = c<<1 ^ 0x04C11DB7
+			} else {
+				c <<= 1
+			}
+		}
+		t[i] = c
+	}
+	return t
+}()
+
+func oggCRC(data []byte) uint32 {
+	var crc uint32
+	for _, b := range data {
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^b]
+	}
+	return crc
+}
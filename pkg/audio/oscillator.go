@@ -13,7 +13,13 @@ type Oscillator struct {
 	Phase      float64
 	Frequency  float64
 	SampleRate float64
-	Duty       float64 // Duty cycle 0.0-1.0 (default 0.5 for square)
+	Duty       float64            // Duty cycle 0.0-1.0 (default 0.5 for square)
+	FM         *FMVoice           // Operator state for GenFM
+	Wave       *tracker.Wavetable // Waves for GenWavetable
+	WavePos    float64            // Morph position (in waves)
+	Formula    bytebeatFunc       // Compiled formula for GenBytebeat
+	beatPos    float64            // Bytebeat time (256 steps per cycle)
+	noise32    uint32             // Noise generator state (never 0)
 }
 
 // NewOscillator creates a new oscillator
@@ -22,9 +28,19 @@ func NewOscillator(genType tracker.Generator, sampleRate float64) *Oscillator {
 		Type:       genType,
 		SampleRate: sampleRate,
 		Duty:       0.5, // Default 50% duty
+		FM:         NewFMVoice(),
+		noise32:    DefaultSeed,
 	}
 }
 
+// SeedNoise restarts the noise generator from seed
+func (o *Oscillator) SeedNoise(seed uint32) {
+	if seed == 0 {
+		seed = DefaultSeed
+	}
+	o.noise32 = seed
+}
+
 // SetDuty sets the duty cycle (0.0 to 1.0)
 func (o *Oscillator) SetDuty(duty float64) {
 	if duty < 0.0 {
@@ -72,6 +88,12 @@ func (o *Oscillator) Sample() float64 {
 		return o.sawBig()
 	case tracker.GenNoise:
 		return o.noise()
+	case tracker.GenFM:
+		return o.FM.Sample(o.Frequency, o.SampleRate)
+	case tracker.GenWavetable:
+		return o.wavetable()
+	case tracker.GenBytebeat:
+		return o.bytebeat(phaseInc)
 	default:
 		return 0
 	}
@@ -106,17 +128,71 @@ func (o *Oscillator) sawBig() float64 {
 	return float64(val)/1024.0 - 1.0
 }
 
-// Noise: pseudo-random noise
+// Noise: pseudo-random noise from a seeded xorshift generator, so the
+// output depends only on the seed and the number of samples played
 func (o *Oscillator) noise() float64 {
-	// LCG-based noise that depends on phase for determinism
-	seed := uint32(o.Phase * 1000000)
-	seed = seed*1103515245 + 12345
-	return float64(int32(seed))/float64(math.MaxInt32)
+	x := o.noise32
+	x ^= x << 13
+	x ^= x >> 17
+	x ^= x << 5
+	o.noise32 = x
+	return float64(int32(x))/float64(math.MaxInt32)
+}
+
+// Wavetable: user-drawn single-cycle waves, morphing between neighbours
+func (o *Oscillator) wavetable() float64 {
+	if o.Wave == nil || len(o.Wave.Waves) == 0 {
+		return o.triangle()
+	}
+	n := len(o.Wave.Waves)
+	w := int(o.WavePos) % n
+	s := o.waveStep(o.Wave.Waves[w])
+	if frac := o.WavePos - math.Floor(o.WavePos); frac > 0 {
+		next := o.waveStep(o.Wave.Waves[(w+1)%n])
+		s += (next - s) * frac
+	}
+	return s
+}
+
+// waveStep returns the current step of a wave scaled to -1.0..1.0
+func (o *Oscillator) waveStep(wave []uint8) float64 {
+	if len(wave) == 0 {
+		return 0
+	}
+	idx := int(o.Phase * float64(len(wave)))
+	if idx >= len(wave) {
+		idx = len(wave) - 1
+	}
+	return float64(wave[idx])/float64(o.Wave.MaxValue())*2.0 - 1.0
+}
+
+// Bytebeat: a formula of t, with t counting 256 steps per cycle so that
+// "t" alone is a sawtooth at the note's pitch; the low 8 bits of the
+// result are the sample
+func (o *Oscillator) bytebeat(phaseInc float64) float64 {
+	if o.Formula == nil {
+		return 0
+	}
+	v := o.Formula(int32(int64(o.beatPos)))
+	o.beatPos += phaseInc * 256
+	return float64(uint8(v))/128.0 - 1.0
+}
+
+// AdvanceMorph moves the wavetable morph position by one tick
+func (o *Oscillator) AdvanceMorph() {
+	if o.Wave == nil || o.Wave.Morph == 0 || len(o.Wave.Waves) < 2 {
+		return
+	}
+	o.WavePos += 1.0 / float64(o.Wave.Morph)
+	if o.WavePos >= float64(len(o.Wave.Waves)) {
+		o.WavePos -= float64(len(o.Wave.Waves))
+	}
 }
 
 // Reset resets the oscillator phase
 func (o *Oscillator) Reset() {
 	o.Phase = 0
+	o.beatPos = 0
 }
 
 // ChannelState holds the current state of a channel during playback
@@ -126,6 +202,7 @@ type ChannelState struct {
 	Instrument  int
 	Note        int8
 	BaseNote    int8    // Note before ornament/effects
+	Detune      float64 // Instrument fine tuning in semitones
 	Volume      float64 // 0.0 to 1.0
 	TargetVol   float64 // For envelope
 	Frequency   float64
@@ -151,6 +228,10 @@ type ChannelState struct {
 	EchoSource  int8
 	EchoDelay   int
 	EchoVolMod  float64
+
+	// Filter state
+	Filter      *FilterState
+	BaseFilter  tracker.Filter // Channel filter (used when the instrument has none)
 }
 
 // NewChannelState creates a new channel state
@@ -159,6 +240,7 @@ func NewChannelState(sampleRate float64) *ChannelState {
 		Oscillator: NewOscillator(tracker.GenTriangle, sampleRate),
 		Volume:     0,
 		EchoSource: -1,
+		Filter:     NewFilterState(sampleRate),
 	}
 }
 
@@ -167,12 +249,31 @@ func (cs *ChannelState) TriggerNote(note int8, inst *tracker.Instrument, volume
 	cs.Active = true
 	cs.Note = note
 	cs.BaseNote = note
-	cs.Frequency = NoteToFreq(note)
+	cs.Detune = 0
+	if inst != nil {
+		cs.Detune = float64(inst.Detune) / 64.0
+	}
+	cs.Frequency = cs.NoteFreq(note)
 	cs.Oscillator.SetFrequency(cs.Frequency)
 	cs.Oscillator.Reset()
 
 	if inst != nil {
 		cs.Oscillator.Type = inst.Generator
+		if inst.Generator == tracker.GenFM {
+			cs.Oscillator.FM.NoteOn(&inst.FM)
+		}
+		if inst.Generator == tracker.GenWavetable {
+			cs.Oscillator.Wave = &inst.Wavetable
+			cs.Oscillator.WavePos = 0
+		}
+		if inst.Generator == tracker.GenBytebeat {
+			cs.Oscillator.Formula = cachedBytebeat(inst.Formula)
+		}
+		if inst.Filter.Type != tracker.FilterOff {
+			cs.Filter.Trigger(&inst.Filter)
+		} else {
+			cs.Filter.Trigger(&cs.BaseFilter)
+		}
 		cs.Ornament = int(inst.Ornament)
 		// Set duty cycle from instrument (128 = 50%)
 		if inst.Duty > 0 {
@@ -196,10 +297,20 @@ func (cs *ChannelState) TriggerNote(note int8, inst *tracker.Instrument, volume
 	cs.OrnTick = 0
 }
 
+// NoteFreq returns the frequency of note with the instrument's detune
+func (cs *ChannelState) NoteFreq(note int8) float64 {
+	if cs.Detune == 0 {
+		return NoteToFreq(note)
+	}
+	return NoteToFreq(note) * math.Pow(2.0, cs.Detune/12.0)
+}
+
 // NoteOff releases the note
 func (cs *ChannelState) NoteOff() {
 	cs.EnvPhase = 3 // Release
 	cs.EnvPos = 0
+	cs.Oscillator.FM.NoteOff()
+	cs.Filter.Release()
 }
 
 // ProcessEnvelope updates the envelope and returns current volume multiplier
@@ -226,15 +337,14 @@ func (cs *ChannelState) ProcessEnvelope(env *tracker.Envelope, tickSamples int)
 		}
 	case 1: // Decay
 		if env.Decay == 0 {
-			cs.EnvPhase = 2
+			cs.endDecay(env)
 		} else {
 			sustainLevel := float64(env.Sustain) / 64.0 * cs.TargetVol
 			cs.EnvPos += 1.0 / float64(env.Decay)
 			cs.Volume = cs.TargetVol - (cs.TargetVol-sustainLevel)*cs.EnvPos
 			if cs.EnvPos >= 1.0 {
 				cs.Volume = sustainLevel
-				cs.EnvPhase = 2
-				cs.EnvPos = 0
+				cs.endDecay(env)
 			}
 		}
 	case 2: // Sustain
@@ -256,18 +366,35 @@ func (cs *ChannelState) ProcessEnvelope(env *tracker.Envelope, tickSamples int)
 	return cs.Volume
 }
 
+// endDecay moves on to sustain, or for a looping envelope back to the
+// attack, rising again from the sustain level
+func (cs *ChannelState) endDecay(env *tracker.Envelope) {
+	if env.Loop {
+		cs.EnvPhase = 0
+		cs.EnvPos = float64(env.Sustain) / 64.0
+		return
+	}
+	cs.EnvPhase = 2
+	cs.EnvPos = 0
+}
+
 // ProcessOrnament applies ornament (semitone offset)
 func (cs *ChannelState) ProcessOrnament(orn *tracker.Ornament) {
 	if orn == nil || len(orn.Values) == 0 {
 		return
 	}
 
+	// The ornament may have been shortened while the note plays
+	if cs.OrnPos >= len(orn.Values) {
+		cs.OrnPos = ornamentRestart(orn)
+	}
+
 	// Get current ornament value
 	offset := orn.Values[cs.OrnPos]
 
 	// Apply semitone offset to frequency
 	cs.Note = cs.BaseNote + offset
-	cs.Frequency = NoteToFreq(cs.Note)
+	cs.Frequency = cs.NoteFreq(cs.Note)
 	cs.Oscillator.SetFrequency(cs.Frequency)
 
 	// Advance ornament position
@@ -276,19 +403,25 @@ func (cs *ChannelState) ProcessOrnament(orn *tracker.Ornament) {
 		cs.OrnTick = 0
 		cs.OrnPos++
 		if cs.OrnPos >= len(orn.Values) {
-			if orn.Loop >= 0 {
-				cs.OrnPos = int(orn.Loop)
-			} else {
-				cs.OrnPos = len(orn.Values) - 1
-			}
+			cs.OrnPos = ornamentRestart(orn)
 		}
 	}
 }
 
+// ornamentRestart returns the step an ornament continues from after its
+// last one: the loop step, kept within the ornament, or the last step
+// held if it does not loop
+func ornamentRestart(orn *tracker.Ornament) int {
+	if orn.Loop >= 0 {
+		return min(int(orn.Loop), len(orn.Values)-1)
+	}
+	return len(orn.Values) - 1
+}
+
 // GenerateSample generates the next audio sample for this channel
 func (cs *ChannelState) GenerateSample() float64 {
 	if !cs.Active || cs.Volume <= 0 {
 		return 0
 	}
-	return cs.Oscillator.Sample() * cs.Volume
+	return cs.Filter.Process(cs.Oscillator.Sample()) * cs.Volume
 }
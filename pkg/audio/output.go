@@ -3,7 +3,10 @@ package audio
 import (
 	"encoding/binary"
 	"io"
+	"math"
 	"sync"
+
+	"github.com/anthropics/abytetracker/pkg/tracker"
 )
 
 // Output manages audio output
@@ -69,97 +72,157 @@ func (ar *AudioReader) Read(p []byte) (n int, err error) {
 	return n, nil
 }
 
-// WAVWriter writes audio to WAV format
-type WAVWriter struct {
-	writer      io.Writer
-	sampleRate  int
-	channels    int
-	dataWritten int
+// ExportOptions controls offline rendering
+type ExportOptions struct {
+	Format     Format  // File format (default 16-bit WAV)
+	TargetLUFS float64 // Normalize integrated loudness to this level (0 = off)
+
+	// Looping songs can be rendered with extra passes through the loop,
+	// fading out over the end. Songs that do not loop ignore these.
+	Loops int     // Extra passes through the looped section
+	Fade  float64 // Fade-out length in seconds (default 5 when Loops > 0)
 }
 
-// NewWAVWriter creates a WAV writer
-func NewWAVWriter(w io.Writer, sampleRate, channels int) *WAVWriter {
-	return &WAVWriter{
-		writer:     w,
-		sampleRate: sampleRate,
-		channels:   channels,
+// defaultLoopFade is the fade-out used when rendering extra loops
+const defaultLoopFade = 5.0
+
+// ExportLength returns the number of stereo frames an export renders
+// and how many of them fade out
+func ExportLength(info SongInfo, sampleRate int, opts ExportOptions) (frames, fadeFrames int) {
+	frames = info.Samples
+	if !info.Loops || opts.Loops <= 0 {
+		return frames, 0
+	}
+	frames += opts.Loops * info.LoopSamples
+	fade := opts.Fade
+	if fade <= 0 {
+		fade = defaultLoopFade
+	}
+	fadeFrames = int(fade * float64(sampleRate))
+	if fadeFrames > frames {
+		fadeFrames = frames
 	}
+	return frames, fadeFrames
 }
 
-// WriteHeader writes the WAV header
-func (w *WAVWriter) WriteHeader(dataSize int) error {
-	// RIFF header
-	w.writer.Write([]byte("RIFF"))
-	binary.Write(w.writer, binary.LittleEndian, uint32(dataSize+36))
-	w.writer.Write([]byte("WAVE"))
-
-	// fmt chunk
-	w.writer.Write([]byte("fmt "))
-	binary.Write(w.writer, binary.LittleEndian, uint32(16))          // Chunk size
-	binary.Write(w.writer, binary.LittleEndian, uint16(1))           // PCM format
-	binary.Write(w.writer, binary.LittleEndian, uint16(w.channels))  // Channels
-	binary.Write(w.writer, binary.LittleEndian, uint32(w.sampleRate)) // Sample rate
-	byteRate := w.sampleRate * w.channels * 2
-	binary.Write(w.writer, binary.LittleEndian, uint32(byteRate))    // Byte rate
-	blockAlign := w.channels * 2
-	binary.Write(w.writer, binary.LittleEndian, uint16(blockAlign))  // Block align
-	binary.Write(w.writer, binary.LittleEndian, uint16(16))          // Bits per sample
-
-	// data chunk header
-	w.writer.Write([]byte("data"))
-	binary.Write(w.writer, binary.LittleEndian, uint32(dataSize))
-
-	return nil
+// ExportWAV exports exactly one pass of the song to 16-bit WAV
+func ExportWAV(player *Player, writer io.Writer) error {
+	return Export(player, writer, ExportOptions{Format: FormatWAV16})
 }
 
-// WriteSamples writes float samples as 16-bit PCM
-func (w *WAVWriter) WriteSamples(samples []float64) error {
-	for _, s := range samples {
-		if s > 1.0 {
-			s = 1.0
-		}
-		if s < -1.0 {
-			s = -1.0
-		}
-		s16 := int16(s * 32767)
-		if err := binary.Write(w.writer, binary.LittleEndian, s16); err != nil {
-			return err
-		}
-		w.dataWritten += 2
+// Export renders the song in the chosen format, optionally looping and
+// normalizing loudness
+func Export(player *Player, writer io.Writer, opts ExportOptions) error {
+	enc, err := NewEncoder(opts.Format, writer, player.SampleRate, 2, SongTags(player.Song))
+	if err != nil {
+		return err
 	}
-	return nil
+	if err := render(player, opts, false, enc.WriteSamples); err != nil {
+		return err
+	}
+	return enc.Close()
 }
 
-// Export exports the song to WAV
-func ExportWAV(player *Player, writer io.Writer, durationSeconds float64) error {
-	sampleRate := player.SampleRate
-	totalSamples := int(durationSeconds * float64(sampleRate))
-	dataSize := totalSamples * 2 // 16-bit mono
-
-	wavWriter := NewWAVWriter(writer, sampleRate, 1)
-	if err := wavWriter.WriteHeader(dataSize); err != nil {
-		return err
+// render plays the song from the start for the export length and hands
+// each chunk of interleaved stereo to write. With stems set, the player's
+// stem tap holds the matching chunk for every channel.
+func render(player *Player, opts ExportOptions, stems bool, write func(buf []float64) error) error {
+	// Take any edit still waiting for the audio thread, so the length
+	// and loudness come from the song that is rendered
+	player.mu.Lock()
+	player.syncSong()
+	player.mu.Unlock()
+	frames, fadeFrames := ExportLength(AnalyzeSong(player.Song), player.SampleRate, opts)
+
+	if opts.TargetLUFS != 0 {
+		measured := measureLoudness(player.Song, frames, fadeFrames)
+		if !math.IsInf(measured, -1) {
+			player.Master.Trim = math.Pow(10, (opts.TargetLUFS-measured)/20)
+			defer func() { player.Master.Trim = 1.0 }()
+		}
 	}
 
 	// Reset player
 	player.SetPosition(0, 0)
+	if stems {
+		player.mu.Lock()
+		player.stems = newStemTap(player)
+		player.mu.Unlock()
+		defer func() {
+			player.mu.Lock()
+			player.stems = nil
+			player.mu.Unlock()
+		}()
+	}
 	player.Play()
+	defer player.Stop()
 
 	// Generate in chunks
+	totalSamples := frames * 2 // Interleaved stereo
 	chunkSize := 4096
 	buffer := make([]float64, chunkSize)
-	for written := 0; written < totalSamples; {
+	for written := 0; written < totalSamples; written += len(buffer) {
 		remaining := totalSamples - written
 		if remaining < chunkSize {
 			buffer = buffer[:remaining]
 		}
-		player.GenerateSamples(buffer)
-		if err := wavWriter.WriteSamples(buffer); err != nil {
+		player.GenerateStereo(buffer)
+
+		first := written / 2
+		applyFade(buffer, first, frames, fadeFrames)
+		if stems {
+			for _, stem := range player.stems.out {
+				applyFade(stem, first, frames, fadeFrames)
+			}
+		}
+		if err := write(buffer); err != nil {
 			return err
 		}
-		written += len(buffer)
+		if stems {
+			player.stems.reset()
+		}
 	}
-
-	player.Stop()
 	return nil
 }
+
+// applyFade fades out interleaved stereo samples starting at frame first
+// over the last fadeFrames of a render frames long
+func applyFade(buf []float64, first, frames, fadeFrames int) {
+	if fadeFrames <= 0 {
+		return
+	}
+	for i := range buf {
+		if frame := first + i/2; frame >= frames-fadeFrames {
+			buf[i] *= float64(frames-frame) / float64(fadeFrames)
+		}
+	}
+}
+
+// MeasureLoudness renders the song silently and returns its integrated loudness in LUFS
+func MeasureLoudness(song *tracker.Song, durationSeconds float64) float64 {
+	return measureLoudness(song, int(durationSeconds*float64(song.SampleRate)), 0)
+}
+
+// measureLoudness renders frames of the song with the export's fade-out
+// and measures what would be written
+func measureLoudness(song *tracker.Song, frames, fadeFrames int) float64 {
+	player := NewPlayer(song)
+	player.SetPosition(0, 0)
+	player.Play()
+	meter := NewLoudnessMeter(player.SampleRate)
+	meter.Gated = true
+
+	totalSamples := frames * 2
+	buffer := make([]float64, 4096)
+	for done := 0; done < totalSamples; done += len(buffer) {
+		if remaining := totalSamples - done; remaining < len(buffer) {
+			buffer = buffer[:remaining]
+		}
+		player.GenerateStereo(buffer)
+		applyFade(buffer, done/2, frames, fadeFrames)
+		for i := 0; i+1 < len(buffer); i += 2 {
+			meter.Process(buffer[i], buffer[i+1])
+		}
+	}
+	return meter.Integrated()
+}
@@ -1,8 +1,8 @@
 package audio
 
 import (
-	"math"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/anthropics/abytetracker/pkg/tracker"
@@ -15,11 +15,19 @@ type PlayerCallbacks struct {
 	OnPattern func(pos, pat int)
 }
 
-// Player manages song playback
+// Player manages song playback.
+//
+// Song is the snapshot owned by the audio thread and must not be edited
+// while playing. Editors keep their own copy and hand changes over with
+// UpdateSong; the player swaps the new snapshot in at a tick boundary.
 type Player struct {
 	Song       *tracker.Song
 	Channels   []*ChannelState
 	SampleRate int
+	Seed       uint32 // Noise seed, applied when channels are reset
+
+	// Wall clock used by AdvanceTime, in nanoseconds (time.Now by default)
+	Clock func() int64
 
 	// Playback state
 	Playing      bool
@@ -29,16 +37,49 @@ type Player struct {
 	Tick         int // Current tick within row
 
 	// Timing
-	TickSamples  int // Samples per tick
-	TickCounter  int // Sample counter for current tick
+	Speed        int // Current ticks per row (set by Fxx)
+	Tempo        int // Current BPM (set by Fxx)
+	TickSamples  float64 // Samples per tick (fractional)
+	TickCounter  float64 // Samples left until the next tick
 	LastTime     int64 // Last update time in nanoseconds
+	timeCarry    float64 // Fraction of a sample left over by AdvanceTime
+
+	// Flow control set by the current row (-1 = none)
+	jumpPos  int  // Bxx target position
+	breakRow int  // Dxx target row in the next pattern
+	wrapped  bool // The last row change ran off the end of the order
+
+	// Order positions repeated instead of the whole song (-1 = none)
+	loopFrom, loopTo int
+
+	// Row audition started by PlayRow, playing only rowChannel
+	// (-1 = every channel)
+	audition   rowAudition
+	rowChannel int
+
+	// Edited song waiting to be picked up at the next tick
+	pending atomic.Pointer[tracker.Song]
+
+	// Echo history (per channel, for Exy / channel echo)
+	echoLines []*delayLine
+
+	// Send-effect bus
+	Delay  *FeedbackDelay
+	Reverb *Reverb
+
+	// Master bus
+	Master *MasterBus
+
+	// Voice for auditioning notes outside the song
+	preview        *ChannelState
+	previewCounter float64 // Samples left until the preview's next tick
 
-	// Echo buffers (per channel)
-	EchoBuffers [][]float64
-	EchoPos     []int
+	// Per-channel capture for stem export (nil when not exporting stems)
+	stems *stemTap
 
-	// Callbacks
+	// Callbacks, delivered outside the lock from the queued events
 	Callbacks PlayerCallbacks
+	events    []seqEvent
 
 	mu sync.Mutex
 }
@@ -49,27 +90,85 @@ func NewPlayer(song *tracker.Song) *Player {
 		Song:       song,
 		SampleRate: song.SampleRate,
 		Channels:   make([]*ChannelState, song.Channels),
+		Seed:       DefaultSeed,
+		Clock:      func() int64 { return time.Now().UnixNano() },
+		loopFrom:   -1,
+		loopTo:     -1,
+		rowChannel: -1,
 	}
 
+	// Echo history starts at 1 second and grows for longer delays
+	p.echoLines = make([]*delayLine, song.Channels)
 	for i := range p.Channels {
-		p.Channels[i] = NewChannelState(float64(song.SampleRate))
+		p.Channels[i] = p.newChannel(i)
+		p.echoLines[i] = newDelayLine(song.SampleRate)
+	}
+	p.Delay = NewFeedbackDelay(song.SampleRate)
+	p.Reverb = NewReverb(song.SampleRate)
+	p.Master = NewMasterBus(song.SampleRate)
+	p.preview = NewChannelState(float64(song.SampleRate))
+
+	p.Speed = int(song.Speed)
+	p.Tempo = int(song.Tempo)
+	p.UpdateTiming()
+	return p
+}
+
+// newChannel creates the state for channel i from the song's channel config
+func (p *Player) newChannel(i int) *ChannelState {
+	cs := NewChannelState(float64(p.SampleRate))
+	// Each channel gets its own noise sequence
+	cs.Oscillator.SeedNoise(p.Seed + uint32(i)*0x9E3779B9)
+	if i < len(p.Song.ChanConfig) {
+		cfg := &p.Song.ChanConfig[i]
+		cs.Oscillator.Type = cfg.Generator
+		cs.EchoSource = cfg.EchoSource
+		cs.EchoDelay = int(cfg.EchoDelay)
+		cs.EchoVolMod = float64(cfg.EchoVolume) / 64.0
+		cs.BaseFilter = cfg.Filter
+		cs.Filter.Trigger(&cs.BaseFilter)
+	}
+	return cs
+}
+
+// UpdateSong hands the player a copy of an edited song. The copy is
+// swapped in by the audio thread at the next tick boundary, so the
+// caller may keep editing song afterwards.
+func (p *Player) UpdateSong(song *tracker.Song) {
+	p.pending.Store(song.Clone())
+}
+
+// syncSong swaps in a pending song snapshot; called with p.mu held
+func (p *Player) syncSong() {
+	song := p.pending.Swap(nil)
+	if song == nil {
+		return
+	}
+	p.Song = song
+
+	// Follow channel count changes
+	for len(p.Channels) < song.Channels {
+		p.Channels = append(p.Channels, p.newChannel(len(p.Channels)))
+		p.echoLines = append(p.echoLines, newDelayLine(p.SampleRate))
+	}
+	if len(p.Channels) > song.Channels {
+		p.Channels = p.Channels[:song.Channels]
+		p.echoLines = p.echoLines[:song.Channels]
+	}
+
+	// Channel filters apply from the next note
+	for i, cs := range p.Channels {
 		if i < len(song.ChanConfig) {
-			p.Channels[i].Oscillator.Type = song.ChanConfig[i].Generator
-			p.Channels[i].EchoSource = song.ChanConfig[i].EchoSource
-			p.Channels[i].EchoDelay = int(song.ChanConfig[i].EchoDelay)
-			p.Channels[i].EchoVolMod = float64(song.ChanConfig[i].EchoVolume) / 64.0
+			cs.BaseFilter = song.ChanConfig[i].Filter
 		}
 	}
 
-	// Initialize echo buffers (1 second max delay)
-	p.EchoBuffers = make([][]float64, song.Channels)
-	p.EchoPos = make([]int, song.Channels)
-	for i := range p.EchoBuffers {
-		p.EchoBuffers[i] = make([]float64, song.SampleRate)
+	if p.Position >= len(song.Order) {
+		p.Position = 0
+	}
+	if p.Position < len(song.Order) {
+		p.Pattern = int(song.Order[p.Position])
 	}
-
-	p.UpdateTiming()
-	return p
 }
 
 // UpdateTiming recalculates timing based on speed/tempo
@@ -77,21 +176,28 @@ func (p *Player) UpdateTiming() {
 	// Classic tracker timing:
 	// Ticks per second = Tempo * 2 / 5
 	// Samples per tick = SampleRate / (Tempo * 2 / 5)
-	ticksPerSecond := float64(p.Song.Tempo) * 2.0 / 5.0
-	p.TickSamples = int(float64(p.SampleRate) / ticksPerSecond)
+	ticksPerSecond := float64(p.Tempo) * 2.0 / 5.0
+	// Kept fractional: ticks land on the nearest following sample and
+	// the remainder carries over, so long renders do not drift
+	p.TickSamples = float64(p.SampleRate) / ticksPerSecond
 }
 
 // Play starts playback
 func (p *Player) Play() {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	p.Playing = true
-	p.LastTime = time.Now().UnixNano()
-	// Process first row immediately
-	p.ProcessRow()
-	if p.Callbacks.OnRow != nil {
-		p.Callbacks.OnRow(p.Position, p.Pattern, p.Row)
+	p.syncSong()
+	if !p.Playing {
+		p.chase()
+		// The current row is processed on the very next sample
+		p.Tick = 0
+		p.TickCounter = 0
 	}
+	p.Playing = true
+	p.audition = rowOff
+	p.rowChannel = -1
+	p.LastTime = p.Clock()
+	p.timeCarry = 0
 }
 
 // Stop stops playback
@@ -99,6 +205,9 @@ func (p *Player) Stop() {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 	p.Playing = false
+	p.audition = rowOff
+	p.rowChannel = -1
+	p.events = p.events[:0]
 	// Silence all channels
 	for _, ch := range p.Channels {
 		ch.Active = false
@@ -106,70 +215,46 @@ func (p *Player) Stop() {
 	}
 }
 
-// AdvanceTime advances playback based on elapsed time (for simulation without audio)
+// AdvanceTime advances playback by the wall-clock time since the last
+// call (for simulation without audio)
 func (p *Player) AdvanceTime() {
 	p.mu.Lock()
-	defer p.mu.Unlock()
-
 	if !p.Playing {
+		p.mu.Unlock()
 		return
 	}
 
-	now := time.Now().UnixNano()
+	now := p.Clock()
 	elapsed := now - p.LastTime
 	p.LastTime = now
 
-	// Calculate samples that would have been generated
-	samplesElapsed := int(float64(elapsed) * float64(p.SampleRate) / 1e9)
+	// Calculate samples that would have been generated, carrying the
+	// fraction over to the next call
+	samples := float64(elapsed)*float64(p.SampleRate)/1e9 + p.timeCarry
+	n := int(samples)
+	p.timeCarry = samples - float64(n)
+	p.mu.Unlock()
 
-	for i := 0; i < samplesElapsed; i++ {
-		p.TickCounter++
-		if p.TickCounter >= p.TickSamples {
-			p.TickCounter = 0
-
-			// Process tick effects
-			p.ProcessTick()
-
-			if p.Callbacks.OnTick != nil {
-				p.Callbacks.OnTick(p.Position, p.Pattern, p.Row, p.Tick)
-			}
+	p.Advance(n)
+}
 
-			p.Tick++
-			if p.Tick >= int(p.Song.Speed) {
-				// Advance to next row
-				p.Tick = 0
-				p.Row++
-
-				if p.Pattern < len(p.Song.Patterns) && p.Row >= p.Song.Patterns[p.Pattern].Rows {
-					// Advance to next position
-					p.Row = 0
-					oldPos := p.Position
-					p.Position++
-					if p.Position >= len(p.Song.Order) {
-						p.Position = 0 // Loop
-					}
-					p.Pattern = int(p.Song.Order[p.Position])
-
-					if p.Callbacks.OnPattern != nil && p.Position != oldPos {
-						p.Callbacks.OnPattern(p.Position, p.Pattern)
-					}
-				}
-
-				// Process new row
-				p.ProcessRow()
-
-				if p.Callbacks.OnRow != nil {
-					p.Callbacks.OnRow(p.Position, p.Pattern, p.Row)
-				}
-			}
-		}
+// Advance moves playback forward by a number of samples without
+// rendering audio
+func (p *Player) Advance(samples int) {
+	p.mu.Lock()
+	for i := 0; i < samples; i++ {
+		p.step()
 	}
+	events := p.takeEvents()
+	p.mu.Unlock()
+	p.dispatch(events)
 }
 
 // SetPosition sets the playback position
 func (p *Player) SetPosition(pos, row int) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
+	p.syncSong()
 	p.Position = pos
 	if pos < len(p.Song.Order) {
 		p.Pattern = int(p.Song.Order[pos])
@@ -177,10 +262,102 @@ func (p *Player) SetPosition(pos, row int) {
 	p.Row = row
 	p.Tick = 0
 	p.TickCounter = 0
+	p.chase()
+}
+
+// chase resets runtime state from the song, then replays the lasting
+// settings (Fxx, instruments, ornaments, echo) of every row played before
+// the current one, so starting mid-song sounds as if played from the
+// start. The rows are walked as the sequencer plays them, following Bxx
+// and Dxx; a row that playback never reaches is chased through the
+// order in sequence instead. Called with p.mu held.
+func (p *Player) chase() {
+	pos, row := p.Position, p.Row
+	p.chaseReset()
+
+	// Walk from the start until the row is reached or one repeats
+	visited := make(map[[2]int]bool)
+	p.Position, p.Row = 0, 0
+	for len(p.Song.Order) > 0 && !(p.Position == pos && p.Row == row) && !visited[[2]int{p.Position, p.Row}] {
+		p.Pattern = int(p.Song.Order[p.Position])
+		visited[[2]int{p.Position, p.Row}] = true
+		p.jumpPos, p.breakRow = -1, -1
+		p.chaseRow(p.Pattern, p.Row)
+		p.nextRow(false)
+	}
+	reached := p.Position == pos && p.Row == row
+
+	p.Position, p.Row = pos, row
+	if pos < len(p.Song.Order) {
+		p.Pattern = int(p.Song.Order[pos])
+	}
+	p.jumpPos, p.breakRow = -1, -1
+	if reached {
+		return
+	}
+
+	p.chaseReset()
+	for pos := 0; pos <= p.Position && pos < len(p.Song.Order); pos++ {
+		patIdx := int(p.Song.Order[pos])
+		if patIdx >= len(p.Song.Patterns) {
+			continue
+		}
+		pat := p.Song.Patterns[patIdx]
+		rows := pat.Rows
+		if pos == p.Position && p.Row < rows {
+			rows = p.Row
+		}
+		for row := 0; row < rows; row++ {
+			p.chaseRow(patIdx, row)
+		}
+	}
+	p.jumpPos, p.breakRow = -1, -1
+}
+
+// chaseReset puts speed, tempo and channels back to the song's defaults
+func (p *Player) chaseReset() {
+	p.Speed = int(p.Song.Speed)
+	p.Tempo = int(p.Song.Tempo)
+	p.UpdateTiming()
+	for i := range p.Channels {
+		p.Channels[i] = p.newChannel(i)
+	}
+}
+
+// chaseRow applies the lasting state of every note on a pattern row
+func (p *Player) chaseRow(patIdx, row int) {
+	if patIdx >= len(p.Song.Patterns) || row >= p.Song.Patterns[patIdx].Rows {
+		return
+	}
+	notes := p.Song.Patterns[patIdx].Notes[row]
+	for ch := 0; ch < len(p.Channels) && ch < len(notes); ch++ {
+		p.chaseNote(ch, notes[ch])
+	}
+}
+
+// chaseNote applies the lasting state of one note without playing it
+func (p *Player) chaseNote(ch int, note tracker.Note) {
+	cs := p.Channels[ch]
+	if note.Pitch >= 0 {
+		instNum := int(note.Instrument) - 1
+		if instNum >= 0 && instNum < len(p.Song.Instruments) {
+			cs.Instrument = instNum
+		}
+		if cs.Instrument >= 0 && cs.Instrument < len(p.Song.Instruments) {
+			cs.Ornament = int(p.Song.Instruments[cs.Instrument].Ornament)
+		}
+	}
+
+	switch note.Effect.Type {
+	case tracker.FxSpeed, tracker.FxOrnament, tracker.FxEcho, tracker.FxJump, tracker.FxBreak:
+		p.processEffect(ch, note.Effect)
+	}
 }
 
 // ProcessRow processes the current row, triggering notes and effects
 func (p *Player) ProcessRow() {
+	p.jumpPos = -1
+	p.breakRow = -1
 	if p.Pattern >= len(p.Song.Patterns) {
 		return
 	}
@@ -190,6 +367,9 @@ func (p *Player) ProcessRow() {
 	}
 
 	for ch := 0; ch < p.Song.Channels && ch < len(pat.Notes[p.Row]); ch++ {
+		if p.rowChannel >= 0 && ch != p.rowChannel {
+			continue
+		}
 		note := pat.Notes[p.Row][ch]
 		cs := p.Channels[ch]
 
@@ -253,15 +433,25 @@ func (p *Player) processEffect(ch int, fx tracker.Effect) {
 		}
 
 	case tracker.FxSpeed:
+		// Runtime only - the song keeps its initial speed/tempo
 		if fx.Param < 32 {
-			p.Song.Speed = fx.Param
+			if fx.Param > 0 {
+				p.Speed = int(fx.Param)
+			}
 		} else {
-			p.Song.Tempo = fx.Param
+			p.Tempo = int(fx.Param)
 			p.UpdateTiming()
 		}
 
+	case tracker.FxJump:
+		p.jumpPos = int(fx.Param)
+
+	case tracker.FxBreak:
+		p.breakRow = int(fx.Param)
+
 	case tracker.FxOrnament:
-		if int(fx.Param) < len(p.Song.Ornaments) {
+		// G01 selects the first ornament, G00 turns it off
+		if int(fx.Param) <= len(p.Song.Ornaments) {
 			cs.Ornament = int(fx.Param)
 			cs.OrnPos = 0
 			cs.OrnTick = 0
@@ -277,66 +467,93 @@ func (p *Player) processEffect(ch int, fx tracker.Effect) {
 	case tracker.FxDuty:
 		// Kxx: set duty cycle (00-FF, 80=50%)
 		cs.Oscillator.SetDuty(float64(fx.Param) / 255.0)
+
+	case tracker.FxCutoff:
+		cs.Filter.SetCutoff(fx.Param)
+
+	case tracker.FxResonance:
+		cs.Filter.SetResonance(fx.Param)
+
+	case tracker.FxFiltSweep:
+		// Nxy: sweep cutoff up x / down y steps per tick
+		up := float64((fx.Param >> 4) & 0x0F)
+		down := float64(fx.Param & 0x0F)
+		cs.Filter.SweepSpeed = up - down
 	}
 }
 
 // ProcessTick processes effects that happen every tick
 func (p *Player) ProcessTick() {
 	for ch := 0; ch < p.Song.Channels; ch++ {
-		cs := p.Channels[ch]
-		if !cs.Active {
-			continue
+		if cs := p.Channels[ch]; cs.Active {
+			p.tickChannel(cs)
 		}
+	}
+}
 
-		// Apply ornament
-		if cs.Ornament > 0 && cs.Ornament <= len(p.Song.Ornaments) {
-			orn := &p.Song.Ornaments[cs.Ornament-1]
-			cs.ProcessOrnament(orn)
-		}
+// tickChannel runs one tick of ornaments, effects and envelopes on a voice
+func (p *Player) tickChannel(cs *ChannelState) {
+	// Apply ornament
+	if cs.Ornament > 0 && cs.Ornament <= len(p.Song.Ornaments) {
+		orn := &p.Song.Ornaments[cs.Ornament-1]
+		cs.ProcessOrnament(orn)
+	}
 
-		// Apply vibrato
-		if cs.VibDepth > 0 {
-			cs.VibPos += cs.VibSpeed * 0.1
-			vibOffset := cs.VibDepth * 0.5 * (1.0 + 0.5*vibOffset(cs.VibPos))
-			freq := NoteToFreq(cs.BaseNote) * (1.0 + vibOffset/100.0)
-			cs.Oscillator.SetFrequency(freq)
-		}
+	// Apply vibrato
+	if cs.VibDepth > 0 {
+		cs.VibPos += cs.VibSpeed * 0.1
+		vibOffset := cs.VibDepth * 0.5 * (1.0 + 0.5*vibOffset(cs.VibPos))
+		freq := cs.NoteFreq(cs.BaseNote) * (1.0 + vibOffset/100.0)
+		cs.Oscillator.SetFrequency(freq)
+	}
 
-		// Apply slide
-		if cs.SlideSpeed != 0 {
-			cs.Frequency += cs.SlideSpeed
-			if cs.Frequency < 20 {
-				cs.Frequency = 20
-			}
-			if cs.Frequency > 20000 {
-				cs.Frequency = 20000
-			}
-			cs.Oscillator.SetFrequency(cs.Frequency)
+	// Apply slide
+	if cs.SlideSpeed != 0 {
+		cs.Frequency += cs.SlideSpeed
+		if cs.Frequency < 20 {
+			cs.Frequency = 20
 		}
+		if cs.Frequency > 20000 {
+			cs.Frequency = 20000
+		}
+		cs.Oscillator.SetFrequency(cs.Frequency)
+	}
 
-		// Apply portamento
-		if cs.PortaSpeed > 0 && cs.PortaTarget > 0 {
+	// Apply portamento
+	if cs.PortaSpeed > 0 && cs.PortaTarget > 0 {
+		if cs.Frequency < cs.PortaTarget {
+			cs.Frequency += cs.PortaSpeed
+			if cs.Frequency > cs.PortaTarget {
+				cs.Frequency = cs.PortaTarget
+			}
+		} else if cs.Frequency > cs.PortaTarget {
+			cs.Frequency -= cs.PortaSpeed
 			if cs.Frequency < cs.PortaTarget {
-				cs.Frequency += cs.PortaSpeed
-				if cs.Frequency > cs.PortaTarget {
-					cs.Frequency = cs.PortaTarget
-				}
-			} else if cs.Frequency > cs.PortaTarget {
-				cs.Frequency -= cs.PortaSpeed
-				if cs.Frequency < cs.PortaTarget {
-					cs.Frequency = cs.PortaTarget
-				}
+				cs.Frequency = cs.PortaTarget
 			}
-			cs.Oscillator.SetFrequency(cs.Frequency)
 		}
+		cs.Oscillator.SetFrequency(cs.Frequency)
+	}
 
-		// Process envelope
-		var env *tracker.Envelope
-		if cs.Instrument >= 0 && cs.Instrument < len(p.Song.Instruments) {
-			env = &p.Song.Instruments[cs.Instrument].Envelope
-		}
-		cs.ProcessEnvelope(env, p.TickSamples)
+	// Advance FM operator envelopes
+	if cs.Oscillator.Type == tracker.GenFM {
+		cs.Oscillator.FM.Tick()
+	}
+
+	// Apply filter sweep and envelope
+	cs.Filter.Tick()
+
+	// Advance wavetable morph
+	if cs.Oscillator.Type == tracker.GenWavetable {
+		cs.Oscillator.AdvanceMorph()
+	}
+
+	// Process envelope
+	var env *tracker.Envelope
+	if cs.Instrument >= 0 && cs.Instrument < len(p.Song.Instruments) {
+		env = &p.Song.Instruments[cs.Instrument].Envelope
 	}
+	cs.ProcessEnvelope(env, int(p.TickSamples))
 }
 
 func vibOffset(pos float64) float64 {
@@ -344,88 +561,138 @@ func vibOffset(pos float64) float64 {
 	return float64(int(pos*256)&255-128) / 128.0
 }
 
-// GenerateSamples generates audio samples into the buffer
+// GenerateSamples generates mono audio samples into the buffer
 func (p *Player) GenerateSamples(buffer []float64) {
 	p.mu.Lock()
-	defer p.mu.Unlock()
-
 	for i := range buffer {
-		if p.Playing {
-			// Check if we need to advance
-			p.TickCounter++
-			if p.TickCounter >= p.TickSamples {
-				p.TickCounter = 0
-
-				if p.Tick == 0 {
-					// First tick of row - process row
-					p.ProcessRow()
-				}
-
-				// Process tick effects
-				p.ProcessTick()
-
-				p.Tick++
-				if p.Tick >= int(p.Song.Speed) {
-					// Advance to next row
-					p.Tick = 0
-					p.Row++
-
-					if p.Pattern < len(p.Song.Patterns) && p.Row >= p.Song.Patterns[p.Pattern].Rows {
-						// Advance to next position
-						p.Row = 0
-						p.Position++
-						if p.Position >= len(p.Song.Order) {
-							p.Position = 0 // Loop
-						}
-						p.Pattern = int(p.Song.Order[p.Position])
-					}
-				}
-			}
-		}
+		left, right := p.nextFrame()
+		buffer[i] = (left + right) / 2
+	}
+	events := p.takeEvents()
+	p.mu.Unlock()
+	p.dispatch(events)
+}
 
-		// Generate samples from all channels
-		var sample float64
-		for ch := 0; ch < len(p.Channels); ch++ {
-			cs := p.Channels[ch]
-			chSample := cs.GenerateSample()
+// GenerateStereo generates interleaved left/right samples into the buffer
+func (p *Player) GenerateStereo(buffer []float64) {
+	p.mu.Lock()
+	for i := 0; i+1 < len(buffer); i += 2 {
+		buffer[i], buffer[i+1] = p.nextFrame()
+	}
+	events := p.takeEvents()
+	p.mu.Unlock()
+	p.dispatch(events)
+}
 
-			// Apply channel volume
-			if ch < len(p.Song.ChanConfig) && !p.Song.ChanConfig[ch].Muted {
-				chSample *= float64(p.Song.ChanConfig[ch].Volume) / 64.0
-			}
+// nextFrame advances the sequencer by one sample and mixes one stereo frame
+func (p *Player) nextFrame() (float64, float64) {
+	p.step()
 
-			// Store in echo buffer
-			p.EchoBuffers[ch][p.EchoPos[ch]] = chSample
-			p.EchoPos[ch] = (p.EchoPos[ch] + 1) % len(p.EchoBuffers[ch])
-
-			// Handle echo channel
-			if cs.EchoSource >= 0 && int(cs.EchoSource) < len(p.Channels) && cs.EchoDelay > 0 {
-				// Calculate delay in samples
-				delaySamples := cs.EchoDelay * p.TickSamples * int(p.Song.Speed)
-				srcCh := int(cs.EchoSource)
-				echoIdx := (p.EchoPos[srcCh] - delaySamples + len(p.EchoBuffers[srcCh])) % len(p.EchoBuffers[srcCh])
-				echoSample := p.EchoBuffers[srcCh][echoIdx] * (1.0 + cs.EchoVolMod)
-				chSample += echoSample
-			}
+	// Samples per row, for tempo-synced delays
+	rowSamples := int(p.TickSamples * float64(p.Speed))
+
+	// When any channel is soloed, only soloed channels are heard
+	solo := false
+	for _, cfg := range p.Song.ChanConfig {
+		if cfg.Solo {
+			solo = true
+			break
+		}
+	}
 
-			sample += chSample
+	// Generate samples from all channels
+	var left, right, delayL, delayR, reverbL, reverbR float64
+	for ch := 0; ch < len(p.Channels); ch++ {
+		cs := p.Channels[ch]
+		chSample := cs.GenerateSample()
+
+		// Apply channel volume
+		audible := true
+		if ch < len(p.Song.ChanConfig) {
+			cfg := &p.Song.ChanConfig[ch]
+			chSample *= float64(cfg.Volume) / 64.0
+			audible = channelAudible(cfg, solo)
 		}
 
-		// Mix down with headroom (divide by sqrt of channels for proper gain staging)
-		numCh := float64(len(p.Channels))
-		if numCh > 1 {
-			sample /= math.Sqrt(numCh)
+		// Store in echo history (before muting, so echo channels keep working)
+		p.echoLines[ch].write(chSample)
+		if !audible {
+			continue
 		}
 
-		// Soft limiter (tanh-style) to avoid hard clipping
-		if sample > 0.9 {
-			sample = 0.9 + 0.1*math.Tanh((sample-0.9)*10)
-		} else if sample < -0.9 {
-			sample = -0.9 + 0.1*math.Tanh((sample+0.9)*10)
+		// Handle echo channel
+		if cs.EchoSource >= 0 && int(cs.EchoSource) < len(p.Channels) && cs.EchoDelay > 0 {
+			// Calculate delay in samples
+			delaySamples := cs.EchoDelay * rowSamples
+			src := p.echoLines[int(cs.EchoSource)]
+			src.grow(delaySamples)
+			chSample += src.read(delaySamples) * (1.0 + cs.EchoVolMod)
 		}
 
-		buffer[i] = sample
+		// Pan and send to the effect bus
+		gainL, gainR := 1.0, 1.0
+		var delaySend, reverbSend float64
+		if ch < len(p.Song.ChanConfig) {
+			cfg := &p.Song.ChanConfig[ch]
+			gainL, gainR = panGains(cfg.Pan)
+			delaySend, reverbSend = channelSends(cfg, chSample)
+		}
+		delayL += delaySend * gainL
+		delayR += delaySend * gainR
+		reverbL += reverbSend * gainL
+		reverbR += reverbSend * gainR
+		left += chSample * gainL
+		right += chSample * gainR
+
+		if p.stems != nil {
+			p.stems.channel(ch, chSample*gainL, chSample*gainR,
+				delaySend*gainL, delaySend*gainR, reverbSend*gainL, reverbSend*gainR)
+		}
+	}
+
+	// The preview voice plays dry in the centre; it is never part of stems
+	if p.preview.Active && p.stems == nil {
+		s := p.preview.GenerateSample()
+		left += s
+		right += s
+	}
+
+	// Effect bus returns
+	if delaySamples := int(p.Song.Bus.DelayRows) * rowSamples; delaySamples != p.Delay.Samples {
+		p.Delay.SetDelay(delaySamples)
+	}
+	wetL, wetR := p.Delay.Process(delayL, delayR, &p.Song.Bus)
+	left += wetL
+	right += wetR
+	wetL, wetR = p.Reverb.Process(reverbL, reverbR, &p.Song.Bus)
+	left += wetL
+	right += wetR
+
+	outL, outR := p.Master.Process(left, right, len(p.Channels), &p.Song.Master)
+	if p.stems != nil {
+		p.stems.finish(p)
 	}
+	return outL, outR
+}
+
+// channelAudible reports whether a channel is heard: a muted channel
+// never is, and while any channel is soloed only soloed channels are.
+// Mute wins over solo.
+func channelAudible(cfg *tracker.ChannelConfig, solo bool) bool {
+	return !cfg.Muted && (!solo || cfg.Solo)
+}
+
+// channelSends returns how much of a channel sample goes to the delay and
+// reverb buses, before panning
+func channelSends(cfg *tracker.ChannelConfig, sample float64) (delay, reverb float64) {
+	return sample * float64(cfg.DelaySend) / 64.0, sample * float64(cfg.ReverbSend) / 64.0
+}
+
+// Meters returns the current master bus meter readings
+func (p *Player) Meters() MeterReading {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.Master.Reading()
 }
 
 // GetPlaybackInfo returns current playback position
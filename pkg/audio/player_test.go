@@ -0,0 +1,351 @@
+package audio
+
+import (
+	"math"
+	"testing"
+
+	"github.com/anthropics/abytetracker/pkg/tracker"
+)
+
+// clockSong returns a song where every tick is a row, so the number of
+// elapsed ticks can be read back from the position
+func clockSong(sampleRate int, tempo uint8) *tracker.Song {
+	song := tracker.NewSong(1)
+	song.SampleRate = sampleRate
+	song.Speed = 1
+	song.Tempo = tempo
+	song.Order = make([]uint8, 200)
+	return song
+}
+
+func ticksElapsed(p *Player) int {
+	return p.Position*p.Song.Patterns[0].Rows + p.Row
+}
+
+func TestClockNoDrift(t *testing.T) {
+	tests := []struct {
+		sampleRate int
+		tempo      uint8
+	}{
+		{44100, 125}, // 882 samples per tick, exact
+		{48000, 127}, // 944.88... samples per tick
+		{44100, 97},  // 1136.59... samples per tick
+		{22050, 255},
+	}
+	for _, tt := range tests {
+		p := NewPlayer(clockSong(tt.sampleRate, tt.tempo))
+		p.Playing = true
+
+		const ticks = 10000
+		tickSamples := float64(tt.sampleRate) / (float64(tt.tempo) * 2 / 5)
+		due := int(math.Ceil(ticks * tickSamples))
+
+		// Tick number `ticks` (counting from 0) must start within one
+		// sample of its theoretical time
+		p.mu.Lock()
+		for i := 0; i < due-1; i++ {
+			p.step()
+		}
+		before := ticksElapsed(p)
+		p.step()
+		p.step()
+		after := ticksElapsed(p)
+		p.mu.Unlock()
+
+		if before > ticks || after < ticks+1 {
+			t.Errorf("rate %d tempo %d: tick %d not within one sample of sample %d (ticks %d..%d)",
+				tt.sampleRate, tt.tempo, ticks, due, before, after)
+		}
+	}
+}
+
+func TestRenderMatchesAdvance(t *testing.T) {
+	// Rendering and silent advancing must move the song identically
+	rendered := NewPlayer(clockSong(48000, 127))
+	advanced := NewPlayer(clockSong(48000, 127))
+	rendered.Playing = true
+	advanced.Playing = true
+
+	buf := make([]float64, 4096)
+	for i := 0; i < 500; i++ {
+		rendered.GenerateSamples(buf)
+		advanced.mu.Lock()
+		for j := 0; j < len(buf); j++ {
+			advanced.step()
+		}
+		advanced.mu.Unlock()
+
+		if ticksElapsed(rendered) != ticksElapsed(advanced) || rendered.Tick != advanced.Tick {
+			t.Fatalf("block %d: rendered at tick %d, advanced at tick %d",
+				i, ticksElapsed(rendered), ticksElapsed(advanced))
+		}
+	}
+
+	// Ticks start on samples 0, ceil(TickSamples), ...
+	want := int(math.Ceil(float64(500*len(buf)) / rendered.TickSamples))
+	if got := ticksElapsed(rendered); got != want {
+		t.Errorf("rendered %d ticks, want %d", got, want)
+	}
+}
+
+func TestCallbacksMatchRendering(t *testing.T) {
+	// Callbacks fire for rendering and AdvanceTime alike, outside the
+	// lock, with one OnRow per row in order
+	p := NewPlayer(clockSong(44100, 125))
+	p.Song.Speed = 3
+
+	var rows, ticks int
+	p.Callbacks.OnRow = func(pos, pat, row int) {
+		if want := rows % 64; row != want {
+			t.Errorf("OnRow row %d, want %d", row, want)
+		}
+		rows++
+		p.GetPlaybackInfo() // Must not deadlock
+	}
+	p.Callbacks.OnTick = func(pos, pat, row, tick int) {
+		ticks++
+	}
+	p.Play()
+
+	// 100 ticks of 882 samples
+	buf := make([]float64, 882*100)
+	p.GenerateSamples(buf)
+	if ticks != 100 || rows != 34 {
+		t.Errorf("got %d ticks, %d rows; want 100 ticks, 34 rows", ticks, rows)
+	}
+}
+
+func TestPreviewVoice(t *testing.T) {
+	// The preview voice sounds while stopped, leaves the song channels
+	// alone and falls silent after its release
+	p := NewPlayer(tracker.NewSong(2))
+	peak := func(frames int) float64 {
+		buf := make([]float64, frames*2)
+		p.GenerateStereo(buf)
+		var m float64
+		for _, s := range buf {
+			m = math.Max(m, math.Abs(s))
+		}
+		return m
+	}
+
+	p.PreviewNote(48, 0, -1)
+	if peak(4410) == 0 {
+		t.Fatal("preview note is silent")
+	}
+	for i, cs := range p.Channels {
+		if cs.Active {
+			t.Errorf("channel %d triggered by the preview", i)
+		}
+	}
+
+	p.PreviewRelease()
+	peak(2 * 44100) // 100 ticks of instrument 1's release
+	if p.PreviewActive() {
+		t.Error("preview still active two seconds after release")
+	}
+	if got := peak(4410); got != 0 {
+		t.Errorf("preview released but output peaks at %g", got)
+	}
+}
+
+func TestLoopRange(t *testing.T) {
+	// With a loop set, playback repeats the range instead of moving on
+	song := tracker.NewSong(1)
+	for i := range 3 {
+		song.InsertPattern(i, tracker.NewPattern(4, 1))
+	}
+	song.Order = []uint8{0, 1, 2}
+	p := NewPlayer(song)
+	p.SetLoop(1, 1)
+	p.SetPosition(1, 0)
+	p.Play()
+
+	rowSamples := int(p.TickSamples*float64(p.Speed)) + 1
+	for i := range 12 {
+		p.Advance(rowSamples)
+		if pos, _, _, _, _ := p.GetPlaybackInfo(); pos != 1 {
+			t.Fatalf("row %d: position %d left the loop", i, pos)
+		}
+	}
+
+	p.ClearLoop()
+	p.Advance(4 * rowSamples)
+	if pos, _, _, _, _ := p.GetPlaybackInfo(); pos != 2 {
+		t.Errorf("after clearing the loop, position %d, want 2", pos)
+	}
+}
+
+func TestPlayRow(t *testing.T) {
+	// PlayRow plays one row, only on the chosen channel, then stops once
+	// the notes have been released
+	song := tracker.NewSong(2)
+	pat := song.Patterns[song.Order[0]]
+	pat.Notes[2][0] = tracker.Note{Pitch: 48, Instrument: 1, Volume: -1}
+	pat.Notes[2][1] = tracker.Note{Pitch: 52, Instrument: 1, Volume: -1}
+	p := NewPlayer(song)
+
+	p.PlayRow(0, 2, 1)
+	p.Advance(1)
+	if p.Channels[0].Active || !p.Channels[1].Active {
+		t.Fatalf("channel-only audition: active = %v, %v, want false, true",
+			p.Channels[0].Active, p.Channels[1].Active)
+	}
+	if !p.RowPreview() {
+		t.Fatal("row audition not reported")
+	}
+
+	p.Advance(3 * 44100)
+	if p.RowPreview() || p.Channels[1].Active {
+		t.Error("row audition still sounding after three seconds")
+	}
+	if _, _, row, _, _ := p.GetPlaybackInfo(); row != 2 {
+		t.Errorf("row audition moved to row %d", row)
+	}
+}
+
+func TestOrnamentShrinksWhilePlaying(t *testing.T) {
+	// Deleting steps from an ornament in the editor must not break the
+	// notes using it, on the preview voice or on the song channels
+	for _, loop := range []int8{-1, 6} {
+		song := tracker.NewSong(1)
+		song.Ornaments = []tracker.Ornament{{Name: "Long", Loop: loop, Values: []int8{0, 1, 2, 3, 4, 5, 6, 7}}}
+		song.Instruments[0].Ornament = 1
+		song.Patterns[song.Order[0]].Notes[0][0] = tracker.Note{Pitch: 48, Instrument: 1, Volume: -1}
+		p := NewPlayer(song)
+		p.Play()
+		p.PreviewNote(48, 0, -1)
+
+		// Six ticks in, both voices are past the steps about to go
+		p.Advance(6 * 882)
+		if p.preview.OrnPos < 2 || p.Channels[0].OrnPos < 2 {
+			t.Fatalf("loop %d: ornament at steps %d and %d, want past 2", loop, p.preview.OrnPos, p.Channels[0].OrnPos)
+		}
+		orn := &song.Ornaments[0]
+		orn.Values = orn.Values[:2]
+		p.Advance(4 * 882)
+
+		for name, cs := range map[string]*ChannelState{"preview": p.preview, "channel": p.Channels[0]} {
+			if cs.OrnPos < 0 || cs.OrnPos >= len(orn.Values) {
+				t.Errorf("loop %d: %s ornament at step %d of %d", loop, name, cs.OrnPos, len(orn.Values))
+			}
+			if cs.Note != 49 {
+				t.Errorf("loop %d: %s plays note %d, want 49 (the last step held)", loop, name, cs.Note)
+			}
+		}
+	}
+}
+
+func TestChannelAudible(t *testing.T) {
+	tests := []struct {
+		muted, solo, anySolo bool
+		want                 bool
+	}{
+		{false, false, false, true},
+		{true, false, false, false},
+		{false, false, true, false}, // another channel is soloed
+		{false, true, true, true},
+		{true, true, true, false}, // mute wins over solo
+	}
+	for _, tt := range tests {
+		cfg := tracker.ChannelConfig{Muted: tt.muted, Solo: tt.solo}
+		if got := channelAudible(&cfg, tt.anySolo); got != tt.want {
+			t.Errorf("muted %v, solo %v, any solo %v: audible = %v, want %v",
+				tt.muted, tt.solo, tt.anySolo, got, tt.want)
+		}
+	}
+}
+
+func TestChannelSends(t *testing.T) {
+	tests := []struct {
+		delaySend, reverbSend uint8
+		delay, reverb         float64
+	}{
+		{0, 0, 0, 0},
+		{64, 0, 0.5, 0},
+		{32, 16, 0.25, 0.125},
+		{0, 64, 0, 0.5},
+	}
+	for _, tt := range tests {
+		cfg := tracker.ChannelConfig{DelaySend: tt.delaySend, ReverbSend: tt.reverbSend}
+		delay, reverb := channelSends(&cfg, 0.5)
+		if delay != tt.delay || reverb != tt.reverb {
+			t.Errorf("sends %d/%d: got %g/%g, want %g/%g",
+				tt.delaySend, tt.reverbSend, delay, reverb, tt.delay, tt.reverb)
+		}
+	}
+}
+
+// stemPeaks renders frames of a song with a stem tap and returns each
+// channel's peak
+func stemPeaks(song *tracker.Song, frames int) []float64 {
+	p := NewPlayer(song)
+	p.stems = newStemTap(p)
+	p.Play()
+	p.GenerateStereo(make([]float64, frames*2))
+	peaks := make([]float64, len(p.stems.out))
+	for ch, out := range p.stems.out {
+		for _, s := range out {
+			peaks[ch] = math.Max(peaks[ch], math.Abs(s))
+		}
+	}
+	return peaks
+}
+
+func TestMuteSolo(t *testing.T) {
+	tests := []struct {
+		muted, solo [3]bool
+		heard       [3]bool
+	}{
+		{heard: [3]bool{true, true, true}},
+		{muted: [3]bool{true, false, false}, heard: [3]bool{false, true, true}},
+		{solo: [3]bool{false, true, false}, heard: [3]bool{false, true, false}},
+		{muted: [3]bool{false, true, false}, solo: [3]bool{true, true, false}, heard: [3]bool{true, false, false}},
+	}
+	for _, tt := range tests {
+		song := tracker.NewSong(3)
+		pat := song.Patterns[song.Order[0]]
+		for ch := range 3 {
+			pat.Notes[0][ch] = tracker.Note{Pitch: int8(48 + 4*ch), Instrument: 1, Volume: -1}
+			song.ChanConfig[ch].Muted = tt.muted[ch]
+			song.ChanConfig[ch].Solo = tt.solo[ch]
+		}
+		for ch, peak := range stemPeaks(song, 4410) {
+			if heard := peak > 0; heard != tt.heard[ch] {
+				t.Errorf("muted %v, solo %v: channel %d heard = %v, want %v",
+					tt.muted, tt.solo, ch+1, heard, tt.heard[ch])
+			}
+		}
+	}
+}
+
+func TestSendLevels(t *testing.T) {
+	// A note released at once after one row leaves only the bus returns
+	// ringing, and only on channels that send to them
+	song := tracker.NewSong(3)
+	song.Instruments[0].Envelope.Release = 0
+	song.Bus = tracker.BusConfig{DelayRows: 2, DelayFeedback: 32, DelayLevel: 64, ReverbSize: 32, ReverbLevel: 64}
+	pat := song.Patterns[song.Order[0]]
+	for ch := range 3 {
+		pat.Notes[0][ch] = tracker.Note{Pitch: 48, Instrument: 1, Volume: -1}
+		pat.Notes[1][ch] = tracker.Note{Pitch: -2, Volume: -1}
+	}
+	song.ChanConfig[1].DelaySend = 64
+	song.ChanConfig[2].ReverbSend = 64
+
+	p := NewPlayer(song)
+	p.stems = newStemTap(p)
+	p.Play()
+	rowFrames := int(p.TickSamples * float64(p.Speed))
+	p.GenerateStereo(make([]float64, rowFrames*4*2))
+	for ch, want := range []bool{false, true, true} {
+		// Rows 2 and 3, after the release
+		var peak float64
+		for _, s := range p.stems.out[ch][rowFrames*2*2:] {
+			peak = math.Max(peak, math.Abs(s))
+		}
+		if ringing := peak > 1e-4; ringing != want {
+			t.Errorf("channel %d: ringing after the release = %v (peak %g), want %v", ch+1, ringing, peak, want)
+		}
+	}
+}
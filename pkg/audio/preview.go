@@ -0,0 +1,67 @@
+package audio
+
+import "github.com/anthropics/abytetracker/pkg/tracker"
+
+// The preview voice auditions notes while editing. It is separate from
+// the song channels: it ignores mute and solo, skips the send effects and
+// sounds whether or not the song is playing, ticking at the current tempo.
+
+// PreviewNote starts a note on the preview voice with instrument inst
+// (0-based, -1 = none). ornament selects an ornament by number (1-based,
+// 0 = none); a negative value keeps the instrument's own.
+func (p *Player) PreviewNote(note int8, inst, ornament int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	cs := p.preview
+	var in *tracker.Instrument
+	cs.Instrument = -1
+	if inst >= 0 && inst < len(p.Song.Instruments) {
+		in = &p.Song.Instruments[inst]
+		cs.Instrument = inst
+	} else {
+		cs.TargetVol = 1.0
+	}
+	cs.TriggerNote(note, in, -1)
+	if ornament >= 0 {
+		cs.Ornament = ornament
+	}
+	p.previewCounter = 0
+}
+
+// PreviewRelease releases the preview note, letting its envelope finish
+func (p *Player) PreviewRelease() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	cs := p.preview
+	if !cs.Active {
+		return
+	}
+	if cs.Instrument < 0 || cs.Instrument >= len(p.Song.Instruments) {
+		// No envelope to release through
+		cs.Active = false
+		return
+	}
+	cs.NoteOff()
+}
+
+// PreviewActive reports whether the preview voice is sounding
+func (p *Player) PreviewActive() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.preview.Active
+}
+
+// stepPreview advances the preview voice's own tick clock by one sample;
+// called with p.mu held
+func (p *Player) stepPreview() {
+	if !p.preview.Active {
+		return
+	}
+	if p.previewCounter <= 0 {
+		p.tickChannel(p.preview)
+		p.previewCounter += p.TickSamples
+	}
+	p.previewCounter--
+}
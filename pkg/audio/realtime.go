@@ -2,25 +2,31 @@ package audio
 
 import (
 	"encoding/binary"
+	"sync/atomic"
+	"time"
 
 	"github.com/ebitengine/oto/v3"
 )
 
-// RealtimeOutput manages real-time audio playback
+// RealtimeOutput plays the player through the sound card (an AudioSink)
 type RealtimeOutput struct {
 	player     *Player
 	otoCtx     *oto.Context
 	otoPlayer  *oto.Player
 	buffer     []float64
-	running    bool
+	running    atomic.Bool // Cleared by Close on the UI goroutine
 }
 
-// NewRealtimeOutput creates a new real-time audio output
-func NewRealtimeOutput(player *Player) (*RealtimeOutput, error) {
+// NewRealtimeOutput creates a new real-time audio output. The block size
+// sets both the device buffer and how much is rendered at a time; the
+// latency sets how far oto reads ahead of the device.
+func NewRealtimeOutput(player *Player, cfg SinkConfig) (*RealtimeOutput, error) {
+	cfg = cfg.withDefaults()
 	op := &oto.NewContextOptions{
 		SampleRate:   player.SampleRate,
-		ChannelCount: 1, // Mono
+		ChannelCount: 2, // Stereo
 		Format:       oto.FormatSignedInt16LE,
+		BufferSize:   time.Duration(cfg.BufferFrames) * time.Second / time.Duration(player.SampleRate),
 	}
 
 	otoCtx, ready, err := oto.NewContext(op)
@@ -30,26 +36,27 @@ func NewRealtimeOutput(player *Player) (*RealtimeOutput, error) {
 	<-ready
 
 	rt := &RealtimeOutput{
-		player:  player,
-		otoCtx:  otoCtx,
-		buffer:  make([]float64, 512),
-		running: true,
+		player: player,
+		otoCtx: otoCtx,
+		buffer: make([]float64, cfg.BufferFrames*2),
 	}
+	rt.running.Store(true)
 
 	// Create audio stream
 	rt.otoPlayer = otoCtx.NewPlayer(&audioStream{rt: rt})
-	rt.otoPlayer.SetBufferSize(player.SampleRate / 10) // 100ms buffer
+	rt.otoPlayer.SetBufferSize(cfg.latencyFrames(player.SampleRate) * 4) // 16-bit stereo
 	rt.otoPlayer.Play()
 
 	return rt, nil
 }
 
 // Close stops the audio output
-func (rt *RealtimeOutput) Close() {
-	rt.running = false
+func (rt *RealtimeOutput) Close() error {
+	rt.running.Store(false)
 	if rt.otoPlayer != nil {
-		rt.otoPlayer.Close()
+		return rt.otoPlayer.Close()
 	}
+	return nil
 }
 
 // audioStream implements io.Reader for oto
@@ -58,7 +65,7 @@ type audioStream struct {
 }
 
 func (s *audioStream) Read(buf []byte) (int, error) {
-	if !s.rt.running {
+	if !s.rt.running.Load() {
 		// Fill with silence
 		for i := range buf {
 			buf[i] = 0
@@ -66,26 +73,25 @@ func (s *audioStream) Read(buf []byte) (int, error) {
 		return len(buf), nil
 	}
 
-	// Generate samples
-	samples := len(buf) / 2 // 16-bit = 2 bytes per sample
-	if samples > len(s.rt.buffer) {
-		s.rt.buffer = make([]float64, samples)
-	}
-
-	s.rt.player.GenerateSamples(s.rt.buffer[:samples])
+	// Generate interleaved stereo samples, one block at a time
+	samples := len(buf) / 4 * 2 // 16-bit stereo = 4 bytes per frame
+	for done := 0; done < samples; {
+		block := s.rt.buffer[:min(samples-done, len(s.rt.buffer))]
+		s.rt.player.GenerateStereo(block)
 
-	// Convert to 16-bit PCM
-	for i := 0; i < samples; i++ {
-		sample := s.rt.buffer[i]
-		// Clamp
-		if sample > 1.0 {
-			sample = 1.0
-		}
-		if sample < -1.0 {
-			sample = -1.0
+		// Convert to 16-bit PCM
+		for i, sample := range block {
+			// Clamp
+			if sample > 1.0 {
+				sample = 1.0
+			}
+			if sample < -1.0 {
+				sample = -1.0
+			}
+			s16 := int16(sample * 32767)
+			binary.LittleEndian.PutUint16(buf[(done+i)*2:], uint16(s16))
 		}
-		s16 := int16(sample * 32767)
-		binary.LittleEndian.PutUint16(buf[i*2:], uint16(s16))
+		done += len(block)
 	}
 
 	return samples * 2, nil
@@ -0,0 +1,36 @@
+package audio
+
+import "github.com/anthropics/abytetracker/pkg/tracker"
+
+// DefaultSeed is the noise seed used unless a player is given another
+const DefaultSeed uint32 = 0x2545F491
+
+// RenderOptions controls a deterministic offline render
+type RenderOptions struct {
+	Seed   uint32 // Noise seed (0 = DefaultSeed)
+	Frames int    // Stereo frames to render (0 = one pass of the song)
+}
+
+// Render renders the song from the start into interleaved stereo. It
+// uses neither the wall clock nor an audio device, so the same song and
+// options always give the same samples.
+func Render(song *tracker.Song, opts RenderOptions) []float64 {
+	frames := opts.Frames
+	if frames <= 0 {
+		frames = AnalyzeSong(song).Samples
+	}
+
+	player := NewPlayer(song)
+	if opts.Seed != 0 {
+		player.Seed = opts.Seed
+	}
+	player.SetPosition(0, 0)
+	player.Play()
+
+	out := make([]float64, frames*2)
+	for i := 0; i < len(out); i += 4096 {
+		player.GenerateStereo(out[i:min(i+4096, len(out))])
+	}
+	player.Stop()
+	return out
+}
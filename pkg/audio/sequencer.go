@@ -0,0 +1,171 @@
+package audio
+
+// The sequencer moves the song position one sample at a time. Rendering
+// (nextFrame) and silent simulation (AdvanceTime) both drive it through
+// step, so rows are processed at the same sample either way.
+
+type seqEventKind int
+
+const (
+	evTick seqEventKind = iota
+	evRow
+	evPattern
+)
+
+// seqEvent is a playback event queued while the audio lock is held
+type seqEvent struct {
+	kind                seqEventKind
+	pos, pat, row, tick int
+}
+
+// step advances the sequencer clock by one sample. A tick starts when
+// the fractional countdown runs out; the remainder carries over so the
+// average tick length is exact. Called with p.mu held.
+func (p *Player) step() {
+	p.stepPreview()
+	if !p.Playing {
+		p.syncSong()
+		return
+	}
+	if p.TickCounter <= 0 {
+		p.syncSong()
+		p.tick()
+		p.TickCounter += p.TickSamples
+	}
+	p.TickCounter--
+}
+
+// tick runs one sequencer tick: the row on tick 0, per-tick effects,
+// then the move to the next row and position
+func (p *Player) tick() {
+	if p.audition == rowReleasing {
+		p.releaseTick()
+		return
+	}
+	if p.Tick == 0 {
+		// First tick of row - process row
+		p.ProcessRow()
+		p.queue(seqEvent{kind: evRow, pos: p.Position, pat: p.Pattern, row: p.Row})
+	}
+
+	// Process tick effects
+	p.ProcessTick()
+	p.queue(seqEvent{kind: evTick, pos: p.Position, pat: p.Pattern, row: p.Row, tick: p.Tick})
+
+	p.Tick++
+	if p.Tick < p.Speed {
+		return
+	}
+	if p.audition == rowPlaying {
+		p.releaseRow()
+		return
+	}
+
+	p.Tick = 0
+	oldPos := p.Position
+	p.wrapped = p.nextRow(p.looping())
+	if p.Position != oldPos {
+		p.queue(seqEvent{kind: evPattern, pos: p.Position, pat: p.Pattern})
+	}
+}
+
+// nextRow moves to the row after the current one, following the Bxx
+// jump or Dxx break it set, repeating the loop range if loop is set and
+// wrapping at the end of the order. It reports whether it wrapped.
+func (p *Player) nextRow(loop bool) (wrapped bool) {
+	if p.jumpPos >= 0 || p.breakRow >= 0 {
+		p.Position++
+		if p.jumpPos >= 0 {
+			p.Position = p.jumpPos
+		}
+		p.Row = 0
+		if p.breakRow >= 0 {
+			p.Row = p.breakRow
+		}
+	} else {
+		p.Row++
+		if p.Row >= p.patternRows() {
+			p.Row = 0
+			p.Position++
+		}
+	}
+
+	if loop && (p.Position < p.loopFrom || p.Position > min(p.loopTo, len(p.Song.Order)-1)) {
+		p.Position = p.loopFrom // Repeat the loop range
+	}
+	if p.Position >= len(p.Song.Order) {
+		p.Position = 0 // Loop
+		wrapped = true
+	}
+	p.Pattern = int(p.Song.Order[p.Position])
+	if p.Row >= p.patternRows() {
+		p.Row = 0
+	}
+	return wrapped
+}
+
+// patternRows returns the row count of the current pattern (0 if missing)
+func (p *Player) patternRows() int {
+	if p.Pattern < 0 || p.Pattern >= len(p.Song.Patterns) {
+		return 0
+	}
+	return p.Song.Patterns[p.Pattern].Rows
+}
+
+// queue records an event if a callback is listening for it
+func (p *Player) queue(ev seqEvent) {
+	switch ev.kind {
+	case evTick:
+		if p.Callbacks.OnTick == nil {
+			return
+		}
+	case evRow:
+		if p.Callbacks.OnRow == nil {
+			return
+		}
+	case evPattern:
+		if p.Callbacks.OnPattern == nil {
+			return
+		}
+	}
+	p.events = append(p.events, ev)
+}
+
+// takeEvents removes the queued events; called with p.mu held
+func (p *Player) takeEvents() []seqEvent {
+	if len(p.events) == 0 {
+		return nil
+	}
+	events := make([]seqEvent, len(p.events))
+	copy(events, p.events)
+	p.events = p.events[:0]
+	return events
+}
+
+// dispatch delivers events to the callbacks. Called without p.mu held,
+// so callbacks may use the player freely.
+func (p *Player) dispatch(events []seqEvent) {
+	if len(events) == 0 {
+		return
+	}
+	p.mu.Lock()
+	cb := p.Callbacks
+	p.mu.Unlock()
+
+	for _, ev := range events {
+		switch ev.kind {
+		case evTick:
+			if cb.OnTick != nil {
+				cb.OnTick(ev.pos, ev.pat, ev.row, ev.tick)
+			}
+		case evRow:
+			if cb.OnRow != nil {
+				cb.OnRow(ev.pos, ev.pat, ev.row)
+			}
+		case evPattern:
+			if cb.OnPattern != nil {
+				cb.OnPattern(ev.pos, ev.pat)
+			}
+		}
+	}
+}
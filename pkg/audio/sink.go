@@ -0,0 +1,126 @@
+package audio
+
+import (
+	"encoding/binary"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// AudioSink drives a player in real time and sends its output somewhere
+// (a sound card, a file or pipe, or nowhere). Playback advances while
+// the sink is open.
+type AudioSink interface {
+	Close() error
+}
+
+// SinkConfig sets the block size and latency of a sink
+type SinkConfig struct {
+	BufferFrames int           // Stereo frames rendered per block (and the sound card buffer)
+	Latency      time.Duration // How far output may run ahead of playback
+}
+
+// DefaultSinkConfig returns 512-frame blocks with 100 ms latency
+func DefaultSinkConfig() SinkConfig {
+	return SinkConfig{BufferFrames: 512, Latency: 100 * time.Millisecond}
+}
+
+// withDefaults fills in unset fields
+func (c SinkConfig) withDefaults() SinkConfig {
+	def := DefaultSinkConfig()
+	if c.BufferFrames <= 0 {
+		c.BufferFrames = def.BufferFrames
+	}
+	if c.Latency <= 0 {
+		c.Latency = def.Latency
+	}
+	return c
+}
+
+// latencyFrames returns the latency in frames at the given sample rate
+func (c SinkConfig) latencyFrames(sampleRate int) int {
+	return int(c.Latency.Seconds() * float64(sampleRate))
+}
+
+// NullSink advances playback on the wall clock without producing sound
+type NullSink struct {
+	stop atomic.Bool
+	done chan struct{}
+}
+
+// NewNullSink starts advancing the player once per block
+func NewNullSink(player *Player, cfg SinkConfig) *NullSink {
+	cfg = cfg.withDefaults()
+	s := &NullSink{done: make(chan struct{})}
+	period := time.Duration(cfg.BufferFrames) * time.Second / time.Duration(player.SampleRate)
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+		for range ticker.C {
+			if s.stop.Load() {
+				return
+			}
+			player.AdvanceTime()
+		}
+	}()
+	return s
+}
+
+// Close stops advancing playback
+func (s *NullSink) Close() error {
+	s.stop.Store(true)
+	<-s.done
+	return nil
+}
+
+// PCMSink streams raw signed 16-bit little-endian stereo to a writer,
+// paced to real time so playback follows the wall clock
+type PCMSink struct {
+	stop atomic.Bool
+	done chan struct{}
+	err  error
+}
+
+// NewPCMSink starts streaming the player's output to w
+func NewPCMSink(player *Player, w io.Writer, cfg SinkConfig) *PCMSink {
+	cfg = cfg.withDefaults()
+	s := &PCMSink{done: make(chan struct{})}
+	go s.run(player, w, cfg)
+	return s
+}
+
+func (s *PCMSink) run(player *Player, w io.Writer, cfg SinkConfig) {
+	defer close(s.done)
+	rate := int64(player.SampleRate)
+	lead := int64(cfg.latencyFrames(player.SampleRate))
+	buffer := make([]float64, cfg.BufferFrames*2)
+	out := make([]byte, len(buffer)*2)
+
+	start := time.Now()
+	var frames int64
+	for !s.stop.Load() {
+		// Stay at most the latency ahead of real time
+		due := time.Duration((frames-lead)*int64(time.Second)/rate) - time.Since(start)
+		if due > 0 {
+			time.Sleep(due)
+		}
+
+		player.GenerateStereo(buffer)
+		for i, sample := range buffer {
+			binary.LittleEndian.PutUint16(out[i*2:], uint16(quantize(sample, 16)))
+		}
+		if _, err := w.Write(out); err != nil {
+			s.err = err
+			return
+		}
+		frames += int64(cfg.BufferFrames)
+	}
+}
+
+// Close stops streaming and returns the first write error, if any
+func (s *PCMSink) Close() error {
+	s.stop.Store(true)
+	<-s.done
+	return s.err
+}
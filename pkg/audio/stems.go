@@ -0,0 +1,181 @@
+package audio
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// stemTap splits the mix into one stereo stem per channel while the
+// player renders. Each channel gets its own copy of the send effects,
+// which are linear, and the stems are aligned with the master latency and
+// scaled by the gain the master applied to each sample, so they sum to the
+// master output.
+type stemTap struct {
+	delays  []*FeedbackDelay
+	reverbs []*Reverb
+
+	// Current frame inputs per channel: dry L/R, delay send L/R, reverb send L/R
+	in [][6]float64
+
+	// Alignment with the master latency
+	alignL, alignR []*delayLine
+	latency        int
+
+	// Rendered interleaved stereo stems since the last reset
+	out [][]float64
+}
+
+func newStemTap(p *Player) *stemTap {
+	n := len(p.Channels)
+	t := &stemTap{
+		delays:  make([]*FeedbackDelay, n),
+		reverbs: make([]*Reverb, n),
+		in:      make([][6]float64, n),
+		alignL:  make([]*delayLine, n),
+		alignR:  make([]*delayLine, n),
+		out:     make([][]float64, n),
+		latency: p.Master.Latency(&p.Song.Master),
+	}
+	for i := 0; i < n; i++ {
+		t.delays[i] = NewFeedbackDelay(p.SampleRate)
+		t.reverbs[i] = NewReverb(p.SampleRate)
+		t.alignL[i] = newDelayLine(t.latency)
+		t.alignR[i] = newDelayLine(t.latency)
+	}
+	return t
+}
+
+// channel records one channel's contribution to the current frame
+func (t *stemTap) channel(ch int, dryL, dryR, delayL, delayR, reverbL, reverbR float64) {
+	if ch < len(t.in) {
+		t.in[ch] = [6]float64{dryL, dryR, delayL, delayR, reverbL, reverbR}
+	}
+}
+
+// finish completes the frame once the master has processed it
+func (t *stemTap) finish(p *Player) {
+	// The master gain stage, clipper or limiter reduced to a gain per side
+	ratioL, ratioR := p.Master.appliedL, p.Master.appliedR
+	for i := range t.in {
+		in := &t.in[i]
+		if t.delays[i].Samples != p.Delay.Samples {
+			t.delays[i].SetDelay(p.Delay.Samples)
+		}
+		dl, dr := t.delays[i].Process(in[2], in[3], &p.Song.Bus)
+		rl, rr := t.reverbs[i].Process(in[4], in[5], &p.Song.Bus)
+		l, r := in[0]+dl+rl, in[1]+dr+rr
+		*in = [6]float64{}
+
+		if t.latency > 0 {
+			// Read before writing, as the limiter does
+			al, ar := t.alignL[i].read(t.latency), t.alignR[i].read(t.latency)
+			t.alignL[i].write(l)
+			t.alignR[i].write(r)
+			l, r = al, ar
+		}
+		t.out[i] = append(t.out[i], l*ratioL, r*ratioR)
+	}
+}
+
+// reset discards the rendered stems
+func (t *stemTap) reset() {
+	for i := range t.out {
+		t.out[i] = t.out[i][:0]
+	}
+}
+
+// StemName returns a file-safe name for channel ch's stem, e.g. "01_Lead"
+func StemName(ch int, name string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, strings.TrimSpace(name))
+	if name == "" {
+		return fmt.Sprintf("%02d", ch+1)
+	}
+	return fmt.Sprintf("%02d_%s", ch+1, name)
+}
+
+// MasterStemName is the name of the full mix written alongside the stems
+const MasterStemName = "master"
+
+// ExportStems renders the song once, writing one stereo file per channel
+// and, when master is set, the full mix. open creates the destination
+// for a stem name (see StemName and MasterStemName). The render is the
+// same as Export, so the stems sum to the master.
+func ExportStems(player *Player, open func(name string) (io.WriteCloser, error), opts ExportOptions, master bool) error {
+	song := player.Song
+	tags := SongTags(song)
+
+	var files []io.WriteCloser
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	newEncoder := func(name string) (Encoder, error) {
+		f, err := open(name)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+		return NewEncoder(opts.Format, f, player.SampleRate, 2, tags)
+	}
+
+	var stems []Encoder
+	for ch := 0; ch < song.Channels; ch++ {
+		name := ""
+		if ch < len(song.ChanConfig) {
+			name = song.ChanConfig[ch].Name
+		}
+		enc, err := newEncoder(StemName(ch, name))
+		if err != nil {
+			return err
+		}
+		stems = append(stems, enc)
+	}
+	var mix Encoder
+	if master {
+		var err error
+		if mix, err = newEncoder(MasterStemName); err != nil {
+			return err
+		}
+	}
+
+	err := render(player, opts, true, func(buf []float64) error {
+		for i, enc := range stems {
+			if err := enc.WriteSamples(player.stems.out[i]); err != nil {
+				return err
+			}
+		}
+		if mix != nil {
+			return mix.WriteSamples(buf)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, enc := range stems {
+		if err := enc.Close(); err != nil {
+			return err
+		}
+	}
+	if mix != nil {
+		if err := mix.Close(); err != nil {
+			return err
+		}
+	}
+	for _, f := range files {
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+	files = nil
+	return nil
+}
@@ -0,0 +1,99 @@
+package audio
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/anthropics/abytetracker/pkg/tracker"
+)
+
+// memCloser is a memFile handed out by an ExportStems open function
+type memCloser struct{ memFile }
+
+func (*memCloser) Close() error { return nil }
+
+// wavFloats returns the samples in the data chunk of a float WAV file
+func wavFloats(t *testing.T, data []byte) []float64 {
+	t.Helper()
+	le := binary.LittleEndian
+	for p := 12; p+8 <= len(data); {
+		id, size := string(data[p:p+4]), int(le.Uint32(data[p+4:]))
+		if id == "data" {
+			out := make([]float64, size/4)
+			for i := range out {
+				out[i] = float64(math.Float32frombits(le.Uint32(data[p+8+i*4:])))
+			}
+			return out
+		}
+		p += 8 + size + size%2
+	}
+	t.Fatal("no data chunk")
+	return nil
+}
+
+func TestStemsSumToMaster(t *testing.T) {
+	// Three loud channels with delay and reverb sends, driven into the
+	// limiter or the soft clipper: the stems must still add up to the
+	// master sample by sample
+	for _, limiter := range []bool{true, false} {
+		song := tracker.NewSong(3)
+		song.Master = tracker.MasterConfig{Gain: 128, Limiter: limiter}
+		pat := song.Patterns[song.Order[0]]
+		for ch := 0; ch < 3; ch++ {
+			pat.Notes[0][ch] = tracker.Note{Pitch: int8(36 + 7*ch), Instrument: 1, Volume: -1}
+			pat.Notes[8][ch] = tracker.Note{Pitch: -2, Volume: -1}
+			song.ChanConfig[ch].Pan = int8(-48 + 48*ch)
+		}
+		song.ChanConfig[0].DelaySend = 48
+		song.ChanConfig[1].ReverbSend = 64
+		song.ChanConfig[2].DelaySend = 32
+		song.ChanConfig[2].ReverbSend = 32
+
+		files := map[string]*memCloser{}
+		open := func(name string) (io.WriteCloser, error) {
+			f := &memCloser{}
+			files[name] = f
+			return f, nil
+		}
+		if err := ExportStems(NewPlayer(song), open, ExportOptions{Format: FormatWAVFloat}, true); err != nil {
+			t.Fatal(err)
+		}
+
+		master := wavFloats(t, files[MasterStemName].data)
+		var peak float64
+		for _, s := range master {
+			peak = math.Max(peak, math.Abs(s))
+		}
+		if limiter && (peak < 0.9*limiterCeiling || peak > limiterCeiling+1e-6) {
+			t.Fatalf("master peaks at %g; the limiter (ceiling %g) is not in use", peak, limiterCeiling)
+		}
+		if !limiter && peak < 0.9 {
+			t.Fatalf("master peaks at %g; the soft clipper is not in use", peak)
+		}
+
+		sum := make([]float64, len(master))
+		for ch := 0; ch < 3; ch++ {
+			stem := wavFloats(t, files[StemName(ch, song.ChanConfig[ch].Name)].data)
+			if len(stem) != len(master) {
+				t.Fatalf("stem %d has %d samples, master %d", ch, len(stem), len(master))
+			}
+			for i, s := range stem {
+				// Stems are scaled by the master's gain, so they stay
+				// near the level of the mix; dividing by the mix would
+				// blow them up wherever it crosses zero
+				if math.Abs(s) > 4 {
+					t.Fatalf("limiter %v: stem %d sample %d = %g", limiter, ch, i, s)
+				}
+				sum[i] += s
+			}
+		}
+		for i := range master {
+			// Each file is rounded to float32
+			if d := math.Abs(sum[i] - master[i]); d > 1e-6 {
+				t.Fatalf("limiter %v: sample %d: stems sum to %g, master is %g", limiter, i, sum[i], master[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package audio
+
+// SetLoop makes playback repeat the order positions from..to (inclusive)
+// instead of running through the whole song. Jumps out of the range land
+// back on its start.
+func (p *Player) SetLoop(from, to int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if to < from {
+		from, to = to, from
+	}
+	p.loopFrom = max(from, 0)
+	p.loopTo = max(to, 0)
+}
+
+// ClearLoop returns to playing the whole song
+func (p *Player) ClearLoop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.loopFrom = -1
+	p.loopTo = -1
+}
+
+// Loop returns the loop range set with SetLoop
+func (p *Player) Loop() (from, to int, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.loopFrom, p.loopTo, p.loopFrom >= 0
+}
+
+// looping reports whether a loop range applies to the current order.
+// Called with p.mu held.
+func (p *Player) looping() bool {
+	return p.loopFrom >= 0 && p.loopFrom < len(p.Song.Order)
+}
+
+// rowAudition is the state of a row started by PlayRow
+type rowAudition int
+
+const (
+	rowOff       rowAudition = iota
+	rowPlaying               // Playing the row; released when it ends
+	rowReleasing             // Row over; ticking only until the notes fade out
+)
+
+// PlayRow auditions one row: it plays the row at pos/row, only channel ch
+// or every channel when ch < 0, and releases the notes when the row ends.
+// Settings from earlier rows are chased as for SetPosition.
+func (p *Player) PlayRow(pos, row, ch int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.syncSong()
+	if pos < 0 || pos >= len(p.Song.Order) {
+		return
+	}
+	p.Position = pos
+	p.Pattern = int(p.Song.Order[pos])
+	p.Row = row
+	p.chase()
+	p.Tick = 0
+	p.TickCounter = 0
+	p.audition = rowPlaying
+	p.rowChannel = ch
+	p.Playing = true
+	p.LastTime = p.Clock()
+	p.timeCarry = 0
+}
+
+// RowPreview reports whether a row started by PlayRow is still sounding
+func (p *Player) RowPreview() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.Playing && p.audition != rowOff
+}
+
+// releaseRow ends an auditioned row, releasing its notes. Channels
+// without an instrument have no envelope to release and stop at once.
+// Called with p.mu held.
+func (p *Player) releaseRow() {
+	p.audition = rowReleasing
+	p.Tick = 0
+	for _, cs := range p.Channels {
+		if cs.Instrument < 0 || cs.Instrument >= len(p.Song.Instruments) {
+			cs.Active = false
+			continue
+		}
+		cs.NoteOff()
+	}
+}
+
+// releaseTick ticks the channels of a released row and stops playback
+// once they have all faded out. Called with p.mu held.
+func (p *Player) releaseTick() {
+	p.ProcessTick()
+	for _, cs := range p.Channels {
+		if cs.Active {
+			return
+		}
+	}
+	p.Playing = false
+	p.audition = rowOff
+	p.rowChannel = -1
+}
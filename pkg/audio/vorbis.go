@@ -0,0 +1,872 @@
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"sort"
+)
+
+// Vorbis encoding: long blocks only, one floor 1 curve and one residue 1
+// vector per channel, stereo coupled as magnitude and angle, coded with
+// static codebooks. The floor follows a simple spreading mask, so the
+// quantization noise stays a fixed distance below the loud bands nearby.
+const (
+	vorbisBlockBits = 11 // 2048-sample blocks
+	vorbisShortBits = 8  // Advertised short blocks, never used
+	vorbisBlockSize = 1 << vorbisBlockBits
+	vorbisHalf      = vorbisBlockSize / 2
+
+	// vorbisNoise is the quantization noise allowed in a band, relative to
+	// its power (-15 dB)
+	vorbisNoise = 0.0316
+	// vorbisSpreadUp and vorbisSpreadDown are how much of a band's power
+	// masks the bands one post above and below it (-10 and -25 dB)
+	vorbisSpreadUp   = 0.1
+	vorbisSpreadDown = 0.00316
+	// vorbisPeak is the largest residue the floor is set for, so the
+	// residue codebooks can always reach it
+	vorbisPeak = 24
+	// vorbisSilence is the smallest floor, about -100 dB, below which
+	// coefficients are dropped
+	vorbisSilence = 1e-5
+)
+
+// Floor 1 setup: 32 posts between the fixed ones at 0 and vorbisHalf,
+// in 8 partitions of 4, with Y values scaled by 2 (range 0-127)
+const (
+	vorbisFloorPosts      = 32
+	vorbisFloorPartitions = 8
+	vorbisFloorDim        = vorbisFloorPosts / vorbisFloorPartitions
+	vorbisFloorMult       = 2
+	vorbisFloorRange      = 128
+	vorbisFloorRangeBits  = vorbisBlockBits - 1
+)
+
+// Residue 1 setup: partitions of 16 coefficients, two classwords per
+// classbook entry, four classes: silent, within ±1, within ±4, and
+// within ±76 as a coarse step of 9 plus a fine ±4 second pass. Channel
+// residues stay within ±38, so a coupled angle (their difference) fits.
+const (
+	vorbisPartition   = 16
+	vorbisClasses     = 4
+	vorbisClassWords  = 2
+	vorbisCoarseStep  = 9
+	vorbisCoarseSteps = 8
+	vorbisMaxResidue  = (vorbisCoarseSteps*vorbisCoarseStep + 4) / 2
+)
+
+// Codebook numbers in the setup header
+const (
+	vorbisBookFloor = iota
+	vorbisBookClass
+	vorbisBookUnit   // ±1, four values per entry
+	vorbisBookFine   // ±4, two values per entry
+	vorbisBookCoarse // ±72 in steps of 9, two values per entry
+)
+
+// vorbisFloorDB is the floor 1 inverse dB table: 256 steps from about
+// -140 dB to 0 dB
+var vorbisFloorDB = func() [256]float64 {
+	var t [256]float64
+	step := -math.Log(1.0649863e-07) / 255
+	for i := range t {
+		t[i] = math.Exp(step * float64(i-255))
+	}
+	return t
+}()
+
+// vorbisPosts lists the floor X positions in coding order: the fixed
+// ends, then the rest spaced roughly logarithmically and ordered by
+// bisection, so each post is predicted from nearby ones
+var vorbisPosts = func() []int {
+	sorted := make([]int, 0, vorbisFloorPosts)
+	for k := 1; len(sorted) < vorbisFloorPosts; k++ {
+		x := int(math.Round(math.Pow(vorbisHalf, float64(k)/(vorbisFloorPosts+1))))
+		if n := len(sorted); n > 0 && x <= sorted[n-1] {
+			x = sorted[n-1] + 1
+		}
+		sorted = append(sorted, x)
+	}
+	posts := []int{0, vorbisHalf}
+	queue := [][2]int{{0, len(sorted)}}
+	for len(queue) > 0 {
+		lo, hi := queue[0][0], queue[0][1]
+		queue = queue[1:]
+		if lo < hi {
+			mid := (lo + hi) / 2
+			posts = append(posts, sorted[mid])
+			queue = append(queue, [2]int{lo, mid}, [2]int{mid + 1, hi})
+		}
+	}
+	return posts
+}()
+
+// vorbisSorted lists the post indexes in order of X
+var vorbisSorted = func() []int {
+	order := make([]int, len(vorbisPosts))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool { return vorbisPosts[order[a]] < vorbisPosts[order[b]] })
+	return order
+}()
+
+// vorbisNeighbors are, per post, the closest earlier posts below and
+// above it, which predict its value
+var vorbisNeighbors = func() [][2]int {
+	nb := make([][2]int, len(vorbisPosts))
+	for i := 2; i < len(vorbisPosts); i++ {
+		x := vorbisPosts[i]
+		lo, hi := 0, 1
+		for j := 0; j < i; j++ {
+			if p := vorbisPosts[j]; p < x && p > vorbisPosts[lo] {
+				lo = j
+			} else if p > x && p < vorbisPosts[hi] {
+				hi = j
+			}
+		}
+		nb[i] = [2]int{lo, hi}
+	}
+	return nb
+}()
+
+// vorbisBooks are the codebooks, indexed by the vorbisBook constants
+var vorbisBooks = []*vorbisBook{
+	vorbisBookFloor: newVorbisBook(1, vorbisFloorRange, func(e int) float64 {
+		return math.Exp(-float64(e) / 6)
+	}, nil),
+	vorbisBookClass: newVorbisBook(vorbisClassWords, vorbisClasses*vorbisClasses, func(e int) float64 {
+		p := [vorbisClasses]float64{4, 3, 3, 1}
+		return p[e%vorbisClasses] * p[e/vorbisClasses]
+	}, nil),
+	vorbisBookUnit: newVorbisBook(4, 81, nil, &vorbisLattice{min: -1, step: 1, values: 3}),
+	vorbisBookFine: newVorbisBook(2, 81, nil, &vorbisLattice{min: -4, step: 1, values: 9}),
+	vorbisBookCoarse: newVorbisBook(2, 289, nil, &vorbisLattice{
+		min: -vorbisCoarseSteps * vorbisCoarseStep, step: vorbisCoarseStep, values: 2*vorbisCoarseSteps + 1}),
+}
+
+// vorbisEncoder writes an Ogg Vorbis stream
+type vorbisEncoder struct {
+	ogg        *oggWriter
+	sampleRate int
+	channels   int
+
+	pcm    [][]float64 // Per channel: the previous half block, then the current one
+	n      int         // Samples in the current half block
+	total  int64       // Samples written
+	blocks int64       // Audio packets written
+
+	window   []float64
+	windowed []float64
+	mdct     *mdct
+	coeffs   [][]float64
+	floors   [][]float64
+	posts    [][]int // Coded post values per channel
+	res      [][]int
+	used     []bool
+	bw       vorbisBits
+}
+
+func newVorbisEncoder(w io.Writer, sampleRate, channels int, tags Tags) (*vorbisEncoder, error) {
+	if channels < 1 || channels > 255 {
+		return nil, errors.New("vorbis: 1 to 255 channels supported")
+	}
+	if sampleRate < 1 {
+		return nil, errors.New("vorbis: unsupported sample rate")
+	}
+	e := &vorbisEncoder{
+		ogg:        newOggWriter(w),
+		sampleRate: sampleRate,
+		channels:   channels,
+		window:     make([]float64, vorbisBlockSize),
+		windowed:   make([]float64, vorbisBlockSize),
+		// Scaled so that a decoder's inverse transform, windowed and
+		// overlapped, gives back the input
+		mdct: newMDCT(vorbisHalf, 2.0/vorbisHalf),
+		used: make([]bool, channels),
+	}
+	for i := range e.window {
+		s := math.Sin(math.Pi * (float64(i) + 0.5) / vorbisBlockSize)
+		e.window[i] = math.Sin(math.Pi / 2 * s * s)
+	}
+	for range channels {
+		e.pcm = append(e.pcm, make([]float64, vorbisBlockSize))
+		e.coeffs = append(e.coeffs, make([]float64, vorbisHalf))
+		e.floors = append(e.floors, make([]float64, vorbisHalf))
+		e.posts = append(e.posts, make([]int, len(vorbisPosts)))
+		e.res = append(e.res, make([]int, vorbisHalf))
+	}
+
+	for _, packet := range [][]byte{e.identification(), e.comment(tags), e.setup()} {
+		if err := e.ogg.writePacket(packet, 0); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// vorbisHeader starts a header packet of the given type
+func vorbisHeader(typ byte) []byte {
+	return append([]byte{typ}, "vorbis"...)
+}
+
+// identification returns the first header packet
+func (e *vorbisEncoder) identification() []byte {
+	le := binary.LittleEndian
+	b := vorbisHeader(1)
+	b = le.AppendUint32(b, 0) // Version
+	b = append(b, byte(e.channels))
+	b = le.AppendUint32(b, uint32(e.sampleRate))
+	b = le.AppendUint32(b, 0) // Maximum, nominal and minimum bitrate unset
+	b = le.AppendUint32(b, 0)
+	b = le.AppendUint32(b, 0)
+	return append(b, vorbisShortBits|vorbisBlockBits<<4, 1)
+}
+
+// comment returns the comment header packet with the tags
+func (e *vorbisEncoder) comment(tags Tags) []byte {
+	b := append(vorbisHeader(3), vorbisComment(tags)...)
+	return append(b, 1) // Framing bit
+}
+
+// setup returns the setup header packet: codebooks, floor, residue,
+// mapping and mode
+func (e *vorbisEncoder) setup() []byte {
+	bw := &e.bw
+	bw.reset()
+	bw.write(uint32(len(vorbisBooks)-1), 8)
+	for _, book := range vorbisBooks {
+		book.writeHeader(bw)
+	}
+	bw.write(0, 6) // One time-domain transform, a placeholder
+	bw.write(0, 16)
+
+	// Floor 1: every partition uses class 0, coded with the floor book
+	bw.write(0, 6)
+	bw.write(1, 16)
+	bw.write(vorbisFloorPartitions, 5)
+	for range vorbisFloorPartitions {
+		bw.write(0, 4)
+	}
+	bw.write(vorbisFloorDim-1, 3)
+	bw.write(0, 2) // No subclasses
+	bw.write(vorbisBookFloor+1, 8)
+	bw.write(vorbisFloorMult-1, 2)
+	bw.write(vorbisFloorRangeBits, 4)
+	for _, x := range vorbisPosts[2:] {
+		bw.write(uint32(x), vorbisFloorRangeBits)
+	}
+
+	// Residue 1 over the whole spectrum
+	bw.write(0, 6)
+	bw.write(1, 16)
+	bw.write(0, 24)
+	bw.write(vorbisHalf, 24)
+	bw.write(vorbisPartition-1, 24)
+	bw.write(vorbisClasses-1, 6)
+	bw.write(vorbisBookClass, 8)
+	cascades := [vorbisClasses][]int{
+		nil,
+		{vorbisBookUnit},
+		{vorbisBookFine},
+		{vorbisBookCoarse, vorbisBookFine},
+	}
+	for _, books := range cascades {
+		bw.write(uint32(1<<len(books)-1), 3) // Passes 0 to len-1
+		bw.write(0, 1)
+	}
+	for _, books := range cascades {
+		for _, b := range books {
+			bw.write(uint32(b), 8)
+		}
+	}
+
+	// Mapping 0: one submap, stereo coupled as magnitude and angle
+	bw.write(0, 6)
+	bw.write(0, 16)
+	bw.write(0, 1)
+	if e.channels == 2 {
+		bw.write(1, 1)
+		bw.write(0, 8) // One coupling step
+		bw.write(0, 1) // ilog(channels - 1) bits each
+		bw.write(1, 1)
+	} else {
+		bw.write(0, 1)
+	}
+	bw.write(0, 2)
+	bw.write(0, 8) // Unused time configuration
+	bw.write(0, 8) // Floor 0
+	bw.write(0, 8) // Residue 0
+
+	// Mode 0: long blocks
+	bw.write(0, 6)
+	bw.write(1, 1)
+	bw.write(0, 16)
+	bw.write(0, 16)
+	bw.write(0, 8)
+
+	bw.write(1, 1) // Framing bit
+	return append(vorbisHeader(5), bw.bytes()...)
+}
+
+// WriteSamples encodes interleaved float samples
+func (e *vorbisEncoder) WriteSamples(samples []float64) error {
+	for i := 0; i+e.channels <= len(samples); i += e.channels {
+		for ch := 0; ch < e.channels; ch++ {
+			e.pcm[ch][vorbisHalf+e.n] = samples[i+ch]
+		}
+		e.n++
+		e.total++
+		if e.n == vorbisHalf {
+			if err := e.flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Close pads the last block with silence, ends the stream on the last
+// sample written and finishes the final page
+func (e *vorbisEncoder) Close() error {
+	// Each packet completes the half block before its own, so the last
+	// samples need one packet more
+	for e.total > 0 && (e.blocks-1)*vorbisHalf < e.total {
+		for ch := range e.pcm {
+			clear(e.pcm[ch][vorbisHalf+e.n:])
+		}
+		if err := e.flush(); err != nil {
+			return err
+		}
+	}
+	return e.ogg.close()
+}
+
+// flush encodes the previous and current half blocks as one packet and
+// moves on by half a block
+func (e *vorbisEncoder) flush() error {
+	for ch := range e.pcm {
+		e.analyze(ch)
+	}
+	if len(e.pcm) == 2 {
+		e.couple()
+	}
+
+	bw := &e.bw
+	bw.reset()
+	bw.write(0, 1) // Audio packet; the only mode needs no bits
+	bw.write(1, 1) // Long previous and next windows
+	bw.write(1, 1)
+	for ch := range e.pcm {
+		e.writeFloor(ch)
+	}
+	e.writeResidue()
+
+	// The first packet only primes the decoder; each later one completes
+	// the half block before it
+	granule := e.blocks * vorbisHalf
+	e.blocks++
+	e.n = 0
+	for ch := range e.pcm {
+		copy(e.pcm[ch], e.pcm[ch][vorbisHalf:])
+	}
+	return e.ogg.writePacket(bw.bytes(), min(granule, e.total))
+}
+
+// analyze transforms one channel's block and fits the floor and residue
+func (e *vorbisEncoder) analyze(ch int) {
+	pcm, coeffs, windowed := e.pcm[ch], e.coeffs[ch], e.windowed
+	for i, s := range pcm {
+		windowed[i] = s * e.window[i]
+	}
+	e.mdct.forward(windowed, coeffs)
+
+	// Measure the band between each post's neighbours in X, so the line
+	// from either side covers every peak
+	bands := len(vorbisSorted)
+	peak, power := make([]float64, bands), make([]float64, bands)
+	for j, i := range vorbisSorted {
+		lo, hi := vorbisPosts[i], vorbisHalf
+		if j > 0 {
+			lo = vorbisPosts[vorbisSorted[j-1]]
+		}
+		if j+1 < bands {
+			hi = vorbisPosts[vorbisSorted[j+1]]
+		}
+		band := coeffs[lo:min(hi+1, vorbisHalf)]
+		for _, c := range band {
+			peak[j] = math.Max(peak[j], math.Abs(c))
+			power[j] += c * c
+		}
+		power[j] /= float64(len(band))
+	}
+
+	// Loud bands mask the bands around them, more so above than below.
+	// The floor lets quantization noise up to a fixed distance below the
+	// mask, but keeps every peak within reach of the residue books.
+	target := make([]int, len(vorbisPosts))
+	for j, i := range vorbisSorted {
+		mask := 0.0
+		for k := range bands {
+			spread := vorbisSpreadUp
+			if k > j {
+				spread = vorbisSpreadDown
+			}
+			mask = math.Max(mask, power[k]*math.Pow(spread, math.Abs(float64(j-k))))
+		}
+		target[i] = vorbisFloorY(math.Max(math.Sqrt(12*mask*vorbisNoise), peak[j]/vorbisPeak))
+	}
+
+	// Code the posts the way the decoder predicts them
+	final := make([]int, len(vorbisPosts))
+	step2 := make([]bool, len(vorbisPosts))
+	posts := e.posts[ch]
+	for i, x := range vorbisPosts {
+		if i < 2 {
+			posts[i], final[i], step2[i] = target[i], target[i], true
+			continue
+		}
+		nb := vorbisNeighbors[i]
+		predicted := vorbisRenderPoint(vorbisPosts[nb[0]], final[nb[0]], vorbisPosts[nb[1]], final[nb[1]], x)
+		posts[i], final[i] = vorbisCodePost(predicted, target[i])
+		if posts[i] != 0 {
+			step2[i], step2[nb[0]], step2[nb[1]] = true, true, true
+		}
+	}
+	vorbisRenderFloor(final, step2, e.floors[ch])
+
+	res := e.res[ch]
+	e.used[ch] = false
+	for i, c := range coeffs {
+		r := int(math.Round(c / e.floors[ch][i]))
+		res[i] = max(-vorbisMaxResidue, min(r, vorbisMaxResidue))
+		if res[i] != 0 {
+			e.used[ch] = true
+		}
+	}
+}
+
+// couple turns a stereo pair's residues into square polar magnitude and
+// angle, which the decoder undoes before applying the floors. The
+// decoder codes both residues when either floor is in use.
+func (e *vorbisEncoder) couple() {
+	if !e.used[0] && !e.used[1] {
+		return
+	}
+	e.used[0], e.used[1] = true, true
+	mag, ang := e.res[0], e.res[1]
+	for i, l := range mag {
+		r := ang[i]
+		switch {
+		case l > 0 && l > r:
+			mag[i], ang[i] = l, l-r
+		case r > 0:
+			mag[i], ang[i] = r, l-r
+		case r > l:
+			mag[i], ang[i] = l, r-l
+		default:
+			mag[i], ang[i] = r, r-l
+		}
+	}
+}
+
+// vorbisFloorY returns the smallest post value whose floor is at least
+// level
+func vorbisFloorY(level float64) int {
+	if level <= vorbisSilence {
+		level = vorbisSilence
+	}
+	for y := 0; y < vorbisFloorRange; y++ {
+		if vorbisFloorDB[y*vorbisFloorMult] >= level {
+			return y
+		}
+	}
+	return vorbisFloorRange - 1
+}
+
+// vorbisCodePost returns the coded value that makes a post with the
+// given prediction decode to target, and the value it decodes to
+func vorbisCodePost(predicted, target int) (coded, final int) {
+	for v := 0; v < vorbisFloorRange; v++ {
+		if y := vorbisDecodePost(predicted, v); y == target {
+			return v, y
+		}
+	}
+	return 0, predicted
+}
+
+// vorbisDecodePost is the decoder's floor 1 amplitude reconstruction of
+// one post from its prediction and coded value
+func vorbisDecodePost(predicted, v int) int {
+	highroom := vorbisFloorRange - predicted
+	lowroom := predicted
+	room := 2 * min(highroom, lowroom)
+	switch {
+	case v == 0:
+		return predicted
+	case v >= room && highroom > lowroom:
+		return v - lowroom + predicted
+	case v >= room:
+		return predicted - v + highroom - 1
+	case v%2 == 1:
+		return predicted - (v+1)/2
+	default:
+		return predicted + v/2
+	}
+}
+
+// vorbisRenderPoint is the decoder's integer line prediction at x
+func vorbisRenderPoint(x0, y0, x1, y1, x int) int {
+	dy := y1 - y0
+	ady := dy
+	if ady < 0 {
+		ady = -ady
+	}
+	off := ady * (x - x0) / (x1 - x0)
+	if dy < 0 {
+		return y0 - off
+	}
+	return y0 + off
+}
+
+// vorbisRenderFloor draws the floor curve through the posts in use, as
+// the decoder does, and converts it to linear amplitude
+func vorbisRenderFloor(final []int, step2 []bool, floor []float64) {
+	lx, ly := 0, final[0]*vorbisFloorMult
+	for _, i := range vorbisSorted[1:] {
+		if !step2[i] {
+			continue
+		}
+		hx, hy := vorbisPosts[i], final[i]*vorbisFloorMult
+		vorbisRenderLine(lx, ly, hx, hy, floor)
+		lx, ly = hx, hy
+	}
+}
+
+// vorbisRenderLine is the decoder's integer line from (x0, y0) up to
+// but not including x1
+func vorbisRenderLine(x0, y0, x1, y1 int, floor []float64) {
+	dy := y1 - y0
+	adx := x1 - x0
+	ady := dy
+	if ady < 0 {
+		ady = -ady
+	}
+	base := dy / adx
+	sy := base + 1
+	if dy < 0 {
+		sy = base - 1
+	}
+	absBase := base
+	if absBase < 0 {
+		absBase = -absBase
+	}
+	ady -= absBase * adx
+
+	y, err := y0, 0
+	floor[x0] = vorbisFloorDB[y]
+	for x := x0 + 1; x < x1; x++ {
+		err += ady
+		if err >= adx {
+			err -= adx
+			y += sy
+		} else {
+			y += base
+		}
+		floor[x] = vorbisFloorDB[y]
+	}
+}
+
+// writeFloor codes one channel's floor, or marks it unused when the
+// channel has nothing to code
+func (e *vorbisEncoder) writeFloor(ch int) {
+	bw := &e.bw
+	if !e.used[ch] {
+		bw.write(0, 1)
+		return
+	}
+	bw.write(1, 1)
+	posts := e.posts[ch]
+	bw.write(uint32(posts[0]), 7) // ilog(range - 1) bits each
+	bw.write(uint32(posts[1]), 7)
+	for _, v := range posts[2:] {
+		vorbisBooks[vorbisBookFloor].writeEntry(bw, v)
+	}
+}
+
+// writeResidue codes the residues of the channels in use, in the
+// decoder's order: per pass, groups of partitions with their classwords
+// first, each partition for every channel in turn
+func (e *vorbisEncoder) writeResidue() {
+	const partitions = vorbisHalf / vorbisPartition
+	var chans []int
+	for ch, used := range e.used {
+		if used {
+			chans = append(chans, ch)
+		}
+	}
+	if len(chans) == 0 {
+		return
+	}
+
+	classes := make([][partitions]int, len(e.used))
+	for _, ch := range chans {
+		for p := range partitions {
+			peak := 0
+			for _, r := range e.res[ch][p*vorbisPartition : (p+1)*vorbisPartition] {
+				peak = max(peak, r, -r)
+			}
+			switch {
+			case peak == 0:
+				classes[ch][p] = 0
+			case peak <= 1:
+				classes[ch][p] = 1
+			case peak <= 4:
+				classes[ch][p] = 2
+			default:
+				classes[ch][p] = 3
+			}
+		}
+	}
+
+	bw := &e.bw
+	for pass := range 2 {
+		for p := 0; p < partitions; p += vorbisClassWords {
+			if pass == 0 {
+				for _, ch := range chans {
+					word := 0
+					for i := range vorbisClassWords {
+						word = word*vorbisClasses + classes[ch][p+i]
+					}
+					vorbisBooks[vorbisBookClass].writeEntry(bw, word)
+				}
+			}
+			for i := range vorbisClassWords {
+				for _, ch := range chans {
+					e.writePartition(bw, pass, classes[ch][p+i], e.res[ch][(p+i)*vorbisPartition:(p+i+1)*vorbisPartition])
+				}
+			}
+		}
+	}
+}
+
+// writePartition codes one partition's share of a residue pass
+func (e *vorbisEncoder) writePartition(bw *vorbisBits, pass, class int, res []int) {
+	var book *vorbisBook
+	vec := make([]int, len(res))
+	switch {
+	case class == 1 && pass == 0:
+		book = vorbisBooks[vorbisBookUnit]
+		copy(vec, res)
+	case class == 2 && pass == 0:
+		book = vorbisBooks[vorbisBookFine]
+		copy(vec, res)
+	case class == 3 && pass == 0:
+		book = vorbisBooks[vorbisBookCoarse]
+		for i, r := range res {
+			vec[i] = vorbisCoarse(r)
+		}
+	case class == 3 && pass == 1:
+		book = vorbisBooks[vorbisBookFine]
+		for i, r := range res {
+			vec[i] = r - vorbisCoarse(r)
+		}
+	default:
+		return
+	}
+	for i := 0; i < len(vec); i += book.dim {
+		book.writeVector(bw, vec[i:i+book.dim])
+	}
+}
+
+// vorbisCoarse returns the coarse step nearest r, leaving at most ±4
+func vorbisCoarse(r int) int {
+	const bias = vorbisCoarseSteps * vorbisCoarseStep
+	q := (r + bias + vorbisCoarseStep/2) / vorbisCoarseStep
+	return q*vorbisCoarseStep - bias
+}
+
+// vorbisLattice is the value grid of a lookup type 1 codebook
+type vorbisLattice struct {
+	min, step int
+	values    int // Values per dimension
+}
+
+// vorbisBook is a Vorbis codebook: Huffman codewords for each entry and,
+// for vector books, the values each entry stands for
+type vorbisBook struct {
+	dim     int
+	lengths []int
+	codes   []uint32
+	lattice *vorbisLattice
+}
+
+// newVorbisBook builds a book whose codeword lengths fit the given entry
+// weights; vector books weight entries by how small their values are
+func newVorbisBook(dim, entries int, weight func(entry int) float64, lattice *vorbisLattice) *vorbisBook {
+	if lattice != nil {
+		weight = func(e int) float64 {
+			w := 1.0
+			for range dim {
+				v := lattice.min + e%lattice.values*lattice.step
+				w *= math.Exp(-math.Abs(float64(v)) / float64(lattice.step))
+				e /= lattice.values
+			}
+			return w
+		}
+	}
+	weights := make([]float64, entries)
+	for i := range weights {
+		weights[i] = weight(i)
+	}
+	b := &vorbisBook{dim: dim, lengths: huffmanLengths(weights), lattice: lattice}
+	b.codes = vorbisCodewords(b.lengths)
+	return b
+}
+
+// writeHeader writes the codebook to the setup header
+func (b *vorbisBook) writeHeader(bw *vorbisBits) {
+	bw.write(0x564342, 24)
+	bw.write(uint32(b.dim), 16)
+	bw.write(uint32(len(b.lengths)), 24)
+	bw.write(0, 1) // Not ordered
+	bw.write(0, 1) // Not sparse
+	for _, l := range b.lengths {
+		bw.write(uint32(l-1), 5)
+	}
+	if b.lattice == nil {
+		bw.write(0, 4) // No lookup
+		return
+	}
+	bw.write(1, 4)
+	bw.write(vorbisFloat(float64(b.lattice.min)), 32)
+	bw.write(vorbisFloat(float64(b.lattice.step)), 32)
+	bits := 1
+	for 1<<bits < b.lattice.values {
+		bits++
+	}
+	bw.write(uint32(bits-1), 4)
+	bw.write(0, 1) // Not cumulative
+	for i := range b.lattice.values {
+		bw.write(uint32(i), bits)
+	}
+}
+
+// writeEntry writes the codeword of an entry, first bit first
+func (b *vorbisBook) writeEntry(bw *vorbisBits, entry int) {
+	code, n := b.codes[entry], b.lengths[entry]
+	for i := n - 1; i >= 0; i-- {
+		bw.write(code>>i&1, 1)
+	}
+}
+
+// writeVector writes the entry of a vector book standing for values
+func (b *vorbisBook) writeVector(bw *vorbisBits, values []int) {
+	entry, scale := 0, 1
+	for _, v := range values {
+		entry += (v - b.lattice.min) / b.lattice.step * scale
+		scale *= b.lattice.values
+	}
+	b.writeEntry(bw, entry)
+}
+
+// vorbisFloat packs an integer-valued float in the codebook format
+func vorbisFloat(v float64) uint32 {
+	var sign uint32
+	if v < 0 {
+		sign, v = 0x80000000, -v
+	}
+	// Mantissa v at exponent 0, biased by 788
+	return sign | 788<<21 | uint32(v)
+}
+
+// vorbisCodewords assigns codewords to lengths the way decoders do: each
+// entry takes the lowest free codeword of its length
+func vorbisCodewords(lengths []int) []uint32 {
+	var marker [33]uint32
+	codes := make([]uint32, len(lengths))
+	for i, l := range lengths {
+		entry := marker[l]
+		codes[i] = entry
+		for j := l; j > 0; j-- {
+			if marker[j]&1 != 0 {
+				if j == 1 {
+					marker[1]++
+				} else {
+					marker[j] = marker[j-1] << 1
+				}
+				break
+			}
+			marker[j]++
+		}
+		for j := l + 1; j < 33; j++ {
+			if marker[j]>>1 != entry {
+				break
+			}
+			entry = marker[j]
+			marker[j] = marker[j-1] << 1
+		}
+	}
+	return codes
+}
+
+// huffmanLengths returns Huffman code lengths for the weights
+func huffmanLengths(weights []float64) []int {
+	type node struct {
+		weight float64
+		leaves []int
+	}
+	nodes := make([]node, len(weights))
+	for i, w := range weights {
+		nodes[i] = node{math.Max(w, 1e-6), []int{i}}
+	}
+	lengths := make([]int, len(weights))
+	for len(nodes) > 1 {
+		sort.SliceStable(nodes, func(a, b int) bool { return nodes[a].weight < nodes[b].weight })
+		a, b := nodes[0], nodes[1]
+		for _, l := range a.leaves {
+			lengths[l]++
+		}
+		for _, l := range b.leaves {
+			lengths[l]++
+		}
+		merged := node{a.weight + b.weight, append(append([]int(nil), a.leaves...), b.leaves...)}
+		nodes = append(nodes[2:], merged)
+	}
+	return lengths
+}
+
+// vorbisBits packs bit fields least significant bit first
+type vorbisBits struct {
+	buf []byte
+	acc uint64
+	n   uint // Bits pending in acc (< 8 between writes)
+}
+
+func (b *vorbisBits) reset() {
+	b.buf = b.buf[:0]
+	b.acc = 0
+	b.n = 0
+}
+
+// write appends the low bits of v
+func (b *vorbisBits) write(v uint32, bits int) {
+	b.acc |= uint64(v) & (1<<bits - 1) << b.n
+	b.n += uint(bits)
+	for b.n >= 8 {
+		b.buf = append(b.buf, byte(b.acc))
+		b.acc >>= 8
+		b.n -= 8
+	}
+}
+
+// bytes returns the packed bits, padded to a whole byte
+func (b *vorbisBits) bytes() []byte {
+	out := append([]byte(nil), b.buf...)
+	if b.n > 0 {
+		out = append(out, byte(b.acc))
+	}
+	return out
+}
@@ -0,0 +1,183 @@
+package audio
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// WAVWriter writes audio to WAV format (16/24-bit PCM or 32-bit float)
+type WAVWriter struct {
+	writer      io.Writer
+	sampleRate  int
+	channels    int
+	bits        int
+	float       bool
+	tags        Tags
+	dataWritten int
+
+	// Header bookkeeping for patching sizes on Close
+	headerDone bool
+	start      int64 // Offset of the RIFF header, -1 if not seekable
+	factOffset int   // Offset of the fact sample count (float only)
+	dataOffset int   // Offset of the data chunk size
+	headerLen  int
+	buf        []byte
+}
+
+// NewWAVWriter creates a 16-bit PCM WAV writer
+func NewWAVWriter(w io.Writer, sampleRate, channels int) *WAVWriter {
+	return newWAVEncoder(w, sampleRate, channels, 16, false, Tags{})
+}
+
+func newWAVEncoder(w io.Writer, sampleRate, channels, bits int, float bool, tags Tags) *WAVWriter {
+	return &WAVWriter{
+		writer:     w,
+		sampleRate: sampleRate,
+		channels:   channels,
+		bits:       bits,
+		float:      float,
+		tags:       tags,
+	}
+}
+
+// WriteHeader writes the WAV header. A negative dataSize marks the size
+// as unknown; Close patches it when the writer can seek.
+func (w *WAVWriter) WriteHeader(dataSize int) error {
+	w.start = tell(w.writer)
+	blockAlign := w.channels * w.bits / 8
+	size := uint32(0xFFFFFFFF)
+	if dataSize >= 0 {
+		size = uint32(dataSize)
+	}
+
+	le := binary.LittleEndian
+	h := []byte("RIFF")
+	h = le.AppendUint32(h, 0xFFFFFFFF) // Patched below
+	h = append(h, "WAVE"...)
+
+	// fmt chunk
+	format := uint16(1) // PCM
+	if w.float {
+		format = 3 // IEEE float
+	}
+	h = append(h, "fmt "...)
+	h = le.AppendUint32(h, 16)
+	h = le.AppendUint16(h, format)
+	h = le.AppendUint16(h, uint16(w.channels))
+	h = le.AppendUint32(h, uint32(w.sampleRate))
+	h = le.AppendUint32(h, uint32(w.sampleRate*blockAlign)) // Byte rate
+	h = le.AppendUint16(h, uint16(blockAlign))
+	h = le.AppendUint16(h, uint16(w.bits))
+
+	// Non-PCM formats carry a sample frame count
+	if w.float {
+		h = append(h, "fact"...)
+		h = le.AppendUint32(h, 4)
+		w.factOffset = len(h)
+		frames := uint32(0xFFFFFFFF)
+		if dataSize >= 0 {
+			frames = uint32(dataSize / blockAlign)
+		}
+		h = le.AppendUint32(h, frames)
+	}
+
+	h = appendWAVInfo(h, w.tags)
+
+	// data chunk header
+	h = append(h, "data"...)
+	w.dataOffset = len(h)
+	h = le.AppendUint32(h, size)
+	if dataSize >= 0 {
+		le.PutUint32(h[4:], uint32(len(h)-8+dataSize))
+	}
+
+	w.headerLen = len(h)
+	w.headerDone = true
+	_, err := w.writer.Write(h)
+	return err
+}
+
+// appendWAVInfo appends a LIST/INFO chunk with the song tags
+func appendWAVInfo(h []byte, tags Tags) []byte {
+	var info []byte
+	for _, tag := range []struct{ id, value string }{
+		{"INAM", tags.Title},
+		{"IART", tags.Author},
+	} {
+		if tag.value == "" {
+			continue
+		}
+		value := append([]byte(tag.value), 0)
+		info = append(info, tag.id...)
+		info = binary.LittleEndian.AppendUint32(info, uint32(len(value)))
+		info = append(info, value...)
+		if len(value)%2 == 1 {
+			info = append(info, 0) // Chunks are word aligned
+		}
+	}
+	if len(info) == 0 {
+		return h
+	}
+	h = append(h, "LIST"...)
+	h = binary.LittleEndian.AppendUint32(h, uint32(len(info)+4))
+	h = append(h, "INFO"...)
+	return append(h, info...)
+}
+
+// WriteSamples writes interleaved float samples
+func (w *WAVWriter) WriteSamples(samples []float64) error {
+	if !w.headerDone {
+		if err := w.WriteHeader(-1); err != nil {
+			return err
+		}
+	}
+
+	w.buf = w.buf[:0]
+	for _, s := range samples {
+		switch {
+		case w.float:
+			w.buf = binary.LittleEndian.AppendUint32(w.buf, math.Float32bits(float32(s)))
+		case w.bits == 24:
+			v := quantize(s, 24)
+			w.buf = append(w.buf, byte(v), byte(v>>8), byte(v>>16))
+		default:
+			w.buf = binary.LittleEndian.AppendUint16(w.buf, uint16(quantize(s, 16)))
+		}
+	}
+	n, err := w.writer.Write(w.buf)
+	w.dataWritten += n
+	return err
+}
+
+// Close finishes the file, patching the RIFF, fact and data sizes when
+// the writer can seek
+func (w *WAVWriter) Close() error {
+	if !w.headerDone {
+		if err := w.WriteHeader(0); err != nil {
+			return err
+		}
+	}
+	// Chunks are word aligned
+	pad := w.dataWritten % 2
+	if pad == 1 {
+		if _, err := w.writer.Write([]byte{0}); err != nil {
+			return err
+		}
+	}
+	if w.start < 0 {
+		return nil
+	}
+
+	le := binary.LittleEndian
+	if err := patch(w.writer, w.start+4, le.AppendUint32(nil, uint32(w.headerLen-8+w.dataWritten+pad))); err != nil {
+		return err
+	}
+	if w.float {
+		frames := w.dataWritten / (w.channels * 4)
+		if err := patch(w.writer, w.start+int64(w.factOffset), le.AppendUint32(nil, uint32(frames))); err != nil {
+			return err
+		}
+	}
+	return patch(w.writer, w.start+int64(w.dataOffset), le.AppendUint32(nil, uint32(w.dataWritten)))
+}
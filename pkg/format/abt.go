@@ -29,21 +29,98 @@ func Save(w io.Writer, song *tracker.Song) error {
 	fmt.Fprintf(w, "speed = %d\n", song.Speed)
 	fmt.Fprintf(w, "rate = %d\n", song.SampleRate)
 	fmt.Fprintf(w, "channels = %d\n", song.Channels)
+	fmt.Fprintf(w, "beat = %d\n", song.RowsPerBeat)
+	fmt.Fprintf(w, "measure = %d\n", song.RowsPerMeasure)
+	fmt.Fprintf(w, "gain = %d\n", song.Master.Gain)
+	limiter := 0
+	if song.Master.Limiter {
+		limiter = 1
+	}
+	fmt.Fprintf(w, "limiter = %d\n", limiter)
 	fmt.Fprintln(w)
 
 	// Instruments section
 	fmt.Fprintln(w, "[instruments]")
-	fmt.Fprintln(w, "# ID | Name     | Gen | Atk Dec Sus Rel | Orn | Vol")
+	fmt.Fprintln(w, "# ID | Name     | Gen | Atk Dec Sus Rel | Orn | Vol | Det Duty | Flags (L=loop envelope)")
 	for i, inst := range song.Instruments {
 		gen := generatorName(inst.Generator)
-		fmt.Fprintf(w, "%02d   | %-8s | %s | %3d %3d %3d %3d | %3d | %3d\n",
+		flags := "-"
+		if inst.Envelope.Loop {
+			flags = "L"
+		}
+		fmt.Fprintf(w, "%02d   | %-8s | %s | %3d %3d %3d %3d | %3d | %3d | %3d %4d | %s\n",
 			i+1, inst.Name, gen,
 			inst.Envelope.Attack, inst.Envelope.Decay,
 			inst.Envelope.Sustain, inst.Envelope.Release,
-			inst.Ornament, inst.Volume)
+			inst.Ornament, inst.Volume,
+			inst.Detune, inst.Duty, flags)
 	}
 	fmt.Fprintln(w)
 
+	// Formulas section (bytebeat instruments; the formula is the rest of
+	// the line, so it may contain "|")
+	hasFormulas := false
+	for _, inst := range song.Instruments {
+		if inst.Formula != "" {
+			hasFormulas = true
+			break
+		}
+	}
+	if hasFormulas {
+		fmt.Fprintln(w, "[formulas]")
+		fmt.Fprintln(w, "# Inst | Formula")
+		for i, inst := range song.Instruments {
+			if inst.Formula != "" {
+				fmt.Fprintf(w, "%02d     | %s\n", i+1, inst.Formula)
+			}
+		}
+		fmt.Fprintln(w)
+	}
+
+	// FM operators section (only for instruments that define operators)
+	hasFM := false
+	for _, inst := range song.Instruments {
+		if len(inst.FM.Operators) > 0 {
+			hasFM = true
+			break
+		}
+	}
+	if hasFM {
+		fmt.Fprintln(w, "[fm]")
+		fmt.Fprintln(w, "# Inst | Alg | Op | Mul Lvl Fb | Atk Dec Sus Rel")
+		for i, inst := range song.Instruments {
+			for j, op := range inst.FM.Operators {
+				fmt.Fprintf(w, "%02d     | %3d | %2d | %3d %3d %2d | %3d %3d %3d %3d\n",
+					i+1, inst.FM.Algorithm, j+1,
+					op.Ratio, op.Level, op.Feedback,
+					op.Envelope.Attack, op.Envelope.Decay,
+					op.Envelope.Sustain, op.Envelope.Release)
+			}
+		}
+		fmt.Fprintln(w)
+	}
+
+	// Wavetables section (one line per wave, steps as hex digits)
+	hasWaves := false
+	for _, inst := range song.Instruments {
+		if len(inst.Wavetable.Waves) > 0 {
+			hasWaves = true
+			break
+		}
+	}
+	if hasWaves {
+		fmt.Fprintln(w, "[wavetables]")
+		fmt.Fprintln(w, "# Inst | Bits Morph | Steps (hex)")
+		for i, inst := range song.Instruments {
+			for _, wave := range inst.Wavetable.Waves {
+				fmt.Fprintf(w, "%02d     | %4d %5d | %s\n",
+					i+1, inst.Wavetable.Depth, inst.Wavetable.Morph,
+					formatWave(wave, inst.Wavetable.Depth))
+			}
+		}
+		fmt.Fprintln(w)
+	}
+
 	// Ornaments section
 	fmt.Fprintln(w, "[ornaments]")
 	fmt.Fprintln(w, "# ID | Name     | Loop | Values")
@@ -59,19 +136,67 @@ func Save(w io.Writer, song *tracker.Song) error {
 
 	// Channels section
 	fmt.Fprintln(w, "[channels]")
-	fmt.Fprintln(w, "# CH | Name   | Gen | Vol | Pan | Echo (src, delay, vol)")
+	fmt.Fprintln(w, "# CH | Name   | Gen | Vol | Pan | Echo (src, delay, vol) | Send (dly, rev) | Flags (M=mute, S=solo)")
 	for i, ch := range song.ChanConfig {
 		gen := generatorName(ch.Generator)
 		echoSrc := "-"
 		if ch.EchoSource >= 0 {
 			echoSrc = fmt.Sprintf("%d", ch.EchoSource+1)
 		}
-		fmt.Fprintf(w, "%d    | %-6s | %s | %3d | %3d | %s, %d, %d\n",
+		flags := ""
+		if ch.Muted {
+			flags += "M"
+		}
+		if ch.Solo {
+			flags += "S"
+		}
+		if flags == "" {
+			flags = "-"
+		}
+		fmt.Fprintf(w, "%d    | %-6s | %s | %3d | %3d | %s, %d, %d | %d, %d | %s\n",
 			i+1, ch.Name, gen, ch.Volume, ch.Pan,
-			echoSrc, ch.EchoDelay, ch.EchoVolume)
+			echoSrc, ch.EchoDelay, ch.EchoVolume,
+			ch.DelaySend, ch.ReverbSend, flags)
 	}
 	fmt.Fprintln(w)
 
+	// Effect bus section
+	pingPong := 0
+	if song.Bus.PingPong {
+		pingPong = 1
+	}
+	fmt.Fprintln(w, "[bus]")
+	fmt.Fprintf(w, "delay_rows = %d\n", song.Bus.DelayRows)
+	fmt.Fprintf(w, "delay_feedback = %d\n", song.Bus.DelayFeedback)
+	fmt.Fprintf(w, "delay_damping = %d\n", song.Bus.DelayDamping)
+	fmt.Fprintf(w, "delay_level = %d\n", song.Bus.DelayLevel)
+	fmt.Fprintf(w, "delay_pingpong = %d\n", pingPong)
+	fmt.Fprintf(w, "reverb_size = %d\n", song.Bus.ReverbSize)
+	fmt.Fprintf(w, "reverb_damping = %d\n", song.Bus.ReverbDamping)
+	fmt.Fprintf(w, "reverb_level = %d\n", song.Bus.ReverbLevel)
+	fmt.Fprintln(w)
+
+	// Filters section (instrument and channel filters that are enabled)
+	var filterLines []string
+	for i, inst := range song.Instruments {
+		if inst.Filter.Type != tracker.FilterOff {
+			filterLines = append(filterLines, formatFilter(fmt.Sprintf("I%02d", i+1), inst.Filter))
+		}
+	}
+	for i, ch := range song.ChanConfig {
+		if ch.Filter.Type != tracker.FilterOff {
+			filterLines = append(filterLines, formatFilter(fmt.Sprintf("C%02d", i+1), ch.Filter))
+		}
+	}
+	if len(filterLines) > 0 {
+		fmt.Fprintln(w, "[filters]")
+		fmt.Fprintln(w, "# For | Type | Cut Res | Env | Atk Dec Sus Rel")
+		for _, line := range filterLines {
+			fmt.Fprintln(w, line)
+		}
+		fmt.Fprintln(w)
+	}
+
 	// Order section
 	fmt.Fprintln(w, "[order]")
 	orderStrs := make([]string, len(song.Order))
@@ -126,12 +251,58 @@ func formatCell(note tracker.Note) string {
 
 	fxStr := "..."
 	if note.Effect.Type != 0 || note.Effect.Param != 0 {
-		fxStr = fmt.Sprintf("%X%02X", note.Effect.Type, note.Effect.Param)
+		fxStr = fmt.Sprintf("%c%02X", tracker.EffectChar(note.Effect.Type), note.Effect.Param)
 	}
 
 	return fmt.Sprintf("%s %s %s %s", noteStr, instStr, volStr, fxStr)
 }
 
+func formatWave(wave []uint8, depth uint8) string {
+	// 4-bit: one hex digit per step, 8-bit: two
+	var b strings.Builder
+	for _, v := range wave {
+		if depth == 8 {
+			fmt.Fprintf(&b, "%02X", v)
+		} else {
+			fmt.Fprintf(&b, "%X", v&0x0F)
+		}
+	}
+	return b.String()
+}
+
+func formatFilter(target string, f tracker.Filter) string {
+	// Format: "I03 | lp   |  80  40 | -32 |   0  20  48  30"
+	return fmt.Sprintf("%s | %-4s | %3d %3d | %3d | %3d %3d %3d %3d",
+		target, filterTypeName(f.Type), f.Cutoff, f.Resonance, f.EnvAmount,
+		f.Envelope.Attack, f.Envelope.Decay, f.Envelope.Sustain, f.Envelope.Release)
+}
+
+func filterTypeName(typ tracker.FilterType) string {
+	switch typ {
+	case tracker.FilterLowPass:
+		return "lp"
+	case tracker.FilterHighPass:
+		return "hp"
+	case tracker.FilterBandPass:
+		return "bp"
+	default:
+		return "off"
+	}
+}
+
+func parseFilterType(name string) tracker.FilterType {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "lp", "lowpass":
+		return tracker.FilterLowPass
+	case "hp", "highpass":
+		return tracker.FilterHighPass
+	case "bp", "bandpass":
+		return tracker.FilterBandPass
+	default:
+		return tracker.FilterOff
+	}
+}
+
 func generatorName(gen tracker.Generator) string {
 	switch gen {
 	case tracker.GenTriangle:
@@ -148,6 +319,10 @@ func generatorName(gen tracker.Generator) string {
 		return "sam"
 	case tracker.GenBytebeat:
 		return "bbt"
+	case tracker.GenFM:
+		return "fmo"
+	case tracker.GenWavetable:
+		return "wav"
 	default:
 		return "tri"
 	}
@@ -169,6 +344,10 @@ func parseGenerator(name string) tracker.Generator {
 		return tracker.GenSample
 	case "bbt", "bytebeat":
 		return tracker.GenBytebeat
+	case "fmo", "fm":
+		return tracker.GenFM
+	case "wav", "wavetable":
+		return tracker.GenWavetable
 	default:
 		return tracker.GenTriangle
 	}
@@ -177,10 +356,14 @@ func parseGenerator(name string) tracker.Generator {
 // Load reads a song from a reader in .abt format
 func Load(r io.Reader) (*tracker.Song, error) {
 	song := &tracker.Song{
-		Speed:      6,
-		Tempo:      125,
-		SampleRate: 44100,
-		Channels:   4,
+		Speed:          6,
+		Tempo:          125,
+		SampleRate:     44100,
+		Channels:       4,
+		RowsPerBeat:    4,
+		RowsPerMeasure: 16,
+		Bus:            tracker.DefaultBusConfig(),
+		Master:         tracker.DefaultMasterConfig(),
 	}
 
 	scanner := bufio.NewScanner(r)
@@ -222,6 +405,16 @@ func Load(r io.Reader) (*tracker.Song, error) {
 			if inst := parseInstrumentLine(line); inst != nil {
 				song.Instruments = append(song.Instruments, *inst)
 			}
+		case "formulas":
+			parseFormulaLine(song, line)
+		case "fm":
+			parseFMLine(song, line)
+		case "wavetables":
+			parseWaveLine(song, line)
+		case "filters":
+			parseFilterLine(song, line)
+		case "bus":
+			parseBusLine(&song.Bus, line)
 		case "ornaments":
 			if orn := parseOrnamentLine(line); orn != nil {
 				song.Ornaments = append(song.Ornaments, *orn)
@@ -281,11 +474,56 @@ func parseSongLine(song *tracker.Song, line string) {
 		if v, err := strconv.Atoi(val); err == nil {
 			song.Channels = v
 		}
+	case "gain":
+		if v, err := strconv.Atoi(val); err == nil {
+			song.Master.Gain = uint8(v)
+		}
+	case "beat":
+		if v, err := strconv.Atoi(val); err == nil {
+			song.RowsPerBeat = uint8(v)
+		}
+	case "measure":
+		if v, err := strconv.Atoi(val); err == nil {
+			song.RowsPerMeasure = uint8(v)
+		}
+	case "limiter":
+		song.Master.Limiter = val == "1" || val == "on" || val == "true"
+	}
+}
+
+func parseBusLine(bus *tracker.BusConfig, line string) {
+	parts := strings.SplitN(line, "=", 2)
+	if len(parts) != 2 {
+		return
+	}
+	key := strings.TrimSpace(parts[0])
+	v, err := parseUint8(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return
+	}
+
+	switch key {
+	case "delay_rows":
+		bus.DelayRows = v
+	case "delay_feedback":
+		bus.DelayFeedback = v
+	case "delay_damping":
+		bus.DelayDamping = v
+	case "delay_level":
+		bus.DelayLevel = v
+	case "delay_pingpong":
+		bus.PingPong = v != 0
+	case "reverb_size":
+		bus.ReverbSize = v
+	case "reverb_damping":
+		bus.ReverbDamping = v
+	case "reverb_level":
+		bus.ReverbLevel = v
 	}
 }
 
 func parseInstrumentLine(line string) *tracker.Instrument {
-	// Format: "01   | Lead     | tri | 0  20  48  30 | 1 | 64"
+	// Format: "01   | Lead     | tri | 0  20  48  30 | 1 | 64 | -8 128 | L"
 	parts := strings.Split(line, "|")
 	if len(parts) < 6 {
 		return nil
@@ -307,9 +545,151 @@ func parseInstrumentLine(line string) *tracker.Instrument {
 	inst.Ornament, _ = parseUint8(strings.TrimSpace(parts[4]))
 	inst.Volume, _ = parseUint8(strings.TrimSpace(parts[5]))
 
+	// Parse detune and duty cycle (optional)
+	if len(parts) >= 7 {
+		toneParts := strings.Fields(parts[6])
+		if len(toneParts) >= 2 {
+			if v, err := strconv.Atoi(toneParts[0]); err == nil {
+				inst.Detune = int8(v)
+			}
+			inst.Duty, _ = parseUint8(toneParts[1])
+		}
+	}
+
+	// Parse envelope loop flag (optional)
+	if len(parts) >= 8 {
+		inst.Envelope.Loop = strings.Contains(strings.ToUpper(parts[7]), "L")
+	}
+
 	return inst
 }
 
+func parseFormulaLine(song *tracker.Song, line string) {
+	// Format: "12     | t*(t>>11&t>>8&123)"
+	parts := strings.SplitN(line, "|", 2)
+	if len(parts) < 2 {
+		return
+	}
+
+	instNum, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || instNum < 1 || instNum > len(song.Instruments) {
+		return
+	}
+	song.Instruments[instNum-1].Formula = strings.TrimSpace(parts[1])
+}
+
+func parseFMLine(song *tracker.Song, line string) {
+	// Format: "03     |   0 |  1 |   1  64  0 |   0  20  48  30"
+	parts := strings.Split(line, "|")
+	if len(parts) < 5 {
+		return
+	}
+
+	instNum, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || instNum < 1 || instNum > len(song.Instruments) {
+		return
+	}
+	inst := &song.Instruments[instNum-1]
+	inst.FM.Algorithm, _ = parseUint8(strings.TrimSpace(parts[1]))
+
+	op := tracker.FMOperator{}
+	opParts := strings.Fields(parts[3])
+	if len(opParts) >= 3 {
+		op.Ratio, _ = parseUint8(opParts[0])
+		op.Level, _ = parseUint8(opParts[1])
+		op.Feedback, _ = parseUint8(opParts[2])
+	}
+	envParts := strings.Fields(parts[4])
+	if len(envParts) >= 4 {
+		op.Envelope.Attack, _ = parseUint8(envParts[0])
+		op.Envelope.Decay, _ = parseUint8(envParts[1])
+		op.Envelope.Sustain, _ = parseUint8(envParts[2])
+		op.Envelope.Release, _ = parseUint8(envParts[3])
+	}
+	inst.FM.Operators = append(inst.FM.Operators, op)
+}
+
+func parseWaveLine(song *tracker.Song, line string) {
+	// Format: "05     |    4     0 | 0123456789ABCDEFFEDCBA9876543210"
+	parts := strings.Split(line, "|")
+	if len(parts) < 3 {
+		return
+	}
+
+	instNum, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || instNum < 1 || instNum > len(song.Instruments) {
+		return
+	}
+	wt := &song.Instruments[instNum-1].Wavetable
+
+	cfg := strings.Fields(parts[1])
+	if len(cfg) >= 2 {
+		wt.Depth, _ = parseUint8(cfg[0])
+		wt.Morph, _ = parseUint8(cfg[1])
+	}
+
+	digits := 1
+	if wt.Depth == 8 {
+		digits = 2
+	}
+	hex := strings.TrimSpace(parts[2])
+	var wave []uint8
+	for i := 0; i+digits <= len(hex); i += digits {
+		v, err := strconv.ParseUint(hex[i:i+digits], 16, 8)
+		if err != nil {
+			return
+		}
+		wave = append(wave, uint8(v))
+	}
+	if len(wave) > 0 {
+		wt.Waves = append(wt.Waves, wave)
+	}
+}
+
+func parseFilterLine(song *tracker.Song, line string) {
+	// Format: "I03 | lp   |  80  40 | -32 |   0  20  48  30" (I = instrument, C = channel)
+	parts := strings.Split(line, "|")
+	if len(parts) < 5 {
+		return
+	}
+
+	f := tracker.Filter{Type: parseFilterType(parts[1])}
+	cfg := strings.Fields(parts[2])
+	if len(cfg) >= 2 {
+		f.Cutoff, _ = parseUint8(cfg[0])
+		f.Resonance, _ = parseUint8(cfg[1])
+	}
+	if v, err := strconv.Atoi(strings.TrimSpace(parts[3])); err == nil {
+		f.EnvAmount = int8(v)
+	}
+	envParts := strings.Fields(parts[4])
+	if len(envParts) >= 4 {
+		f.Envelope.Attack, _ = parseUint8(envParts[0])
+		f.Envelope.Decay, _ = parseUint8(envParts[1])
+		f.Envelope.Sustain, _ = parseUint8(envParts[2])
+		f.Envelope.Release, _ = parseUint8(envParts[3])
+	}
+
+	target := strings.TrimSpace(parts[0])
+	if len(target) < 2 {
+		return
+	}
+	idx, err := strconv.Atoi(target[1:])
+	if err != nil || idx < 1 {
+		return
+	}
+	switch target[0] {
+	case 'I', 'i':
+		if idx <= len(song.Instruments) {
+			song.Instruments[idx-1].Filter = f
+		}
+	case 'C', 'c':
+		if idx <= len(song.ChanConfig) {
+			song.ChanConfig[idx-1].Filter = f
+		}
+	}
+}
+
 func parseOrnamentLine(line string) *tracker.Ornament {
 	// Format: "01   | Arp Maj  | 0 | 0, 4, 7"
 	parts := strings.Split(line, "|")
@@ -371,6 +751,22 @@ func parseChannelLine(line string) *tracker.ChannelConfig {
 		}
 	}
 
+	// Parse effect bus sends (optional)
+	if len(parts) >= 7 {
+		sendParts := strings.Split(parts[6], ",")
+		if len(sendParts) >= 2 {
+			ch.DelaySend, _ = parseUint8(strings.TrimSpace(sendParts[0]))
+			ch.ReverbSend, _ = parseUint8(strings.TrimSpace(sendParts[1]))
+		}
+	}
+
+	// Parse mute/solo flags (optional)
+	if len(parts) >= 8 {
+		flags := strings.ToUpper(parts[7])
+		ch.Muted = strings.Contains(flags, "M")
+		ch.Solo = strings.Contains(flags, "S")
+	}
+
 	return ch
 }
 
@@ -441,8 +837,8 @@ func parseCell(cell string) *tracker.Note {
 
 	// Effect
 	if parts[3] != "..." && len(parts[3]) >= 3 {
-		if typ, err := strconv.ParseInt(parts[3][:1], 16, 32); err == nil {
-			note.Effect.Type = uint8(typ)
+		if typ, ok := tracker.ParseEffectChar(parts[3][0]); ok {
+			note.Effect.Type = typ
 		}
 		if param, err := strconv.ParseInt(parts[3][1:], 16, 32); err == nil {
 			note.Effect.Param = uint8(param)
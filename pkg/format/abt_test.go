@@ -0,0 +1,136 @@
+package format
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/anthropics/abytetracker/pkg/tracker"
+)
+
+func TestInstrumentRoundTrip(t *testing.T) {
+	song := tracker.NewSong(2)
+	song.Instruments = []tracker.Instrument{tracker.DefaultInstrument(), tracker.DefaultInstrument(), tracker.DefaultInstrument()}
+	song.Instruments[0].Detune = -12
+	song.Instruments[0].Duty = 200
+	song.Instruments[0].Envelope.Loop = true
+	song.Instruments[1].Generator = tracker.GenBytebeat
+	song.Instruments[1].Formula = "t*(t>>11|t>>8) & 0x7F | t>>4"
+	song.Instruments[2].Detune = 63
+
+	var buf bytes.Buffer
+	if err := Save(&buf, song); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Instruments) != len(song.Instruments) {
+		t.Fatalf("loaded %d instruments, saved %d", len(got.Instruments), len(song.Instruments))
+	}
+	for i, want := range song.Instruments {
+		inst := got.Instruments[i]
+		if inst.Detune != want.Detune || inst.Duty != want.Duty ||
+			inst.Envelope != want.Envelope || inst.Formula != want.Formula || inst.Generator != want.Generator {
+			t.Errorf("instrument %d: loaded %+v, saved %+v", i+1, inst, want)
+		}
+	}
+}
+
+func TestLoadOldInstrumentRows(t *testing.T) {
+	// Files from before the detune, duty and flags columns still load
+	song, err := Load(strings.NewReader("[instruments]\n01   | Lead     | squ |   0  20  48  30 |   0 |  64\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	inst := song.Instruments[0]
+	if inst.Generator != tracker.GenSquare || inst.Volume != 64 || inst.Detune != 0 || inst.Duty != 0 || inst.Envelope.Loop {
+		t.Errorf("loaded %+v", inst)
+	}
+}
+
+// roundTrip saves and reloads a song
+func roundTrip(t *testing.T, song *tracker.Song) *tracker.Song {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := Save(&buf, song); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestSynthRoundTrip(t *testing.T) {
+	song := tracker.NewSong(2)
+	song.Instruments = []tracker.Instrument{tracker.DefaultInstrument(), tracker.DefaultInstrument(), tracker.DefaultInstrument()}
+	fm := &song.Instruments[0]
+	fm.Generator = tracker.GenFM
+	fm.FM = tracker.FMPatch{Algorithm: 2, Operators: []tracker.FMOperator{
+		{Ratio: 1, Level: 64, Feedback: 5, Envelope: tracker.Envelope{Attack: 1, Decay: 20, Sustain: 40, Release: 12}},
+		{Ratio: 0, Level: 30, Envelope: tracker.Envelope{Attack: 0, Decay: 255, Sustain: 0, Release: 3}},
+		{Ratio: 15, Level: 7, Feedback: 7},
+	}}
+	wt := &song.Instruments[1]
+	wt.Generator = tracker.GenWavetable
+	wt.Wavetable = tracker.NewWavetable(64, 8)
+	wt.Wavetable.Morph = 9
+	wt.Wavetable.Waves = append(wt.Wavetable.Waves, append([]uint8(nil), wt.Wavetable.Waves[0]...))
+	for i := range wt.Wavetable.Waves[1] {
+		wt.Wavetable.Waves[1][i] = uint8(i * 4)
+	}
+	small := &song.Instruments[2]
+	small.Wavetable = tracker.NewWavetable(32, 4)
+	small.Wavetable.Waves[0][3] = 0xF
+	small.Filter = tracker.Filter{Type: tracker.FilterBandPass, Cutoff: 200, Resonance: 99, EnvAmount: -40,
+		Envelope: tracker.Envelope{Attack: 2, Decay: 30, Sustain: 10, Release: 50}}
+	song.ChanConfig[1].Filter = tracker.Filter{Type: tracker.FilterHighPass, Cutoff: 5, Resonance: 255}
+
+	got := roundTrip(t, song)
+	for i, want := range song.Instruments {
+		inst := got.Instruments[i]
+		if !reflect.DeepEqual(inst.FM, want.FM) {
+			t.Errorf("instrument %d FM: loaded %+v, saved %+v", i+1, inst.FM, want.FM)
+		}
+		if !reflect.DeepEqual(inst.Wavetable, want.Wavetable) {
+			t.Errorf("instrument %d wavetable: loaded %+v, saved %+v", i+1, inst.Wavetable, want.Wavetable)
+		}
+		if inst.Filter != want.Filter {
+			t.Errorf("instrument %d filter: loaded %+v, saved %+v", i+1, inst.Filter, want.Filter)
+		}
+	}
+	for i, want := range song.ChanConfig {
+		if f := got.ChanConfig[i].Filter; f != want.Filter {
+			t.Errorf("channel %d filter: loaded %+v, saved %+v", i+1, f, want.Filter)
+		}
+	}
+}
+
+func TestMixerRoundTrip(t *testing.T) {
+	song := tracker.NewSong(4)
+	song.Bus = tracker.BusConfig{DelayRows: 5, DelayFeedback: 60, DelayDamping: 1, DelayLevel: 33,
+		ReverbSize: 64, ReverbDamping: 0, ReverbLevel: 17}
+	chans := song.ChanConfig
+	chans[0].Muted = true
+	chans[1].Solo = true
+	chans[2].Muted, chans[2].Solo = true, true
+	chans[0].DelaySend, chans[0].ReverbSend = 64, 0
+	chans[3].DelaySend, chans[3].ReverbSend = 12, 48
+	chans[3].EchoSource, chans[3].EchoDelay, chans[3].EchoVolume = 1, 3, -20
+	chans[2].Pan, chans[2].Volume = -64, 40
+	chans[1].Name = "Bass"
+
+	got := roundTrip(t, song)
+	if got.Bus != song.Bus {
+		t.Errorf("bus: loaded %+v, saved %+v", got.Bus, song.Bus)
+	}
+	for i, want := range chans {
+		if ch := got.ChanConfig[i]; !reflect.DeepEqual(ch, want) {
+			t.Errorf("channel %d: loaded %+v, saved %+v", i+1, ch, want)
+		}
+	}
+}
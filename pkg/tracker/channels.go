@@ -0,0 +1,159 @@
+package tracker
+
+import "fmt"
+
+// MaxChannels is the most channels a song can have (Exy addresses
+// echo sources with one hex digit)
+const MaxChannels = 16
+
+// DefaultChannelConfig returns the settings for channel i of a new song
+func DefaultChannelConfig(i int) ChannelConfig {
+	gens := []Generator{GenTriangle, GenSawtooth, GenSquare, GenSquare, GenNoise, GenNoise}
+	names := []string{"Lead", "Bass", "Chord1", "Chord2", "Perc1", "Perc2"}
+	cfg := ChannelConfig{
+		Name:       fmt.Sprintf("CH%d", i+1),
+		Generator:  GenTriangle,
+		Volume:     64,
+		EchoSource: -1,
+	}
+	if i >= 0 && i < len(gens) {
+		cfg.Generator = gens[i]
+		cfg.Name = names[i]
+	}
+	return cfg
+}
+
+// InsertChannel inserts a channel at index i with an empty column in
+// every pattern, renumbering echo sources that refer to the channels
+// after it. It returns false if the song already has MaxChannels.
+func (s *Song) InsertChannel(i int, cfg ChannelConfig) bool {
+	if s.Channels >= MaxChannels {
+		return false
+	}
+	if i < 0 || i > s.Channels {
+		i = s.Channels
+	}
+	s.ChanConfig = append(s.ChanConfig, ChannelConfig{})
+	copy(s.ChanConfig[i+1:], s.ChanConfig[i:])
+	s.ChanConfig[i] = cfg
+
+	for _, pat := range s.Patterns {
+		for r, row := range pat.Notes {
+			row = append(row, Note{})
+			copy(row[i+1:], row[i:])
+			row[i] = Note{Pitch: -1, Volume: -1}
+			pat.Notes[r] = row
+		}
+		pat.Channels++
+	}
+	s.Channels++
+
+	s.remapChannels(func(n int) int {
+		if n >= i {
+			return n + 1
+		}
+		return n
+	})
+	return true
+}
+
+// DeleteChannel removes channel i and its pattern column. Channels that
+// echoed it lose their echo source, Exy effects naming it are cleared and
+// references to later channels are renumbered.
+func (s *Song) DeleteChannel(i int) {
+	if i < 0 || i >= s.Channels {
+		return
+	}
+	if i < len(s.ChanConfig) {
+		s.ChanConfig = append(s.ChanConfig[:i], s.ChanConfig[i+1:]...)
+	}
+	for _, pat := range s.Patterns {
+		for r, row := range pat.Notes {
+			if i < len(row) {
+				pat.Notes[r] = append(row[:i], row[i+1:]...)
+			}
+		}
+		if i < pat.Channels {
+			pat.Channels--
+		}
+	}
+	s.Channels--
+
+	s.remapChannels(func(n int) int {
+		switch {
+		case n == i:
+			return -1
+		case n > i:
+			return n - 1
+		}
+		return n
+	})
+}
+
+// MoveChannel moves channel from to index to, together with its pattern
+// column, renumbering echo sources to match
+func (s *Song) MoveChannel(from, to int) {
+	if from < 0 || from >= s.Channels || to < 0 || to >= s.Channels || from == to {
+		return
+	}
+	move := func(n int) int {
+		switch {
+		case n == from:
+			return to
+		case from < to && n > from && n <= to:
+			return n - 1
+		case to < from && n >= to && n < from:
+			return n + 1
+		}
+		return n
+	}
+
+	if len(s.ChanConfig) == s.Channels {
+		cfgs := make([]ChannelConfig, len(s.ChanConfig))
+		for n, cfg := range s.ChanConfig {
+			cfgs[move(n)] = cfg
+		}
+		s.ChanConfig = cfgs
+	}
+	for _, pat := range s.Patterns {
+		for r, row := range pat.Notes {
+			if len(row) != s.Channels {
+				continue
+			}
+			moved := make([]Note, len(row))
+			for n, note := range row {
+				moved[move(n)] = note
+			}
+			pat.Notes[r] = moved
+		}
+	}
+
+	s.remapChannels(move)
+}
+
+// remapChannels rewrites every channel reference (channel echo sources
+// and the source of Exy effects) through f, which maps old indices to
+// new ones (-1 = channel removed)
+func (s *Song) remapChannels(f func(n int) int) {
+	for i := range s.ChanConfig {
+		if src := s.ChanConfig[i].EchoSource; src >= 0 {
+			s.ChanConfig[i].EchoSource = int8(f(int(src)))
+		}
+	}
+	for _, pat := range s.Patterns {
+		for _, row := range pat.Notes {
+			for ch := range row {
+				fx := &row[ch].Effect
+				if fx.Type != FxEcho || fx.Param == 0 {
+					continue
+				}
+				src := f(int(fx.Param >> 4))
+				if src < 0 {
+					*fx = Effect{}
+					continue
+				}
+				fx.Param = uint8(src)<<4 | fx.Param&0x0F
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package tracker
+
+import (
+	"strings"
+	"testing"
+)
+
+// chanSong returns a song with channels A-D whose first row holds each
+// channel's own marker note. B echoes A and D echoes C; on the second
+// row A has E35 (echo D), B E15 (echo B), C E00 and D E25 (echo C).
+func chanSong() *Song {
+	s := NewSong(4)
+	s.Patterns = append(s.Patterns, NewPattern(4, 4))
+	for ch, name := range []string{"A", "B", "C", "D"} {
+		s.ChanConfig[ch] = DefaultChannelConfig(ch)
+		s.ChanConfig[ch].Name = name
+		for _, pat := range s.Patterns {
+			pat.Notes[0][ch] = Note{Pitch: int8(ch), Volume: -1}
+		}
+	}
+	s.ChanConfig[1].EchoSource = 0
+	s.ChanConfig[3].EchoSource = 2
+	for ch, param := range []uint8{0x35, 0x15, 0x00, 0x25} {
+		s.Patterns[1].Notes[1][ch].Effect = Effect{Type: FxEcho, Param: param}
+	}
+	return s
+}
+
+// chanState describes each channel as its name, its echo source and its
+// Exy source on pattern 1 ("-" for none, "0" for E00), checking that the
+// marker notes moved with their channels
+func chanState(t *testing.T, s *Song) string {
+	t.Helper()
+	name := func(n int) string {
+		if n < 0 || n >= len(s.ChanConfig) {
+			return "?"
+		}
+		return s.ChanConfig[n].Name
+	}
+	if len(s.ChanConfig) != s.Channels {
+		t.Fatalf("%d channel configs for %d channels", len(s.ChanConfig), s.Channels)
+	}
+	var parts []string
+	for ch := 0; ch < s.Channels; ch++ {
+		for p, pat := range s.Patterns {
+			if pat.Channels != s.Channels || len(pat.Notes[0]) != s.Channels {
+				t.Fatalf("pattern %d has %d channels, %d columns; song has %d", p, pat.Channels, len(pat.Notes[0]), s.Channels)
+			}
+			if pitch := pat.Notes[0][ch].Pitch; pitch >= 0 && name(ch) != string(rune('A'+pitch)) {
+				t.Fatalf("channel %s holds the column of %c", name(ch), 'A'+pitch)
+			}
+		}
+		echo := "-"
+		if src := s.ChanConfig[ch].EchoSource; src >= 0 {
+			echo = name(int(src))
+		}
+		exy := "-"
+		if fx := s.Patterns[1].Notes[1][ch].Effect; fx.Type == FxEcho && fx.Param == 0 {
+			exy = "0"
+		} else if fx.Type == FxEcho {
+			if fx.Param&0x0F != 5 {
+				t.Fatalf("channel %s: E%02X lost its volume digit", name(ch), fx.Param)
+			}
+			exy = name(int(fx.Param >> 4))
+		}
+		parts = append(parts, name(ch)+echo+exy)
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestChannelRemap(t *testing.T) {
+	if got := chanState(t, chanSong()); got != "A-D BAB C-0 DCC" {
+		t.Fatalf("test song is %s", got)
+	}
+	newChan := ChannelConfig{Name: "N", EchoSource: -1}
+	tests := []struct {
+		name string
+		edit func(s *Song)
+		want string
+	}{
+		{"insert in the middle", func(s *Song) { s.InsertChannel(1, newChan) }, "A-D N-- BAB C-0 DCC"},
+		{"insert at the start", func(s *Song) { s.InsertChannel(0, newChan) }, "N-- A-D BAB C-0 DCC"},
+		{"insert past the end appends", func(s *Song) { s.InsertChannel(9, newChan) }, "A-D BAB C-0 DCC N--"},
+		{"delete clears echoes of it", func(s *Song) { s.DeleteChannel(2) }, "A-D BAB D--"},
+		{"delete the first", func(s *Song) { s.DeleteChannel(0) }, "B-B C-0 DCC"},
+		{"delete out of range", func(s *Song) { s.DeleteChannel(4) }, "A-D BAB C-0 DCC"},
+		{"move down", func(s *Song) { s.MoveChannel(0, 2) }, "BAB C-0 A-D DCC"},
+		{"move up", func(s *Song) { s.MoveChannel(3, 0) }, "DCC A-D BAB C-0"},
+		{"move to itself", func(s *Song) { s.MoveChannel(1, 1) }, "A-D BAB C-0 DCC"},
+	}
+	for _, tt := range tests {
+		s := chanSong()
+		tt.edit(s)
+		if got := chanState(t, s); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestInsertChannelLimit(t *testing.T) {
+	s := chanSong()
+	for s.Channels < MaxChannels {
+		if !s.InsertChannel(s.Channels, ChannelConfig{Name: "N", EchoSource: -1}) {
+			t.Fatalf("insert refused at %d channels", s.Channels)
+		}
+	}
+	if s.InsertChannel(0, ChannelConfig{Name: "N", EchoSource: -1}) {
+		t.Error("inserted past MaxChannels")
+	}
+	if got := chanState(t, s); !strings.HasPrefix(got, "A-D BAB C-0 DCC N--") {
+		t.Errorf("refused insert changed the song: %s", got)
+	}
+}
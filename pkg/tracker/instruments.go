@@ -0,0 +1,116 @@
+package tracker
+
+// MaxInstruments is the most instruments a song can hold
+// (pattern cells number them 1-255)
+const MaxInstruments = 255
+
+// Clone returns a deep copy of the instrument
+func (inst Instrument) Clone() Instrument {
+	inst.Sample = append([]int16(nil), inst.Sample...)
+	inst.FM.Operators = append([]FMOperator(nil), inst.FM.Operators...)
+	waves := make([][]uint8, len(inst.Wavetable.Waves))
+	for j, w := range inst.Wavetable.Waves {
+		waves[j] = append([]uint8(nil), w...)
+	}
+	inst.Wavetable.Waves = waves
+	return inst
+}
+
+// InsertInstrument inserts inst at index i, renumbering pattern
+// references to the instruments after it. It returns false if the song
+// already holds MaxInstruments, or a note names instrument MaxInstruments
+// and so could not be renumbered.
+func (s *Song) InsertInstrument(i int, inst Instrument) bool {
+	if len(s.Instruments) >= MaxInstruments || s.usesInstrument(MaxInstruments) {
+		return false
+	}
+	if i < 0 || i > len(s.Instruments) {
+		i = len(s.Instruments)
+	}
+	s.Instruments = append(s.Instruments, Instrument{})
+	copy(s.Instruments[i+1:], s.Instruments[i:])
+	s.Instruments[i] = inst
+
+	s.remapInstruments(func(n int) int {
+		if n >= i {
+			return n + 1
+		}
+		return n
+	})
+	return true
+}
+
+// DeleteInstrument removes instrument i. Notes that used it lose their
+// instrument number and references to later instruments are renumbered.
+func (s *Song) DeleteInstrument(i int) {
+	if i < 0 || i >= len(s.Instruments) {
+		return
+	}
+	s.Instruments = append(s.Instruments[:i], s.Instruments[i+1:]...)
+
+	s.remapInstruments(func(n int) int {
+		switch {
+		case n == i:
+			return -1
+		case n > i:
+			return n - 1
+		}
+		return n
+	})
+}
+
+// MoveInstrument moves instrument from to index to, shifting the ones in
+// between and renumbering pattern references to match
+func (s *Song) MoveInstrument(from, to int) {
+	if from < 0 || from >= len(s.Instruments) || to < 0 || to >= len(s.Instruments) || from == to {
+		return
+	}
+	inst := s.Instruments[from]
+	if from < to {
+		copy(s.Instruments[from:], s.Instruments[from+1:to+1])
+	} else {
+		copy(s.Instruments[to+1:], s.Instruments[to:from])
+	}
+	s.Instruments[to] = inst
+
+	s.remapInstruments(func(n int) int {
+		switch {
+		case n == from:
+			return to
+		case from < to && n > from && n <= to:
+			return n - 1
+		case to < from && n >= to && n < from:
+			return n + 1
+		}
+		return n
+	})
+}
+
+// usesInstrument reports whether a note names instrument number num (1-based)
+func (s *Song) usesInstrument(num uint8) bool {
+	for _, pat := range s.Patterns {
+		for _, row := range pat.Notes {
+			for _, note := range row {
+				if note.Instrument == num {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// remapInstruments rewrites the instrument number of every note through
+// f, which maps old 0-based indices to new ones (-1 = none)
+func (s *Song) remapInstruments(f func(n int) int) {
+	for _, pat := range s.Patterns {
+		for _, row := range pat.Notes {
+			for ch := range row {
+				if row[ch].Instrument == 0 {
+					continue
+				}
+				row[ch].Instrument = uint8(f(int(row[ch].Instrument)-1) + 1)
+			}
+		}
+	}
+}
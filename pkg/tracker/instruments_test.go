@@ -0,0 +1,113 @@
+package tracker
+
+import "testing"
+
+// instSong returns a song with instruments named A, B, C, D and one
+// pattern whose first row uses instruments 1-4 and no instrument
+func instSong() *Song {
+	s := NewSong(5)
+	s.Instruments = nil
+	for _, name := range []string{"A", "B", "C", "D"} {
+		inst := DefaultInstrument()
+		inst.Name = name
+		s.Instruments = append(s.Instruments, inst)
+	}
+	for ch := range 4 {
+		s.Patterns[0].Notes[0][ch] = Note{Pitch: 48, Instrument: uint8(ch + 1), Volume: -1}
+	}
+	return s
+}
+
+// instNames returns the instrument names in order
+func instNames(s *Song) string {
+	names := ""
+	for _, inst := range s.Instruments {
+		names += inst.Name
+	}
+	return names
+}
+
+// rowInstruments returns the instrument numbers on the first row
+func rowInstruments(s *Song) [5]uint8 {
+	var nums [5]uint8
+	for ch := range nums {
+		nums[ch] = s.Patterns[0].Notes[0][ch].Instrument
+	}
+	return nums
+}
+
+func TestInstrumentRemap(t *testing.T) {
+	tests := []struct {
+		name  string
+		edit  func(s *Song)
+		names string
+		nums  [5]uint8
+	}{
+		{"insert at the start", func(s *Song) { s.InsertInstrument(0, Instrument{Name: "N"}) }, "NABCD", [5]uint8{2, 3, 4, 5, 0}},
+		{"insert in the middle", func(s *Song) { s.InsertInstrument(2, Instrument{Name: "N"}) }, "ABNCD", [5]uint8{1, 2, 4, 5, 0}},
+		{"insert past the end appends", func(s *Song) { s.InsertInstrument(9, Instrument{Name: "N"}) }, "ABCDN", [5]uint8{1, 2, 3, 4, 0}},
+		{"delete clears its notes", func(s *Song) { s.DeleteInstrument(1) }, "ACD", [5]uint8{1, 0, 2, 3, 0}},
+		{"delete the last", func(s *Song) { s.DeleteInstrument(3) }, "ABC", [5]uint8{1, 2, 3, 0, 0}},
+		{"delete out of range", func(s *Song) { s.DeleteInstrument(4) }, "ABCD", [5]uint8{1, 2, 3, 4, 0}},
+		{"move down", func(s *Song) { s.MoveInstrument(0, 2) }, "BCAD", [5]uint8{3, 1, 2, 4, 0}},
+		{"move up", func(s *Song) { s.MoveInstrument(3, 1) }, "ADBC", [5]uint8{1, 3, 4, 2, 0}},
+		{"move to itself", func(s *Song) { s.MoveInstrument(2, 2) }, "ABCD", [5]uint8{1, 2, 3, 4, 0}},
+	}
+	for _, tt := range tests {
+		s := instSong()
+		tt.edit(s)
+		if got := instNames(s); got != tt.names {
+			t.Errorf("%s: instruments %s, want %s", tt.name, got, tt.names)
+		}
+		// Each note must still point at the instrument it used before
+		if got := rowInstruments(s); got != tt.nums {
+			t.Errorf("%s: notes use %v, want %v", tt.name, got, tt.nums)
+		}
+	}
+}
+
+func TestInsertInstrumentLimit(t *testing.T) {
+	s := instSong()
+	for len(s.Instruments) < MaxInstruments {
+		s.InsertInstrument(len(s.Instruments), DefaultInstrument())
+	}
+	if s.InsertInstrument(0, DefaultInstrument()) {
+		t.Error("inserted past MaxInstruments")
+	}
+	if got := rowInstruments(s); got != [5]uint8{1, 2, 3, 4, 0} {
+		t.Errorf("refused insert renumbered the notes: %v", got)
+	}
+}
+
+func TestInsertInstrumentLastNumber(t *testing.T) {
+	// A note naming instrument 255 has no number to move up to, so the
+	// insert is refused rather than wrapping it to "no instrument"
+	s := instSong()
+	s.Patterns[0].Notes[0][4].Instrument = MaxInstruments
+	if s.InsertInstrument(0, DefaultInstrument()) {
+		t.Error("insert accepted with a note on instrument 255")
+	}
+	if got := rowInstruments(s); got != [5]uint8{1, 2, 3, 4, MaxInstruments} {
+		t.Errorf("refused insert renumbered the notes: %v", got)
+	}
+
+	s.Patterns[0].Notes[0][4].Instrument = MaxInstruments - 1
+	if !s.InsertInstrument(0, DefaultInstrument()) {
+		t.Fatal("insert refused")
+	}
+	if got := rowInstruments(s); got != [5]uint8{2, 3, 4, 5, MaxInstruments} {
+		t.Errorf("after insert: %v", got)
+	}
+}
+
+func TestCloneInstrument(t *testing.T) {
+	inst := DefaultInstrument()
+	inst.FM.Operators = []FMOperator{{Ratio: 1}}
+	inst.Wavetable.Waves = [][]uint8{{1, 2}}
+	c := inst.Clone()
+	c.FM.Operators[0].Ratio = 2
+	c.Wavetable.Waves[0][0] = 9
+	if inst.FM.Operators[0].Ratio != 1 || inst.Wavetable.Waves[0][0] != 1 {
+		t.Error("clone shares operators or waves with the original")
+	}
+}
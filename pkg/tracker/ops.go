@@ -0,0 +1,211 @@
+package tracker
+
+import "math/rand/v2"
+
+// MaxPitch is the highest playable pitch (B-7)
+const MaxPitch = 95
+
+// Block is a rectangle of pattern cells, rows and channels inclusive
+type Block struct {
+	Row0, Row1 int
+	Ch0, Ch1   int
+}
+
+// Whole returns the block covering every cell of the pattern
+func (p *Pattern) Whole() Block {
+	return Block{Row0: 0, Row1: p.Rows - 1, Ch0: 0, Ch1: p.Channels - 1}
+}
+
+// Track returns the block covering one channel of the pattern
+func (p *Pattern) Track(ch int) Block {
+	return Block{Row0: 0, Row1: p.Rows - 1, Ch0: ch, Ch1: ch}
+}
+
+// clip limits b to the pattern and orders its corners
+func (p *Pattern) clip(b Block) Block {
+	if b.Row0 > b.Row1 {
+		b.Row0, b.Row1 = b.Row1, b.Row0
+	}
+	if b.Ch0 > b.Ch1 {
+		b.Ch0, b.Ch1 = b.Ch1, b.Ch0
+	}
+	b.Row0, b.Ch0 = max(b.Row0, 0), max(b.Ch0, 0)
+	b.Row1, b.Ch1 = min(b.Row1, len(p.Notes)-1), min(b.Ch1, p.Channels-1)
+	return b
+}
+
+// cells calls f for every note in b along with the instrument it plays
+// with: its own, or the last one set earlier in the channel (0 if none)
+func (p *Pattern) cells(b Block, f func(row, ch int, n *Note, inst uint8)) {
+	b = p.clip(b)
+	for ch := b.Ch0; ch <= b.Ch1; ch++ {
+		var inst uint8
+		for row := 0; row <= b.Row1; row++ {
+			if ch >= len(p.Notes[row]) {
+				break
+			}
+			n := &p.Notes[row][ch]
+			if n.Instrument > 0 {
+				inst = n.Instrument
+			}
+			if row >= b.Row0 {
+				f(row, ch, n, inst)
+			}
+		}
+	}
+}
+
+// Transpose shifts the notes in b by semitones, keeping them within
+// C-0..B-7. With inst > 0 only notes played by that instrument move.
+// It returns the number of notes changed.
+func (p *Pattern) Transpose(b Block, semitones int, inst uint8) int {
+	changed := 0
+	p.cells(b, func(_, _ int, n *Note, playing uint8) {
+		if n.Pitch < 0 || (inst > 0 && playing != inst) {
+			return
+		}
+		pitch := int8(min(max(int(n.Pitch)+semitones, 0), MaxPitch))
+		if pitch != n.Pitch {
+			n.Pitch = pitch
+			changed++
+		}
+	})
+	return changed
+}
+
+// ReplaceInstrument changes instrument from to to on the notes in b and
+// returns the number of notes changed
+func (p *Pattern) ReplaceInstrument(b Block, from, to uint8) int {
+	changed := 0
+	p.cells(b, func(_, _ int, n *Note, _ uint8) {
+		if from > 0 && n.Instrument == from && from != to {
+			n.Instrument = to
+			changed++
+		}
+	})
+	return changed
+}
+
+// ScaleVolume scales the volumes set in b, in the volume column and by
+// Cxx, to percent of their value (clamped to 0-64). It returns the number
+// of values changed.
+func (p *Pattern) ScaleVolume(b Block, percent int) int {
+	scale := func(v int) int {
+		return min(max((v*percent+50)/100, 0), 64)
+	}
+	changed := 0
+	p.cells(b, func(_, _ int, n *Note, _ uint8) {
+		if n.Volume >= 0 {
+			if v := int8(scale(int(n.Volume))); v != n.Volume {
+				n.Volume = v
+				changed++
+			}
+		}
+		if n.Effect.Type == FxVolume && n.Effect.Param <= 64 {
+			if v := uint8(scale(int(n.Effect.Param))); v != n.Effect.Param {
+				n.Effect.Param = v
+				changed++
+			}
+		}
+	})
+	return changed
+}
+
+// Humanize nudges the volumes set on the notes in b by a random amount
+// of up to ±amount (clamped to 0-64), drawn from rng. Notes that play at
+// their instrument's volume are left alone. It returns the number of
+// volumes changed.
+func (p *Pattern) Humanize(b Block, amount int, rng *rand.Rand) int {
+	if amount <= 0 {
+		return 0
+	}
+	changed := 0
+	p.cells(b, func(_, _ int, n *Note, _ uint8) {
+		if n.Pitch < 0 || n.Volume < 0 {
+			return
+		}
+		v := int8(min(max(int(n.Volume)+rng.IntN(2*amount+1)-amount, 0), 64))
+		if v != n.Volume {
+			n.Volume = v
+			changed++
+		}
+	})
+	return changed
+}
+
+// InterpolateVolume fills the volume column of every channel in b with
+// a linear ramp from the first row's volume to the last row's. Channels
+// without a volume on both ends are left alone. It returns the number of
+// cells written.
+func (p *Pattern) InterpolateVolume(b Block) int {
+	b = p.clip(b)
+	changed := 0
+	for ch := b.Ch0; ch <= b.Ch1; ch++ {
+		from, to := p.Notes[b.Row0][ch].Volume, p.Notes[b.Row1][ch].Volume
+		if from < 0 || to < 0 {
+			continue
+		}
+		for row := b.Row0 + 1; row < b.Row1; row++ {
+			p.Notes[row][ch].Volume = int8(lerp(int(from), int(to), row-b.Row0, b.Row1-b.Row0))
+			changed++
+		}
+	}
+	return changed
+}
+
+// InterpolateEffect fills the effect column of every channel in b with
+// the effect found on both the first and last row, ramping its parameter
+// between them. Channels whose end rows differ in effect are left alone.
+// It returns the number of cells written.
+func (p *Pattern) InterpolateEffect(b Block) int {
+	b = p.clip(b)
+	changed := 0
+	for ch := b.Ch0; ch <= b.Ch1; ch++ {
+		from, to := p.Notes[b.Row0][ch].Effect, p.Notes[b.Row1][ch].Effect
+		if from.Type != to.Type || (from.Type == FxNone && from.Param == 0 && to.Param == 0) {
+			continue
+		}
+		for row := b.Row0 + 1; row < b.Row1; row++ {
+			param := lerp(int(from.Param), int(to.Param), row-b.Row0, b.Row1-b.Row0)
+			p.Notes[row][ch].Effect = Effect{Type: from.Type, Param: uint8(param)}
+			changed++
+		}
+	}
+	return changed
+}
+
+// lerp interpolates from a to b at step i of n, rounding to nearest
+func lerp(a, b, i, n int) int {
+	d := (b - a) * i
+	if d >= 0 {
+		return a + (d+n/2)/n
+	}
+	return a - (-d+n/2)/n
+}
+
+// Transpose shifts every note in the song by semitones (see Pattern.Transpose)
+func (s *Song) Transpose(semitones int, inst uint8) int {
+	changed := 0
+	for _, pat := range s.Patterns {
+		changed += pat.Transpose(pat.Whole(), semitones, inst)
+	}
+	return changed
+}
+
+// ReplaceInstrument changes instrument from to to throughout the song
+func (s *Song) ReplaceInstrument(from, to uint8) int {
+	changed := 0
+	for _, pat := range s.Patterns {
+		changed += pat.ReplaceInstrument(pat.Whole(), from, to)
+	}
+	return changed
+}
+
+// ScaleVolume scales every volume in the song (see Pattern.ScaleVolume)
+func (s *Song) ScaleVolume(percent int) int {
+	changed := 0
+	for _, pat := range s.Patterns {
+		changed += pat.ScaleVolume(pat.Whole(), percent)
+	}
+	return changed
+}
@@ -0,0 +1,251 @@
+package tracker
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+// column returns a one-channel pattern holding the given notes
+func column(notes ...Note) *Pattern {
+	pat := NewPattern(len(notes), 1)
+	for row, n := range notes {
+		pat.Notes[row][0] = n
+	}
+	return pat
+}
+
+// pitches returns the pitches of channel 0
+func pitches(pat *Pattern) []int8 {
+	var out []int8
+	for _, row := range pat.Notes {
+		out = append(out, row[0].Pitch)
+	}
+	return out
+}
+
+// volumes returns the volume column of channel 0
+func volumes(pat *Pattern) []int8 {
+	var out []int8
+	for _, row := range pat.Notes {
+		out = append(out, row[0].Volume)
+	}
+	return out
+}
+
+func equal[T comparable](a, b []T) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestTranspose(t *testing.T) {
+	// Row 0 sets instrument 1, row 2 switches to 2; rows without an
+	// instrument play the one set above them
+	notes := func() *Pattern {
+		return column(
+			Note{Pitch: 48, Instrument: 1, Volume: -1},
+			Note{Pitch: 50, Volume: -1},
+			Note{Pitch: 52, Instrument: 2, Volume: -1},
+			Note{Pitch: 53, Volume: -1},
+			Note{Pitch: -2, Volume: -1},
+			Note{Pitch: 2, Volume: -1},
+			Note{Pitch: 94, Volume: -1},
+		)
+	}
+	tests := []struct {
+		name      string
+		block     Block
+		semitones int
+		inst      uint8
+		changed   int
+		want      []int8
+	}{
+		{"whole column", Block{0, 6, 0, 0}, 1, 0, 6, []int8{49, 51, 53, 54, -2, 3, 95}},
+		{"clamped at B-7", Block{0, 6, 0, 0}, 2, 0, 6, []int8{50, 52, 54, 55, -2, 4, 95}},
+		{"clamped at C-0", Block{0, 6, 0, 0}, -12, 0, 6, []int8{36, 38, 40, 41, -2, 0, 82}},
+		{"instrument 1 only", Block{0, 6, 0, 0}, 12, 1, 2, []int8{60, 62, 52, 53, -2, 2, 94}},
+		{"instrument 2 only", Block{0, 6, 0, 0}, 12, 2, 4, []int8{48, 50, 64, 65, -2, 14, 95}},
+		// The instrument is carried in from above the block
+		{"block below the instrument", Block{3, 5, 0, 0}, -1, 2, 2, []int8{48, 50, 52, 52, -2, 1, 94}},
+		{"by nothing changes nothing", Block{0, 6, 0, 0}, 0, 0, 0, []int8{48, 50, 52, 53, -2, 2, 94}},
+		{"reversed corners", Block{1, 0, 0, 0}, 5, 0, 2, []int8{53, 55, 52, 53, -2, 2, 94}},
+	}
+	for _, tt := range tests {
+		pat := notes()
+		if n := pat.Transpose(tt.block, tt.semitones, tt.inst); n != tt.changed {
+			t.Errorf("%s: %d notes changed, want %d", tt.name, n, tt.changed)
+		}
+		if got := pitches(pat); !equal(got, tt.want) {
+			t.Errorf("%s: pitches %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	song := NewSong(1)
+	song.Patterns = []*Pattern{notes(), notes()}
+	if n := song.Transpose(12, 1); n != 4 {
+		t.Errorf("song transpose of instrument 1 changed %d notes, want 4", n)
+	}
+}
+
+func TestLerp(t *testing.T) {
+	tests := []struct{ a, b, i, n, want int }{
+		{0, 64, 1, 4, 16},
+		{0, 10, 1, 3, 3}, // 3.33
+		{0, 10, 2, 3, 7}, // 6.67
+		{10, 0, 1, 3, 7}, // Rounds the same way going down
+		{10, 0, 2, 3, 3},
+		{0, 1, 1, 2, 1}, // Halves round away from a
+		{1, 0, 1, 2, 0},
+		{5, 5, 1, 2, 5},
+	}
+	for _, tt := range tests {
+		if got := lerp(tt.a, tt.b, tt.i, tt.n); got != tt.want {
+			t.Errorf("lerp(%d, %d, %d, %d) = %d, want %d", tt.a, tt.b, tt.i, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestInterpolateVolume(t *testing.T) {
+	pat := NewPattern(8, 3)
+	// Channel 0 ramps up, channel 1 down, channel 2 has no end volume
+	pat.Notes[1][0].Volume, pat.Notes[4][0].Volume = 0, 10
+	pat.Notes[1][1].Volume, pat.Notes[4][1].Volume = 64, 16
+	pat.Notes[1][2].Volume = 32
+	pat.Notes[2][2].Volume = 7
+
+	if n := pat.InterpolateVolume(Block{4, 1, 0, 2}); n != 4 {
+		t.Errorf("%d cells written, want 4", n)
+	}
+	for ch, want := range [][]int8{
+		{-1, 0, 3, 7, 10, -1, -1, -1},
+		{-1, 64, 48, 32, 16, -1, -1, -1},
+		{-1, 32, 7, -1, -1, -1, -1, -1},
+	} {
+		var got []int8
+		for _, row := range pat.Notes {
+			got = append(got, row[ch].Volume)
+		}
+		if !equal(got, want) {
+			t.Errorf("channel %d: volumes %v, want %v", ch, got, want)
+		}
+	}
+}
+
+func TestInterpolateEffect(t *testing.T) {
+	pat := NewPattern(5, 4)
+	fx := func(row, ch int, typ, param uint8) { pat.Notes[row][ch].Effect = Effect{Type: typ, Param: param} }
+	fx(0, 0, FxVolume, 0x10) // Same effect at both ends: ramped
+	fx(4, 0, FxVolume, 0x40)
+	fx(0, 1, FxVolume, 0x10) // Different effects: left alone
+	fx(4, 1, FxSpeed, 0x40)
+	fx(2, 1, FxSpeed, 0x03)
+	fx(2, 2, FxSpeed, 0x03) // Empty ends: left alone
+	fx(0, 3, FxNone, 0x20)  // Arpeggio (effect 0) with parameters ramps
+	fx(4, 3, FxNone, 0x00)
+
+	if n := pat.InterpolateEffect(pat.Whole()); n != 6 {
+		t.Errorf("%d cells written, want 6", n)
+	}
+	want := [][]Effect{
+		{{FxVolume, 0x10}, {FxVolume, 0x1C}, {FxVolume, 0x28}, {FxVolume, 0x34}, {FxVolume, 0x40}},
+		{{FxVolume, 0x10}, {}, {FxSpeed, 0x03}, {}, {FxSpeed, 0x40}},
+		{{}, {}, {FxSpeed, 0x03}, {}, {}},
+		{{FxNone, 0x20}, {FxNone, 0x18}, {FxNone, 0x10}, {FxNone, 0x08}, {}},
+	}
+	for ch, col := range want {
+		for row, w := range col {
+			if got := pat.Notes[row][ch].Effect; got != w {
+				t.Errorf("channel %d row %d: %v, want %v", ch, row, got, w)
+			}
+		}
+	}
+}
+
+func TestScaleVolume(t *testing.T) {
+	pat := column(
+		Note{Pitch: 48, Volume: 40},
+		Note{Pitch: 48, Volume: -1},
+		Note{Pitch: -1, Volume: 10},
+		Note{Pitch: -1, Volume: -1, Effect: Effect{Type: FxVolume, Param: 0x20}},
+		Note{Pitch: -1, Volume: -1, Effect: Effect{Type: FxVolume, Param: 0x50}}, // Out of range
+		Note{Pitch: -1, Volume: 0},
+		Note{Pitch: -1, Volume: 1},
+	)
+	if n := pat.ScaleVolume(pat.Whole(), 150); n != 4 {
+		t.Errorf("%d values changed, want 4", n)
+	}
+	if got, want := volumes(pat), []int8{60, -1, 15, -1, -1, 0, 2}; !equal(got, want) {
+		t.Errorf("volumes %v, want %v", got, want)
+	}
+	if p := pat.Notes[3][0].Effect.Param; p != 0x30 {
+		t.Errorf("C20 scaled to C%02X, want C30", p)
+	}
+	if p := pat.Notes[4][0].Effect.Param; p != 0x50 {
+		t.Errorf("C50 changed to C%02X", p)
+	}
+
+	// Clamped at 64, rounded to nearest
+	pat.ScaleVolume(pat.Whole(), 200)
+	if got, want := volumes(pat), []int8{64, -1, 30, -1, -1, 0, 4}; !equal(got, want) {
+		t.Errorf("volumes %v, want %v", got, want)
+	}
+	pat.ScaleVolume(pat.Whole(), 10)
+	if got, want := volumes(pat), []int8{6, -1, 3, -1, -1, 0, 0}; !equal(got, want) {
+		t.Errorf("volumes %v, want %v", got, want)
+	}
+
+	song := NewSong(1)
+	song.Patterns = []*Pattern{column(Note{Pitch: 48, Volume: 32}), column(Note{Pitch: 48, Volume: 16})}
+	if n := song.ScaleVolume(50); n != 2 || song.Patterns[1].Notes[0][0].Volume != 8 {
+		t.Errorf("song scale changed %d volumes, pattern 1 at %d", n, song.Patterns[1].Notes[0][0].Volume)
+	}
+}
+
+func TestHumanize(t *testing.T) {
+	build := func() *Pattern {
+		pat := NewPattern(64, 1)
+		for row := range pat.Notes {
+			pat.Notes[row][0] = Note{Pitch: 48, Volume: int8(row)}
+		}
+		pat.Notes[10][0].Volume = -1 // Instrument volume: left alone
+		pat.Notes[11][0].Pitch = -2  // Note off: left alone
+		return pat
+	}
+	pat := build()
+	n := pat.Humanize(pat.Whole(), 4, rand.New(rand.NewPCG(1, 2)))
+	if n == 0 {
+		t.Fatal("nothing changed")
+	}
+	for row, v := range volumes(pat) {
+		want := int8(row)
+		switch {
+		case row == 10:
+			want = -1
+		case row == 11:
+		default:
+			if d := int(v) - row; v < 0 || v > 64 || d < -4 || d > 4 {
+				t.Errorf("row %d: volume %d moved more than 4", row, v)
+			}
+			continue
+		}
+		if v != want {
+			t.Errorf("row %d: volume %d changed, want %d", row, v, want)
+		}
+	}
+
+	// The same seed gives the same result
+	again := build()
+	again.Humanize(again.Whole(), 4, rand.New(rand.NewPCG(1, 2)))
+	if !equal(volumes(again), volumes(pat)) {
+		t.Error("humanize is not repeatable for one seed")
+	}
+	if n := again.Humanize(again.Whole(), 0, rand.New(rand.NewPCG(1, 2))); n != 0 {
+		t.Errorf("amount 0 changed %d volumes", n)
+	}
+}
@@ -0,0 +1,128 @@
+package tracker
+
+// MaxOrnaments is the most ornaments a song can hold
+// (Gxx and instruments number them 1-255)
+const MaxOrnaments = 255
+
+// Clone returns a deep copy of the ornament
+func (orn Ornament) Clone() Ornament {
+	orn.Values = append([]int8(nil), orn.Values...)
+	return orn
+}
+
+// Chord is a named set of semitone intervals above the root
+type Chord struct {
+	Name      string
+	Intervals []int8
+}
+
+// Chords are the presets offered by the arpeggio generator
+var Chords = []Chord{
+	{"Maj", []int8{0, 4, 7}},
+	{"Min", []int8{0, 3, 7}},
+	{"Dim", []int8{0, 3, 6}},
+	{"Aug", []int8{0, 4, 8}},
+	{"Sus2", []int8{0, 2, 7}},
+	{"Sus4", []int8{0, 5, 7}},
+	{"Maj7", []int8{0, 4, 7, 11}},
+	{"Min7", []int8{0, 3, 7, 10}},
+	{"7th", []int8{0, 4, 7, 10}},
+	{"Oct", []int8{0, 12}},
+	{"Pow", []int8{0, 7, 12}},
+}
+
+// ArpeggioOrnament returns a looping ornament that cycles through the
+// chord, holding each note for ticks ticks
+func ArpeggioOrnament(chord Chord, ticks int) Ornament {
+	if ticks < 1 {
+		ticks = 1
+	}
+	orn := Ornament{Name: "Arp " + chord.Name, Loop: 0}
+	for _, v := range chord.Intervals {
+		for i := 0; i < ticks; i++ {
+			orn.Values = append(orn.Values, v)
+		}
+	}
+	return orn
+}
+
+// InsertOrnament inserts orn at index i, renumbering Gxx effects and
+// instrument ornaments that refer to the ornaments after it. It returns
+// false if the song already holds MaxOrnaments, or something selects
+// ornament MaxOrnaments and so could not be renumbered.
+func (s *Song) InsertOrnament(i int, orn Ornament) bool {
+	if len(s.Ornaments) >= MaxOrnaments || s.usesOrnament(MaxOrnaments) {
+		return false
+	}
+	if i < 0 || i > len(s.Ornaments) {
+		i = len(s.Ornaments)
+	}
+	s.Ornaments = append(s.Ornaments, Ornament{})
+	copy(s.Ornaments[i+1:], s.Ornaments[i:])
+	s.Ornaments[i] = orn
+
+	s.remapOrnaments(func(n int) int {
+		if n >= i {
+			return n + 1
+		}
+		return n
+	})
+	return true
+}
+
+// DeleteOrnament removes ornament i. Instruments using it fall back to no
+// ornament, Gxx effects selecting it become G00 (ornament off) and
+// references to later ornaments are renumbered.
+func (s *Song) DeleteOrnament(i int) {
+	if i < 0 || i >= len(s.Ornaments) {
+		return
+	}
+	s.Ornaments = append(s.Ornaments[:i], s.Ornaments[i+1:]...)
+
+	s.remapOrnaments(func(n int) int {
+		switch {
+		case n == i:
+			return -1
+		case n > i:
+			return n - 1
+		}
+		return n
+	})
+}
+
+// usesOrnament reports whether an instrument or Gxx selects ornament
+// number num (1-based)
+func (s *Song) usesOrnament(num uint8) bool {
+	used := false
+	s.remapOrnaments(func(n int) int {
+		used = used || n == int(num)-1
+		return n
+	})
+	return used
+}
+
+// remapOrnaments rewrites every ornament number (instrument defaults and
+// Gxx params) through f, which maps old 0-based indices to new ones
+// (-1 = none)
+
+func (s *Song) remapOrnaments(f func(n int) int) {
+	remap := func(num uint8) uint8 {
+		if num == 0 {
+			return 0
+		}
+		return uint8(f(int(num)-1) + 1)
+	}
+
+	for i := range s.Instruments {
+		s.Instruments[i].Ornament = remap(s.Instruments[i].Ornament)
+	}
+	for _, pat := range s.Patterns {
+		for _, row := range pat.Notes {
+			for ch := range row {
+				if fx := &row[ch].Effect; fx.Type == FxOrnament {
+					fx.Param = remap(fx.Param)
+				}
+			}
+		}
+	}
+}
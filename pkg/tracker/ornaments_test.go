@@ -0,0 +1,113 @@
+package tracker
+
+import "testing"
+
+// ornSong returns a song with ornaments named A, B, C, instruments using
+// ornaments 0-3 and a row selecting them with G00-G03
+func ornSong() *Song {
+	s := NewSong(4)
+	s.Ornaments = []Ornament{{Name: "A"}, {Name: "B"}, {Name: "C"}}
+	s.Instruments = nil
+	for i := range 4 {
+		inst := DefaultInstrument()
+		inst.Ornament = uint8(i)
+		s.Instruments = append(s.Instruments, inst)
+		s.Patterns[0].Notes[0][i].Effect = Effect{Type: FxOrnament, Param: uint8(i)}
+	}
+	// Not an ornament number: must be left alone
+	s.Patterns[0].Notes[1][0].Effect = Effect{Type: FxSpeed, Param: 2}
+	return s
+}
+
+// ornamentRefs returns the instrument ornaments and the Gxx params
+func ornamentRefs(s *Song) (insts, fx [4]uint8) {
+	for i := range 4 {
+		insts[i] = s.Instruments[i].Ornament
+		fx[i] = s.Patterns[0].Notes[0][i].Effect.Param
+	}
+	return insts, fx
+}
+
+func TestOrnamentRemap(t *testing.T) {
+	tests := []struct {
+		name  string
+		edit  func(s *Song)
+		names string
+		refs  [4]uint8 // Same for instruments and Gxx
+	}{
+		{"insert at the start", func(s *Song) { s.InsertOrnament(0, Ornament{Name: "N"}) }, "NABC", [4]uint8{0, 2, 3, 4}},
+		{"insert in the middle", func(s *Song) { s.InsertOrnament(1, Ornament{Name: "N"}) }, "ANBC", [4]uint8{0, 1, 3, 4}},
+		{"insert past the end appends", func(s *Song) { s.InsertOrnament(7, Ornament{Name: "N"}) }, "ABCN", [4]uint8{0, 1, 2, 3}},
+		{"delete turns its Gxx into G00", func(s *Song) { s.DeleteOrnament(1) }, "AC", [4]uint8{0, 1, 0, 2}},
+		{"delete the first", func(s *Song) { s.DeleteOrnament(0) }, "BC", [4]uint8{0, 0, 1, 2}},
+		{"delete out of range", func(s *Song) { s.DeleteOrnament(3) }, "ABC", [4]uint8{0, 1, 2, 3}},
+	}
+	for _, tt := range tests {
+		s := ornSong()
+		tt.edit(s)
+		names := ""
+		for _, orn := range s.Ornaments {
+			names += orn.Name
+		}
+		if names != tt.names {
+			t.Errorf("%s: ornaments %s, want %s", tt.name, names, tt.names)
+		}
+		insts, fx := ornamentRefs(s)
+		if insts != tt.refs {
+			t.Errorf("%s: instruments use ornaments %v, want %v", tt.name, insts, tt.refs)
+		}
+		if fx != tt.refs {
+			t.Errorf("%s: Gxx params %v, want %v", tt.name, fx, tt.refs)
+		}
+		if fx := s.Patterns[0].Notes[1][0].Effect; fx != (Effect{Type: FxSpeed, Param: 2}) {
+			t.Errorf("%s: F02 changed to %v", tt.name, fx)
+		}
+	}
+}
+
+func TestInsertOrnamentLimit(t *testing.T) {
+	s := ornSong()
+	for len(s.Ornaments) < MaxOrnaments {
+		s.InsertOrnament(len(s.Ornaments), Ornament{})
+	}
+	if s.InsertOrnament(0, Ornament{}) {
+		t.Error("inserted past MaxOrnaments")
+	}
+	if insts, _ := ornamentRefs(s); insts != [4]uint8{0, 1, 2, 3} {
+		t.Errorf("refused insert renumbered the instruments: %v", insts)
+	}
+}
+
+func TestInsertOrnamentLastNumber(t *testing.T) {
+	// GFF has no number to move up to, so the insert is refused rather
+	// than wrapping it to G00
+	s := ornSong()
+	s.Patterns[0].Notes[2][0].Effect = Effect{Type: FxOrnament, Param: MaxOrnaments}
+	if s.InsertOrnament(0, Ornament{}) {
+		t.Error("insert accepted with a GFF in the song")
+	}
+	s.Patterns[0].Notes[2][0].Effect = Effect{}
+	s.Instruments[0].Ornament = MaxOrnaments
+	if s.InsertOrnament(0, Ornament{}) {
+		t.Error("insert accepted with an instrument on ornament FF")
+	}
+	if insts, fx := ornamentRefs(s); insts != [4]uint8{MaxOrnaments, 1, 2, 3} || fx != [4]uint8{0, 1, 2, 3} {
+		t.Errorf("refused insert renumbered %v %v", insts, fx)
+	}
+}
+
+func TestArpeggioOrnament(t *testing.T) {
+	orn := ArpeggioOrnament(Chord{"Maj", []int8{0, 4, 7}}, 2)
+	want := []int8{0, 0, 4, 4, 7, 7}
+	if orn.Loop != 0 || len(orn.Values) != len(want) {
+		t.Fatalf("got %+v", orn)
+	}
+	for i, v := range want {
+		if orn.Values[i] != v {
+			t.Fatalf("values %v, want %v", orn.Values, want)
+		}
+	}
+	if orn := ArpeggioOrnament(Chord{"Oct", []int8{0, 12}}, 0); len(orn.Values) != 2 {
+		t.Errorf("0 ticks per note gave %v, want 1 tick each", orn.Values)
+	}
+}
@@ -0,0 +1,134 @@
+package tracker
+
+// MaxPatterns is the most patterns a song can hold (order entries are uint8)
+const MaxPatterns = 256
+
+// InsertPattern inserts pat at index i, renumbering order entries that
+// refer to the patterns after it. It returns false if the song already
+// holds MaxPatterns.
+func (s *Song) InsertPattern(i int, pat *Pattern) bool {
+	if len(s.Patterns) >= MaxPatterns {
+		return false
+	}
+	if i < 0 || i > len(s.Patterns) {
+		i = len(s.Patterns)
+	}
+	s.Patterns = append(s.Patterns, nil)
+	copy(s.Patterns[i+1:], s.Patterns[i:])
+	s.Patterns[i] = pat
+
+	for pos, n := range s.Order {
+		if int(n) >= i {
+			s.Order[pos] = n + 1
+		}
+	}
+	return true
+}
+
+// ClonePattern inserts a copy of pattern i right after it and returns the
+// copy's index, or -1 if the song is full
+func (s *Song) ClonePattern(i int) int {
+	if i < 0 || i >= len(s.Patterns) || !s.InsertPattern(i+1, s.Patterns[i].Clone()) {
+		return -1
+	}
+	return i + 1
+}
+
+// DeletePattern removes pattern i along with its order entries, keeping
+// at least one pattern. Bxx jumps are renumbered to follow the order.
+func (s *Song) DeletePattern(i int) {
+	if i < 0 || i >= len(s.Patterns) || len(s.Patterns) <= 1 {
+		return
+	}
+	s.Patterns = append(s.Patterns[:i], s.Patterns[i+1:]...)
+
+	s.removeOrderEntries(func(n uint8) bool { return int(n) == i })
+	for pos, n := range s.Order {
+		if int(n) > i {
+			s.Order[pos] = n - 1
+		}
+	}
+}
+
+// MovePattern moves pattern from to index to, renumbering order entries
+func (s *Song) MovePattern(from, to int) {
+	if from < 0 || from >= len(s.Patterns) || to < 0 || to >= len(s.Patterns) || from == to {
+		return
+	}
+	pat := s.Patterns[from]
+	if from < to {
+		copy(s.Patterns[from:], s.Patterns[from+1:to+1])
+	} else {
+		copy(s.Patterns[to+1:], s.Patterns[to:from])
+	}
+	s.Patterns[to] = pat
+
+	for pos, n := range s.Order {
+		switch {
+		case int(n) == from:
+			s.Order[pos] = uint8(to)
+		case from < to && int(n) > from && int(n) <= to:
+			s.Order[pos] = n - 1
+		case to < from && int(n) >= to && int(n) < from:
+			s.Order[pos] = n + 1
+		}
+	}
+}
+
+// UsedPatterns reports which patterns appear in the order
+func (s *Song) UsedPatterns() []bool {
+	used := make([]bool, len(s.Patterns))
+	for _, n := range s.Order {
+		if int(n) < len(used) {
+			used[n] = true
+		}
+	}
+	return used
+}
+
+// RemoveUnusedPatterns deletes the patterns that do not appear in the
+// order and returns how many were removed
+func (s *Song) RemoveUnusedPatterns() int {
+	used := s.UsedPatterns()
+	removed := 0
+	for i := len(used) - 1; i >= 0; i-- {
+		if !used[i] && len(s.Patterns) > 1 {
+			s.DeletePattern(i)
+			removed++
+		}
+	}
+	return removed
+}
+
+// removeOrderEntries drops the order positions whose pattern matches
+// drop, pointing Bxx jumps at the same place in the shortened order.
+// The order keeps at least one entry.
+func (s *Song) removeOrderEntries(drop func(n uint8) bool) {
+	// newPos[p] is where old position p ends up (or the next kept one)
+	newPos := make([]int, len(s.Order)+1)
+	order := s.Order[:0]
+	for pos, n := range s.Order {
+		newPos[pos] = len(order)
+		if !drop(n) {
+			order = append(order, n)
+		}
+	}
+	newPos[len(s.Order)] = len(order)
+	if len(order) == len(s.Order) {
+		return
+	}
+	s.Order = order
+	if len(s.Order) == 0 {
+		s.Order = []uint8{0}
+	}
+
+	for _, pat := range s.Patterns {
+		for _, row := range pat.Notes {
+			for ch := range row {
+				if fx := &row[ch].Effect; fx.Type == FxJump && int(fx.Param) < len(newPos) {
+					fx.Param = uint8(newPos[fx.Param] % len(s.Order))
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package tracker
+
+import (
+	"fmt"
+	"testing"
+)
+
+// patSong returns a song of patterns 0-3, each marked by its number in
+// its first cell, played in the order 0 1 2 1 3. Pattern 0 ends with B02
+// and pattern 3 with B03.
+func patSong() *Song {
+	s := NewSong(1)
+	s.Patterns = nil
+	for i := range 4 {
+		pat := NewPattern(4, 1)
+		pat.Notes[0][0].Pitch = int8(i)
+		s.Patterns = append(s.Patterns, pat)
+	}
+	s.Order = []uint8{0, 1, 2, 1, 3}
+	s.Patterns[0].Notes[3][0].Effect = Effect{Type: FxJump, Param: 2}
+	s.Patterns[3].Notes[3][0].Effect = Effect{Type: FxJump, Param: 3}
+	return s
+}
+
+// patState describes the song as the pattern markers, the order and the
+// Bxx jumps of each pattern, e.g. "0123 [0 1 2 1 3] 0:B02 3:B03"
+func patState(s *Song) string {
+	markers := ""
+	for _, pat := range s.Patterns {
+		markers += fmt.Sprint(pat.Notes[0][0].Pitch)
+	}
+	out := fmt.Sprintf("%s %v", markers, s.Order)
+	for _, pat := range s.Patterns {
+		for _, row := range pat.Notes {
+			if fx := row[0].Effect; fx.Type == FxJump {
+				out += fmt.Sprintf(" %d:B%02X", pat.Notes[0][0].Pitch, fx.Param)
+			}
+		}
+	}
+	return out
+}
+
+func TestPatternRemap(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(s *Song)
+		want string
+	}{
+		{"insert renumbers the order", func(s *Song) { s.InsertPattern(1, NewPattern(4, 1)) }, "0-1123 [0 2 3 2 4] 0:B02 3:B03"},
+		{"clone", func(s *Song) { s.ClonePattern(3) }, "01233 [0 1 2 1 3] 0:B02 3:B03 3:B03"},
+		// Positions 1 and 3 go: B02 follows pattern 2 to position 1 and
+		// B03 lands on the next kept position, 2
+		{"delete a pattern used twice", func(s *Song) { s.DeletePattern(1) }, "023 [0 1 2] 0:B01 3:B02"},
+		{"delete the last pattern", func(s *Song) { s.DeletePattern(3) }, "012 [0 1 2 1] 0:B02"},
+		{"delete the only pattern is refused", func(s *Song) {
+			s.Patterns = s.Patterns[:1]
+			s.Order = []uint8{0}
+			s.DeletePattern(0)
+		}, "0 [0] 0:B02"},
+		{"move down", func(s *Song) { s.MovePattern(0, 2) }, "1203 [2 0 1 0 3] 0:B02 3:B03"},
+		{"move up", func(s *Song) { s.MovePattern(3, 1) }, "0312 [0 2 3 2 1] 0:B02 3:B03"},
+		{"move to itself", func(s *Song) { s.MovePattern(2, 2) }, "0123 [0 1 2 1 3] 0:B02 3:B03"},
+		// Only unused patterns go, so the order and jumps keep their places
+		{"remove unused", func(s *Song) {
+			s.Order = []uint8{0, 2}
+			if n := s.RemoveUnusedPatterns(); n != 2 {
+				t.Errorf("remove unused: removed %d, want 2", n)
+			}
+		}, "02 [0 1] 0:B02"},
+	}
+	for _, tt := range tests {
+		s := patSong()
+		tt.edit(s)
+		if got := patState(s); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDeletePatternEmptiesOrder(t *testing.T) {
+	// Deleting the only pattern in the order leaves one entry behind and
+	// jumps wrap into it; B03 was already past the end and stays
+	s := patSong()
+	s.Order = []uint8{1, 1}
+	s.Patterns[0].Notes[3][0].Effect = Effect{Type: FxJump, Param: 1}
+	s.DeletePattern(1)
+	if got := patState(s); got != "023 [0] 0:B00 3:B03" {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestRemoveOrderEntries(t *testing.T) {
+	// Jumps past the end of the order are left alone, and nothing
+	// changes when no entry is dropped
+	s := patSong()
+	s.Patterns[1].Notes[3][0].Effect = Effect{Type: FxJump, Param: 0x40}
+	s.removeOrderEntries(func(n uint8) bool { return n == 9 })
+	if got := patState(s); got != "0123 [0 1 2 1 3] 0:B02 1:B40 3:B03" {
+		t.Errorf("nothing dropped: got %s", got)
+	}
+	s.removeOrderEntries(func(n uint8) bool { return n == 0 })
+	if got := patState(s); got != "0123 [1 2 1 3] 0:B01 1:B40 3:B02" {
+		t.Errorf("first entry dropped: got %s", got)
+	}
+}
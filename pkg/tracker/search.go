@@ -0,0 +1,177 @@
+package tracker
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Query matches pattern cells field by field, written the way cells are
+// saved: "C-4 01 40 A04" (note, instrument, volume, effect), with "---",
+// "--" and "..." for empty fields. In a field ? matches any one character
+// and * matches anything; fields left off the end match anything.
+type Query struct {
+	Fields [4]string
+}
+
+// Cell field widths, in query order
+var queryWidths = [4]int{3, 2, 2, 3}
+
+// ParseQuery parses a query such as "C-? 01" or "* * * C??"
+func ParseQuery(s string) (Query, error) {
+	var q Query
+	parts := strings.Fields(strings.ToUpper(s))
+	if len(parts) > len(q.Fields) {
+		return q, fmt.Errorf("too many fields (note inst vol fx)")
+	}
+	for i := range q.Fields {
+		q.Fields[i] = "*"
+		if i >= len(parts) {
+			continue
+		}
+		if f := parts[i]; f != "*" {
+			if len(f) != queryWidths[i] {
+				return q, fmt.Errorf("%q should be %d characters", f, queryWidths[i])
+			}
+			q.Fields[i] = f
+		}
+	}
+	return q, nil
+}
+
+func (q Query) String() string {
+	return strings.TrimRight(strings.Join(q.Fields[:], " "), " *")
+}
+
+// cellFields returns the text of a cell's fields, as matched by Query
+func cellFields(n Note) [4]string {
+	f := [4]string{NoteToString(n.Pitch), "--", "--", "..."}
+	if n.Instrument > 0 {
+		f[1] = fmt.Sprintf("%02X", n.Instrument)
+	}
+	if n.Volume >= 0 {
+		f[2] = fmt.Sprintf("%02X", n.Volume)
+	}
+	if n.Effect.Type != 0 || n.Effect.Param != 0 {
+		f[3] = fmt.Sprintf("%c%02X", EffectChar(n.Effect.Type), n.Effect.Param)
+	}
+	return f
+}
+
+// Match reports whether a cell matches the query
+func (q Query) Match(n Note) bool {
+	cell := cellFields(n)
+	for i, f := range q.Fields {
+		if f == "*" || f == "" {
+			continue
+		}
+		for j := range len(f) {
+			if f[j] != '?' && f[j] != cell[i][j] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// apply rewrites a cell with a replacement query: * keeps a field and
+// ? keeps one character of it. It returns false if the result is not a
+// valid cell.
+func (q Query) apply(n Note) (Note, bool) {
+	cell := cellFields(n)
+	for i, f := range q.Fields {
+		if f == "*" || f == "" {
+			continue
+		}
+		b := []byte(f)
+		for j := range b {
+			if b[j] == '?' {
+				b[j] = cell[i][j]
+			}
+		}
+		cell[i] = string(b)
+	}
+
+	out := Note{Pitch: -1, Volume: -1}
+	switch note := cell[0]; {
+	case note == "---":
+	case note == "OFF":
+		out.Pitch = -2
+	default:
+		out.Pitch = StringToNote(note)
+		if out.Pitch < 0 || note[2] < '0' || note[2] > '7' {
+			return n, false
+		}
+	}
+	if cell[1] != "--" {
+		v, err := strconv.ParseUint(cell[1], 16, 8)
+		if err != nil {
+			return n, false
+		}
+		out.Instrument = uint8(v)
+	}
+	if cell[2] != "--" {
+		v, err := strconv.ParseUint(cell[2], 16, 8)
+		if err != nil || v > 64 {
+			return n, false
+		}
+		out.Volume = int8(v)
+	}
+	if cell[3] != "..." {
+		typ, ok := ParseEffectChar(cell[3][0])
+		param, err := strconv.ParseUint(cell[3][1:], 16, 8)
+		if !ok || err != nil {
+			return n, false
+		}
+		out.Effect = Effect{Type: typ, Param: uint8(param)}
+	}
+	return out, true
+}
+
+// Hit is the position of a matching cell in the song
+type Hit struct {
+	Pos int // Order position
+	Row int
+	Ch  int
+}
+
+// Find returns the cells matching q in playing order. A pattern used at
+// several order positions is reported at each of them.
+func (s *Song) Find(q Query) []Hit {
+	var hits []Hit
+	for pos, n := range s.Order {
+		if int(n) >= len(s.Patterns) {
+			continue
+		}
+		pat := s.Patterns[n]
+		for row := range pat.Notes {
+			for ch, note := range pat.Notes[row] {
+				if q.Match(note) {
+					hits = append(hits, Hit{Pos: pos, Row: row, Ch: ch})
+				}
+			}
+		}
+	}
+	return hits
+}
+
+// ReplaceAll rewrites every cell matching q, in all patterns, with the
+// replacement r (see Query.apply). Cells whose result would not be valid
+// are left alone. It returns the number of cells changed.
+func (s *Song) ReplaceAll(q, r Query) int {
+	changed := 0
+	for _, pat := range s.Patterns {
+		for row := range pat.Notes {
+			for ch, note := range pat.Notes[row] {
+				if !q.Match(note) {
+					continue
+				}
+				if out, ok := r.apply(note); ok && out != note {
+					pat.Notes[row][ch] = out
+					changed++
+				}
+			}
+		}
+	}
+	return changed
+}
@@ -0,0 +1,175 @@
+package tracker
+
+import "testing"
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		in   string
+		want [4]string
+		str  string
+		err  bool
+	}{
+		{"", [4]string{"*", "*", "*", "*"}, "", false},
+		{"c-4", [4]string{"C-4", "*", "*", "*"}, "C-4", false},
+		{"C-? 01", [4]string{"C-?", "01", "*", "*"}, "C-? 01", false},
+		{"* * * c??", [4]string{"*", "*", "*", "C??"}, "* * * C??", false},
+		{"  --- --  ", [4]string{"---", "--", "*", "*"}, "--- --", false},
+		{"OFF * 40", [4]string{"OFF", "*", "40", "*"}, "OFF * 40", false},
+		{"C-4 01 40 A04 x", [4]string{}, "", true}, // Too many fields
+		{"C4", [4]string{}, "", true},              // Note is 3 characters
+		{"* 1", [4]string{}, "", true},             // Instrument is 2
+		{"* * 040", [4]string{}, "", true},         // Volume is 2
+		{"* * * A4", [4]string{}, "", true},        // Effect is 3
+	}
+	for _, tt := range tests {
+		q, err := ParseQuery(tt.in)
+		if (err != nil) != tt.err {
+			t.Errorf("ParseQuery(%q) error %v, want error %v", tt.in, err, tt.err)
+			continue
+		}
+		if tt.err {
+			continue
+		}
+		if q.Fields != tt.want {
+			t.Errorf("ParseQuery(%q) = %q, want %q", tt.in, q.Fields, tt.want)
+		}
+		if s := q.String(); s != tt.str {
+			t.Errorf("ParseQuery(%q).String() = %q, want %q", tt.in, s, tt.str)
+		}
+	}
+}
+
+func TestQueryMatch(t *testing.T) {
+	c4 := Note{Pitch: 48, Instrument: 1, Volume: 0x20, Effect: Effect{Type: FxVolume, Param: 0x10}}
+	empty := Note{Pitch: -1, Volume: -1}
+	off := Note{Pitch: -2, Volume: -1}
+	tests := []struct {
+		query string
+		note  Note
+		want  bool
+	}{
+		{"", c4, true},
+		{"", empty, true},
+		{"C-4", c4, true},
+		{"C-?", c4, true},
+		{"C-5", c4, false},
+		{"?#?", c4, false},
+		{"??4 01 20 C10", c4, true},
+		{"* 02", c4, false},
+		{"* * 2?", c4, true},
+		{"* * * C??", c4, true},
+		{"* * * A??", c4, false},
+		{"* * --", c4, false},
+		{"---", empty, true},
+		{"--- -- -- ...", empty, true},
+		{"???", empty, true},
+		{"C-?", empty, false},
+		{"OFF", off, true},
+		{"---", off, false},
+		{"* * * ...", c4, false},
+		{"* * * ...", empty, true},
+	}
+	for _, tt := range tests {
+		q, err := ParseQuery(tt.query)
+		if err != nil {
+			t.Fatalf("ParseQuery(%q): %v", tt.query, err)
+		}
+		if got := q.Match(tt.note); got != tt.want {
+			t.Errorf("%q matches %+v = %v, want %v", tt.query, tt.note, got, tt.want)
+		}
+	}
+}
+
+func TestQueryApply(t *testing.T) {
+	c4 := Note{Pitch: 48, Instrument: 1, Volume: 0x20, Effect: Effect{Type: FxVolume, Param: 0x10}}
+	empty := Note{Pitch: -1, Volume: -1}
+	tests := []struct {
+		name  string
+		repl  string
+		note  Note
+		want  Note
+		valid bool
+	}{
+		{"keep everything", "", c4, c4, true},
+		{"note name, same octave", "D#?", c4, Note{Pitch: 51, Instrument: 1, Volume: 0x20, Effect: c4.Effect}, true},
+		{"octave only", "??6", c4, Note{Pitch: 72, Instrument: 1, Volume: 0x20, Effect: c4.Effect}, true},
+		{"note off", "OFF", c4, Note{Pitch: -2, Instrument: 1, Volume: 0x20, Effect: c4.Effect}, true},
+		{"clear the note", "---", c4, Note{Pitch: -1, Instrument: 1, Volume: 0x20, Effect: c4.Effect}, true},
+		{"instrument", "* 0A", c4, Note{Pitch: 48, Instrument: 10, Volume: 0x20, Effect: c4.Effect}, true},
+		{"clear the instrument", "* --", c4, Note{Pitch: 48, Volume: 0x20, Effect: c4.Effect}, true},
+		{"full volume", "* * 40", c4, Note{Pitch: 48, Instrument: 1, Volume: 64, Effect: c4.Effect}, true},
+		{"clear the volume", "* * --", c4, Note{Pitch: 48, Instrument: 1, Volume: -1, Effect: c4.Effect}, true},
+		{"effect type kept", "* * * ?30", c4, Note{Pitch: 48, Instrument: 1, Volume: 0x20, Effect: Effect{Type: FxVolume, Param: 0x30}}, true},
+		{"effect", "* * * G05", c4, Note{Pitch: 48, Instrument: 1, Volume: 0x20, Effect: Effect{Type: FxOrnament, Param: 5}}, true},
+		{"clear the effect", "* * * ...", c4, Note{Pitch: 48, Instrument: 1, Volume: 0x20}, true},
+		{"set an empty cell", "E-3 02 10 F03", empty, Note{Pitch: 40, Instrument: 2, Volume: 0x10, Effect: Effect{Type: FxSpeed, Param: 3}}, true},
+
+		{"octave past 7", "??8", c4, c4, false},
+		{"not a note", "X-4", c4, c4, false},
+		{"octave from an empty note", "C-?", empty, empty, false},
+		{"sharp from an empty note", "D#?", empty, empty, false},
+		{"volume past 40", "* * 41", c4, c4, false},
+		{"hex volume digits only", "* * 2G", c4, c4, false},
+		{"instrument not hex", "* ZZ", c4, c4, false},
+		{"half an instrument", "* 0?", empty, empty, false},
+		{"effect param from an empty effect", "* * * C??", empty, empty, false},
+		{"effect type not a character", "* * * #10", c4, c4, false},
+	}
+	for _, tt := range tests {
+		r, err := ParseQuery(tt.repl)
+		if err != nil {
+			t.Fatalf("%s: ParseQuery(%q): %v", tt.name, tt.repl, err)
+		}
+		got, ok := r.apply(tt.note)
+		if ok != tt.valid {
+			t.Errorf("%s: %q valid = %v, want %v", tt.name, tt.repl, ok, tt.valid)
+		}
+		if got != tt.want {
+			t.Errorf("%s: %q gave %+v, want %+v", tt.name, tt.repl, got, tt.want)
+		}
+	}
+}
+
+func TestFindAndReplaceAll(t *testing.T) {
+	s := NewSong(2)
+	s.Patterns = []*Pattern{NewPattern(4, 2), NewPattern(4, 2)}
+	s.Order = []uint8{0, 1, 0}
+	p0, p1 := s.Patterns[0], s.Patterns[1]
+	p0.Notes[1][1] = Note{Pitch: 48, Instrument: 1, Volume: 0x20}
+	p0.Notes[2][0] = Note{Pitch: 50, Instrument: 1, Volume: -1}
+	p1.Notes[0][0] = Note{Pitch: 36, Instrument: 1, Volume: 0x35}
+	p1.Notes[3][1] = Note{Pitch: 48, Instrument: 2, Volume: 0x20}
+
+	q, _ := ParseQuery("* 01")
+	want := []Hit{{0, 1, 1}, {0, 2, 0}, {1, 0, 0}, {2, 1, 1}, {2, 2, 0}}
+	hits := s.Find(q)
+	if !equal(hits, want) {
+		t.Errorf("Find = %v, want %v (in playing order, at every use)", hits, want)
+	}
+
+	// "4?" keeps the second volume digit: 20 becomes 40 but 35 would be
+	// 45 (too loud) and the note without a volume "4-", so both stay
+	r, _ := ParseQuery("* 03 4?")
+	if n := s.ReplaceAll(q, r); n != 1 {
+		t.Errorf("ReplaceAll changed %d cells, want 1", n)
+	}
+	for _, tt := range []struct {
+		pat     *Pattern
+		row, ch int
+		want    Note
+	}{
+		{p0, 1, 1, Note{Pitch: 48, Instrument: 3, Volume: 0x40}},
+		{p0, 2, 0, Note{Pitch: 50, Instrument: 1, Volume: -1}},
+		{p1, 0, 0, Note{Pitch: 36, Instrument: 1, Volume: 0x35}},
+		{p1, 3, 1, Note{Pitch: 48, Instrument: 2, Volume: 0x20}}, // Not matched
+	} {
+		if got := tt.pat.Notes[tt.row][tt.ch]; got != tt.want {
+			t.Errorf("row %d ch %d: %+v, want %+v", tt.row, tt.ch, got, tt.want)
+		}
+	}
+
+	// Replacing a cell with itself is not a change
+	if n := s.ReplaceAll(q, Query{}); n != 0 {
+		t.Errorf("empty replacement changed %d cells", n)
+	}
+}
@@ -34,6 +34,9 @@ const (
 	FxRetrigger  uint8 = 0x12 // Ixy - Retrigger note
 	FxCut        uint8 = 0x13 // Jxx - Cut note after xx ticks
 	FxDuty       uint8 = 0x14 // Kxx - Set duty cycle (00-FF, 80=50%)
+	FxCutoff     uint8 = 0x15 // Lxx - Set filter cutoff
+	FxResonance  uint8 = 0x16 // Mxx - Set filter resonance
+	FxFiltSweep  uint8 = 0x17 // Nxy - Sweep cutoff up x / down y per tick
 )
 
 // Generator types for instruments
@@ -47,6 +50,9 @@ const (
 	GenNoise
 	GenSample    // Sample-based
 	GenBytebeat  // Custom bytebeat formula
+	GenFM        // Multi-operator FM synthesis
+	GenWavetable // User-drawn single-cycle waves
+	GenCount
 )
 
 // Instrument defines a sound source
@@ -57,9 +63,45 @@ type Instrument struct {
 	Formula   string    // For GenBytebeat
 	Envelope  Envelope
 	Ornament  uint8     // Default ornament (0 = none)
-	Detune    int8      // Fine detune (-64 to +63)
+	Detune    int8      // Fine detune in 1/64 semitones (-64 to +63)
 	Volume    uint8     // Default volume (0-64)
 	Duty      uint8     // Duty cycle for pulse wave (0-255, 128=50%)
+	FM        FMPatch   // For GenFM
+	Wavetable Wavetable // For GenWavetable
+	Filter    Filter    // Instrument filter (overrides channel filter)
+}
+
+// DefaultInstrument returns the settings for a new instrument
+func DefaultInstrument() Instrument {
+	return Instrument{
+		Name:      "New",
+		Generator: GenTriangle,
+		Volume:    64,
+		Envelope:  Envelope{Attack: 0, Decay: 20, Sustain: 48, Release: 30},
+	}
+}
+
+// FM algorithms (operator 1 is always a carrier)
+const (
+	FMAlgSerial   uint8 = iota // 4→3→2→1
+	FMAlgPairs                 // (2→1) + (4→3)
+	FMAlgStack                 // (2+3+4)→1
+	FMAlgAdditive              // 1 + 2 + 3 + 4
+	FMAlgCount
+)
+
+// FMPatch defines a 2-4 operator FM voice (Genesis/OPL style)
+type FMPatch struct {
+	Algorithm uint8        // Operator routing (FMAlg*)
+	Operators []FMOperator // 2-4 operators
+}
+
+// FMOperator defines one sine operator of an FM patch
+type FMOperator struct {
+	Ratio    uint8    // Frequency multiplier (0 = 0.5, 1-15 like OPL MULT)
+	Level    uint8    // Output level (0-64)
+	Feedback uint8    // Self-modulation (0-7)
+	Envelope Envelope // Operator envelope (tick-based, Loop unused)
 }
 
 // Envelope defines ADSR-like volume envelope
@@ -68,7 +110,26 @@ type Envelope struct {
 	Decay   uint8 // Decay time
 	Sustain uint8 // Sustain level (0-64)
 	Release uint8 // Release time
-	Loop    bool  // Loop sustain
+	Loop    bool  // Repeat attack and decay until released
+}
+
+// FilterType selects the state-variable filter response
+type FilterType uint8
+
+const (
+	FilterOff FilterType = iota
+	FilterLowPass
+	FilterHighPass
+	FilterBandPass
+)
+
+// Filter defines a resonant state-variable filter
+type Filter struct {
+	Type      FilterType
+	Cutoff    uint8    // Cutoff (0-255, exponential 20 Hz to 20 kHz)
+	Resonance uint8    // Resonance (0-255)
+	EnvAmount int8     // Filter envelope depth in cutoff steps (0 = no envelope)
+	Envelope  Envelope // Filter envelope (tick-based)
 }
 
 // Ornament defines semitone offset pattern (ZX Spectrum style)
@@ -101,6 +162,19 @@ func NewPattern(rows, channels int) *Pattern {
 	return p
 }
 
+// Clone returns a deep copy of the pattern
+func (p *Pattern) Clone() *Pattern {
+	c := &Pattern{
+		Rows:     p.Rows,
+		Channels: p.Channels,
+		Notes:    make([][]Note, len(p.Notes)),
+	}
+	for i, row := range p.Notes {
+		c.Notes[i] = append([]Note(nil), row...)
+	}
+	return c
+}
+
 // ChannelConfig defines per-channel settings
 type ChannelConfig struct {
 	Name       string
@@ -113,6 +187,48 @@ type ChannelConfig struct {
 	EchoSource int8  // -1 = none, or channel index (0-based)
 	EchoDelay  uint8 // Delay in rows
 	EchoVolume int8  // Volume offset (negative = quieter)
+	// Filter used when the instrument has none
+	Filter     Filter
+	// Send amounts to the effect bus (0-64)
+	DelaySend  uint8
+	ReverbSend uint8
+}
+
+// BusConfig defines the send-effect bus shared by all channels
+type BusConfig struct {
+	DelayRows     uint8 // Delay time in rows (tempo-synced, 0 = off)
+	DelayFeedback uint8 // Feedback amount (0-64)
+	DelayDamping  uint8 // High-frequency damping in the feedback path (0-64)
+	DelayLevel    uint8 // Delay return level (0-64)
+	PingPong      bool  // Alternate repeats between left and right
+	ReverbSize    uint8 // Room size (0-64)
+	ReverbDamping uint8 // High-frequency damping (0-64)
+	ReverbLevel   uint8 // Reverb return level (0-64)
+}
+
+// DefaultBusConfig returns the effect bus settings for new songs
+func DefaultBusConfig() BusConfig {
+	return BusConfig{
+		DelayRows:     3,
+		DelayFeedback: 24,
+		DelayDamping:  16,
+		DelayLevel:    48,
+		PingPong:      true,
+		ReverbSize:    40,
+		ReverbDamping: 24,
+		ReverbLevel:   40,
+	}
+}
+
+// MasterConfig defines the master bus
+type MasterConfig struct {
+	Gain    uint8 // Master gain (0-128, 64 = unity)
+	Limiter bool  // Look-ahead limiter instead of the soft clipper
+}
+
+// DefaultMasterConfig returns the master bus settings for new songs
+func DefaultMasterConfig() MasterConfig {
+	return MasterConfig{Gain: 64}
 }
 
 // Song represents a complete tracker song
@@ -124,11 +240,17 @@ type Song struct {
 	SampleRate  int             // Audio sample rate
 	Channels    int             // Number of channels
 
+	// Row highlighting in the editor (0 = off)
+	RowsPerBeat    uint8
+	RowsPerMeasure uint8
+
 	Instruments []Instrument
 	Ornaments   []Ornament
 	Patterns    []*Pattern
 	Order       []uint8         // Pattern order list
 	ChanConfig  []ChannelConfig // Per-channel config
+	Bus         BusConfig       // Send-effect bus (delay, reverb)
+	Master      MasterConfig    // Master bus (gain, limiter)
 }
 
 // NewSong creates a new song with defaults
@@ -138,34 +260,24 @@ func NewSong(channels int) *Song {
 	}
 
 	s := &Song{
-		Title:      "Untitled",
-		Author:     "",
-		Speed:      6,
-		Tempo:      125,
-		SampleRate: 44100,
-		Channels:   channels,
-		ChanConfig: make([]ChannelConfig, channels),
-		Patterns:   []*Pattern{NewPattern(64, channels)},
-		Order:      []uint8{0},
+		Title:          "Untitled",
+		Author:         "",
+		Speed:          6,
+		Tempo:          125,
+		SampleRate:     44100,
+		Channels:       channels,
+		RowsPerBeat:    4,
+		RowsPerMeasure: 16,
+		ChanConfig:     make([]ChannelConfig, channels),
+		Patterns:       []*Pattern{NewPattern(64, channels)},
+		Order:          []uint8{0},
+		Bus:            DefaultBusConfig(),
+		Master:         DefaultMasterConfig(),
 	}
 
 	// Default channel config
-	gens := []Generator{GenTriangle, GenSawtooth, GenSquare, GenSquare, GenNoise, GenNoise}
-	names := []string{"Lead", "Bass", "Chord1", "Chord2", "Perc1", "Perc2"}
 	for i := range s.ChanConfig {
-		gen := GenTriangle
-		name := "CH" + string(rune('1'+i))
-		if i < len(gens) {
-			gen = gens[i]
-			name = names[i]
-		}
-		s.ChanConfig[i] = ChannelConfig{
-			Name:       name,
-			Generator:  gen,
-			Volume:     64,
-			Pan:        0,
-			EchoSource: -1,
-		}
+		s.ChanConfig[i] = DefaultChannelConfig(i)
 	}
 
 	// Default instruments
@@ -193,6 +305,31 @@ func NewSong(channels int) *Song {
 	return s
 }
 
+// Clone returns a deep copy of the song, so it can be handed to another
+// goroutine while the original keeps being edited
+func (s *Song) Clone() *Song {
+	c := *s
+
+	c.Instruments = make([]Instrument, len(s.Instruments))
+	for i, inst := range s.Instruments {
+		c.Instruments[i] = inst.Clone()
+	}
+
+	c.Ornaments = make([]Ornament, len(s.Ornaments))
+	for i, orn := range s.Ornaments {
+		c.Ornaments[i] = orn.Clone()
+	}
+
+	c.Patterns = make([]*Pattern, len(s.Patterns))
+	for i, pat := range s.Patterns {
+		c.Patterns[i] = pat.Clone()
+	}
+
+	c.Order = append([]uint8(nil), s.Order...)
+	c.ChanConfig = append([]ChannelConfig(nil), s.ChanConfig...)
+	return &c
+}
+
 // NoteToString converts a pitch to note name
 func NoteToString(pitch int8) string {
 	if pitch < 0 {
@@ -207,6 +344,29 @@ func NoteToString(pitch int8) string {
 	return notes[note] + string(rune('0'+octave))
 }
 
+// EffectChar returns the display character for an effect type
+// (0-9, A-Z: types 0x10 and up continue with G, H, I...)
+func EffectChar(typ uint8) byte {
+	const chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	if int(typ) < len(chars) {
+		return chars[typ]
+	}
+	return '?'
+}
+
+// ParseEffectChar converts an effect character back to its type
+func ParseEffectChar(c byte) (uint8, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'A' && c <= 'Z':
+		return c - 'A' + 10, true
+	case c >= 'a' && c <= 'z':
+		return c - 'a' + 10, true
+	}
+	return 0, false
+}
+
 // StringToNote converts note name to pitch
 func StringToNote(s string) int8 {
 	if len(s) < 3 || s == "---" {
@@ -0,0 +1,82 @@
+package tracker
+
+// Wavetable holds single-cycle waves for GenWavetable
+// (Game Boy / PC Engine style wave RAM)
+type Wavetable struct {
+	Depth uint8     // Bits per step (4 or 8)
+	Morph uint8     // Ticks to morph from one wave to the next (0 = no morph)
+	Waves [][]uint8 // One or more waves of 32 or 64 steps
+}
+
+// Wavetable step counts and bit depths
+var (
+	WaveLengths = []int{32, 64}
+	WaveDepths  = []uint8{4, 8}
+)
+
+// NewWavetable creates a wavetable with a single triangle wave
+func NewWavetable(length int, depth uint8) Wavetable {
+	wt := Wavetable{Depth: depth}
+	max := int(wt.MaxValue())
+	wave := make([]uint8, length)
+	for i := range wave {
+		// Triangle: 0 → max → 0
+		v := i * 2 * max / length
+		if i >= length/2 {
+			v = 2*max - v
+		}
+		if v > max {
+			v = max
+		}
+		wave[i] = uint8(v)
+	}
+	wt.Waves = [][]uint8{wave}
+	return wt
+}
+
+// MaxValue returns the highest step value for the table's bit depth
+func (wt *Wavetable) MaxValue() uint8 {
+	if wt.Depth == 8 {
+		return 255
+	}
+	return 15
+}
+
+// Length returns the number of steps per wave
+func (wt *Wavetable) Length() int {
+	if len(wt.Waves) == 0 {
+		return 0
+	}
+	return len(wt.Waves[0])
+}
+
+// Resize resamples all waves to the given number of steps
+func (wt *Wavetable) Resize(length int) {
+	for i, wave := range wt.Waves {
+		if len(wave) == length || len(wave) == 0 {
+			continue
+		}
+		resized := make([]uint8, length)
+		for j := range resized {
+			resized[j] = wave[j*len(wave)/length]
+		}
+		wt.Waves[i] = resized
+	}
+}
+
+// SetDepth changes the bit depth, rescaling all step values
+func (wt *Wavetable) SetDepth(depth uint8) {
+	from8, to8 := wt.Depth == 8, depth == 8
+	if from8 != to8 {
+		for _, wave := range wt.Waves {
+			for j, v := range wave {
+				if to8 {
+					wave[j] = v * 17 // 0x0-0xF → 0x00-0xFF
+				} else {
+					wave[j] = v >> 4
+				}
+			}
+		}
+	}
+	wt.Depth = depth
+}
@@ -0,0 +1,270 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/anthropics/abytetracker/pkg/tracker"
+)
+
+// chanField is one column of the channel editor
+type chanField struct {
+	name   string
+	width  int
+	value  func(cfg *tracker.ChannelConfig) string
+	adjust func(cfg *tracker.ChannelConfig, delta, channels int)
+}
+
+var filterTypeNames = [...]string{
+	tracker.FilterOff: "--", tracker.FilterLowPass: "LP",
+	tracker.FilterHighPass: "HP", tracker.FilterBandPass: "BP",
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "--"
+}
+
+// chanFields are the editable channel settings, in editor order
+var chanFields = []chanField{
+	{"Name", 8,
+		func(c *tracker.ChannelConfig) string { return c.Name },
+		nil},
+	{"Gen", 3,
+		func(c *tracker.ChannelConfig) string { return genName(c.Generator) },
+		func(c *tracker.ChannelConfig, d, _ int) { c.Generator = nextGenerator(c.Generator, d) }},
+	{"Vol", 3,
+		func(c *tracker.ChannelConfig) string { return fmt.Sprintf("%d", c.Volume) },
+		func(c *tracker.ChannelConfig, d, _ int) { c.Volume = uint8(clamp(int(c.Volume)+d, 0, 64)) }},
+	{"Pan", 3,
+		func(c *tracker.ChannelConfig) string { return fmt.Sprintf("%+d", c.Pan) },
+		func(c *tracker.ChannelConfig, d, _ int) { c.Pan = int8(clamp(int(c.Pan)+d, -64, 64)) }},
+	{"Mute", 4,
+		func(c *tracker.ChannelConfig) string { return onOff(c.Muted) },
+		func(c *tracker.ChannelConfig, _, _ int) { c.Muted = !c.Muted }},
+	{"Solo", 4,
+		func(c *tracker.ChannelConfig) string { return onOff(c.Solo) },
+		func(c *tracker.ChannelConfig, _, _ int) { c.Solo = !c.Solo }},
+	{"Echo", 4,
+		func(c *tracker.ChannelConfig) string {
+			if c.EchoSource < 0 {
+				return "--"
+			}
+			return fmt.Sprintf("%d", c.EchoSource+1)
+		},
+		func(c *tracker.ChannelConfig, d, channels int) {
+			c.EchoSource = int8(clamp(int(c.EchoSource)+d, -1, channels-1))
+		}},
+	{"EDly", 4,
+		func(c *tracker.ChannelConfig) string { return fmt.Sprintf("%d", c.EchoDelay) },
+		func(c *tracker.ChannelConfig, d, _ int) { c.EchoDelay = uint8(clamp(int(c.EchoDelay)+d, 0, 255)) }},
+	{"EVol", 4,
+		func(c *tracker.ChannelConfig) string { return fmt.Sprintf("%+d", c.EchoVolume) },
+		func(c *tracker.ChannelConfig, d, _ int) { c.EchoVolume = int8(clamp(int(c.EchoVolume)+d, -64, 64)) }},
+	{"Dly", 3,
+		func(c *tracker.ChannelConfig) string { return fmt.Sprintf("%d", c.DelaySend) },
+		func(c *tracker.ChannelConfig, d, _ int) { c.DelaySend = uint8(clamp(int(c.DelaySend)+d, 0, 64)) }},
+	{"Rev", 3,
+		func(c *tracker.ChannelConfig) string { return fmt.Sprintf("%d", c.ReverbSend) },
+		func(c *tracker.ChannelConfig, d, _ int) { c.ReverbSend = uint8(clamp(int(c.ReverbSend)+d, 0, 64)) }},
+	{"Filt", 4,
+		func(c *tracker.ChannelConfig) string { return filterTypeNames[c.Filter.Type%4] },
+		func(c *tracker.ChannelConfig, d, _ int) {
+			c.Filter.Type = tracker.FilterType(((int(c.Filter.Type)+d)%4 + 4) % 4)
+		}},
+	{"Cut", 3,
+		func(c *tracker.ChannelConfig) string { return fmt.Sprintf("%02X", c.Filter.Cutoff) },
+		func(c *tracker.ChannelConfig, d, _ int) {
+			c.Filter.Cutoff = uint8(clamp(int(c.Filter.Cutoff)+d, 0, 255))
+		}},
+	{"Res", 3,
+		func(c *tracker.ChannelConfig) string { return fmt.Sprintf("%02X", c.Filter.Resonance) },
+		func(c *tracker.ChannelConfig, d, _ int) {
+			c.Filter.Resonance = uint8(clamp(int(c.Filter.Resonance)+d, 0, 255))
+		}},
+	{"FEnv", 4,
+		func(c *tracker.ChannelConfig) string { return fmt.Sprintf("%+d", c.Filter.EnvAmount) },
+		func(c *tracker.ChannelConfig, d, _ int) {
+			c.Filter.EnvAmount = int8(clamp(int(c.Filter.EnvAmount)+d, -128, 127))
+		}},
+	{"FAtk", 4,
+		func(c *tracker.ChannelConfig) string { return fmt.Sprintf("%d", c.Filter.Envelope.Attack) },
+		func(c *tracker.ChannelConfig, d, _ int) {
+			c.Filter.Envelope.Attack = uint8(clamp(int(c.Filter.Envelope.Attack)+d, 0, 255))
+		}},
+	{"FDec", 4,
+		func(c *tracker.ChannelConfig) string { return fmt.Sprintf("%d", c.Filter.Envelope.Decay) },
+		func(c *tracker.ChannelConfig, d, _ int) {
+			c.Filter.Envelope.Decay = uint8(clamp(int(c.Filter.Envelope.Decay)+d, 0, 255))
+		}},
+	{"FSus", 4,
+		func(c *tracker.ChannelConfig) string { return fmt.Sprintf("%d", c.Filter.Envelope.Sustain) },
+		func(c *tracker.ChannelConfig, d, _ int) {
+			c.Filter.Envelope.Sustain = uint8(clamp(int(c.Filter.Envelope.Sustain)+d, 0, 64))
+		}},
+	{"FRel", 4,
+		func(c *tracker.ChannelConfig) string { return fmt.Sprintf("%d", c.Filter.Envelope.Release) },
+		func(c *tracker.ChannelConfig, d, _ int) {
+			c.Filter.Envelope.Release = uint8(clamp(int(c.Filter.Envelope.Release)+d, 0, 255))
+		}},
+}
+
+// handleChannelKey handles the channel editor's own keys, ahead of the
+// global bindings, and returns false for any other key
+func (m *Model) handleChannelKey(msg tea.KeyMsg) bool {
+	song := m.Song
+	m.ChanCursor = clamp(m.ChanCursor, 0, len(song.ChanConfig)-1)
+
+	switch m.Keys.EditorAction(secChannels, msg.String()) {
+	case ActChanIncrease:
+		m.adjustChannel(1)
+	case ActChanDecrease:
+		m.adjustChannel(-1)
+	case ActChanRename:
+		i := m.ChanCursor
+		m.startInput("Channel name", song.ChanConfig[i].Name, 8, func(m *Model, v string) {
+			if err := checkName(v); err != nil {
+				m.StatusMsg = "Name: " + err.Error()
+				return
+			}
+			m.Song.ChanConfig[i].Name = v
+		})
+	case ActChanNew:
+		// New channel after the cursor
+		at := m.ChanCursor + 1
+		if !song.InsertChannel(at, tracker.DefaultChannelConfig(at)) {
+			m.StatusMsg = fmt.Sprintf("Song already has %d channels", tracker.MaxChannels)
+			break
+		}
+		m.ChanCursor = at
+		if m.CursorCh >= at {
+			m.CursorCh++
+		}
+		m.edited()
+	case ActChanDelete:
+		// Keep at least one channel
+		if song.Channels > 1 {
+			song.DeleteChannel(m.ChanCursor)
+			m.StatusMsg = fmt.Sprintf("Deleted channel %d", m.ChanCursor+1)
+			if m.CursorCh > m.ChanCursor {
+				m.CursorCh--
+			}
+			m.ChanCursor = min(m.ChanCursor, song.Channels-1)
+			m.CursorCh = min(m.CursorCh, song.Channels-1)
+			m.edited()
+		}
+	default:
+		return m.channelCursorKey(msg.String())
+	}
+	return true
+}
+
+// channelCursorKey handles the channel editor's fixed cursor keys
+func (m *Model) channelCursorKey(key string) bool {
+	song := m.Song
+	switch key {
+	case "up":
+		if m.ChanCursor > 0 {
+			m.ChanCursor--
+		}
+	case "down":
+		if m.ChanCursor < len(song.ChanConfig)-1 {
+			m.ChanCursor++
+		}
+	case "left":
+		if m.ChanField > 0 {
+			m.ChanField--
+		}
+	case "right":
+		if m.ChanField < len(chanFields)-1 {
+			m.ChanField++
+		}
+	case "pgup":
+		m.adjustChannel(16)
+	case "pgdown":
+		m.adjustChannel(-16)
+	case "shift+up":
+		if m.ChanCursor > 0 {
+			m.moveChannel(m.ChanCursor, m.ChanCursor-1)
+		}
+	case "shift+down":
+		if m.ChanCursor < song.Channels-1 {
+			m.moveChannel(m.ChanCursor, m.ChanCursor+1)
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// moveChannel moves a channel, keeping the pattern cursor on the same column
+func (m *Model) moveChannel(from, to int) {
+	m.Song.MoveChannel(from, to)
+	switch m.CursorCh {
+	case from:
+		m.CursorCh = to
+	case to:
+		m.CursorCh = from
+	}
+	m.ChanCursor = to
+	m.edited()
+}
+
+// adjustChannel changes the selected channel setting by delta
+func (m *Model) adjustChannel(delta int) {
+	if m.ChanCursor >= len(m.Song.ChanConfig) {
+		return
+	}
+	if f := chanFields[m.ChanField]; f.adjust != nil {
+		f.adjust(&m.Song.ChanConfig[m.ChanCursor], delta, m.Song.Channels)
+		m.edited()
+	}
+}
+
+func (m Model) channelView() string {
+	var b strings.Builder
+	title := m.Theme.Fg(m.Theme.Title).Bold(true).Render("CHANNELS")
+	fmt.Fprintf(&b, "%s (%s to exit)\n\n", title, m.Keys.shortLabel(ActChannelView))
+
+	header := m.Theme.Fg(m.Theme.Dim)
+	selected := lipgloss.NewStyle().Reverse(true)
+
+	line := "    "
+	for _, f := range chanFields {
+		line += fmt.Sprintf(" %-*s", f.width, f.name)
+	}
+	b.WriteString(header.Render(line) + "\n")
+
+	for i := range m.Song.ChanConfig {
+		cfg := &m.Song.ChanConfig[i]
+		cursor := "  "
+		style := lipgloss.NewStyle()
+		if i == m.ChanCursor {
+			cursor = "> "
+			style = m.Theme.Fg(m.Theme.Accent).Bold(true)
+		}
+		b.WriteString(style.Render(fmt.Sprintf("%s%02d", cursor, i+1)))
+		for j, f := range chanFields {
+			value := fmt.Sprintf("%-*s", f.width, f.value(cfg))
+			if i == m.ChanCursor && j == m.ChanField {
+				value = selected.Render(value)
+			} else {
+				value = style.Render(value)
+			}
+			b.WriteString(" " + value)
+		}
+		b.WriteString("\n")
+	}
+
+	key := m.Keys.shortLabel
+	fmt.Fprintf(&b, "\n ↑↓ Channel  ←→ Setting  %s/%s PgUp/PgDn Change  %s Rename\n",
+		key(ActChanIncrease), key(ActChanDecrease), key(ActChanRename))
+	fmt.Fprintf(&b, " %s Add  %s Remove  Shift+↑↓ Move (with its pattern column)\n",
+		key(ActChanNew), key(ActChanDelete))
+	return b.String()
+}
@@ -0,0 +1,59 @@
+package tui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Config is the user's editor configuration, read from a JSON file:
+//
+//	{
+//	  "layout": "azerty",
+//	  "keys": {"quit": ["ctrl+q"], "play_row": ["enter", "ctrl+p"]},
+//	  "theme": "mine",
+//	  "themes": {"mine": {"base": "high-contrast", "note": "#ffd700"}}
+//	}
+//
+// Binding an action replaces its default keys; an empty list unbinds it.
+// Editor actions such as "ornament_arpeggio" only apply in their editor.
+// A theme under "themes" changes the colours it names in its base theme.
+type Config struct {
+	Layout   string                     `json:"layout"`    // Note key layout: qwerty, azerty or dvorak
+	NoteKeys []string                   `json:"note_keys"` // Custom note rows, overriding the layout
+	Keys     map[string][]string        `json:"keys"`      // Action name -> keys
+	Theme    string                     `json:"theme"`     // Theme name, built in or from Themes
+	Themes   map[string]json.RawMessage `json:"themes"`    // Custom themes by name
+}
+
+// DefaultConfigPath returns the config file in the user config directory
+// (for example ~/.config/abytetracker/config.json)
+func DefaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "abytetracker", "config.json")
+}
+
+// LoadConfig reads a config file. A missing file gives the defaults.
+func LoadConfig(path string) (Config, error) {
+	var cfg Config
+	if path == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,82 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/anthropics/abytetracker/pkg/audio"
+	"github.com/anthropics/abytetracker/pkg/tracker"
+)
+
+// exportDir is where exports are written, relative to the working directory
+const exportDir = "_export"
+
+// ExportFiles renders the song into the export directory, naming files
+// after filename (or the song title). With stems it writes one file per
+// channel, plus the full mix when master is set. It returns a status line.
+func ExportFiles(song *tracker.Song, filename string, opts audio.ExportOptions, stems, master bool) (string, error) {
+	baseName := "output"
+	if filename != "" {
+		baseName = strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	} else if song.Title != "" {
+		baseName = song.Title
+	}
+
+	// Create _export directory if needed
+	if err := os.MkdirAll(exportDir, 0755); err != nil {
+		return "", err
+	}
+
+	// Find the exact length by simulating the song
+	info := audio.AnalyzeSong(song)
+	if info.Samples == 0 {
+		return "", fmt.Errorf("song is empty")
+	}
+	frames, _ := audio.ExportLength(info, song.SampleRate, opts)
+	duration := float64(frames) / float64(song.SampleRate)
+
+	// Export with a separate player so live playback is not disturbed
+	player := audio.NewPlayer(song.Clone())
+	var outputPath string
+	if stems {
+		outputPath = filepath.Join(exportDir, baseName+"_*"+opts.Format.Ext())
+		open := func(name string) (io.WriteCloser, error) {
+			return os.Create(filepath.Join(exportDir, baseName+"_"+name+opts.Format.Ext()))
+		}
+		if err := audio.ExportStems(player, open, opts, master); err != nil {
+			return "", err
+		}
+	} else {
+		outputPath = filepath.Join(exportDir, baseName+opts.Format.Ext())
+		f, err := os.Create(outputPath)
+		if err != nil {
+			return "", err
+		}
+		err = audio.Export(player, f, opts)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if info.Loops {
+		return fmt.Sprintf("Exported to %s (%.1fs, loops to %02X:%02X)", outputPath, duration, info.LoopPos, info.LoopRow), nil
+	}
+	return fmt.Sprintf("Exported to %s (%.1fs)", outputPath, duration), nil
+}
+
+// exportSong exports the song with the model's export settings
+func (m *Model) exportSong(stems bool) {
+	opts := audio.ExportOptions{Format: m.ExportFormat, TargetLUFS: m.ExportLUFS, Loops: m.ExportLoops}
+	status, err := ExportFiles(m.Song, m.Filename, opts, stems, m.ExportStemMaster)
+	if err != nil {
+		m.StatusMsg = "Export failed: " + err.Error()
+		return
+	}
+	m.StatusMsg = status
+}
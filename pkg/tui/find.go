@@ -0,0 +1,105 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/anthropics/abytetracker/pkg/tracker"
+)
+
+// findLabel prompts for a search and sums up its syntax
+const findLabel = "Find (note inst vol fx, ? and * wildcards)"
+
+// startFind opens the find prompt with the last search
+func (m *Model) startFind() {
+	m.startInput(findLabel, m.Find, 20, func(m *Model, v string) {
+		if _, err := tracker.ParseQuery(v); err != nil {
+			m.StatusMsg = "Error: " + err.Error()
+			return
+		}
+		m.Find = v
+		m.findNext(1, true)
+	})
+}
+
+// startReplace asks for the replacement of the current search and
+// rewrites every match in the song as one undoable edit
+func (m *Model) startReplace() {
+	q, err := tracker.ParseQuery(m.Find)
+	if err != nil || m.Find == "" {
+		m.StatusMsg = fmt.Sprintf("Find something first (%s)", m.Keys.shortLabel(ActFind))
+		return
+	}
+	m.startInput(fmt.Sprintf("Replace %q with", q.String()), "", 20, func(m *Model, v string) {
+		r, err := tracker.ParseQuery(v)
+		if err != nil {
+			m.StatusMsg = "Error: " + err.Error()
+			return
+		}
+		before := m.Song.Clone()
+		n := m.Song.ReplaceAll(q, r)
+		if n > 0 {
+			m.pushUndo(before)
+		}
+		m.StatusMsg = fmt.Sprintf("Replaced %d cells (%s to undo)", n, m.Keys.shortLabel(ActUndo))
+	})
+}
+
+// findNext moves the cursor to the next match of the current search in
+// dir (1 or -1), following the order list and wrapping at the ends. With
+// here set, a match under the cursor counts.
+func (m *Model) findNext(dir int, here bool) {
+	q, err := tracker.ParseQuery(m.Find)
+	if err != nil || m.Find == "" {
+		m.StatusMsg = fmt.Sprintf("Find something first (%s)", m.Keys.shortLabel(ActFind))
+		return
+	}
+	hits := m.Song.Find(q)
+	if len(hits) == 0 {
+		m.StatusMsg = fmt.Sprintf("No match for %q", q.String())
+		return
+	}
+
+	// Compare hits with the cursor in playing order
+	cursor := tracker.Hit{Pos: m.EditPos, Row: m.CursorRow, Ch: m.CursorCh}
+	cmp := func(h tracker.Hit) int {
+		switch {
+		case h.Pos != cursor.Pos:
+			return h.Pos - cursor.Pos
+		case h.Row != cursor.Row:
+			return h.Row - cursor.Row
+		}
+		return h.Ch - cursor.Ch
+	}
+
+	idx := -1
+	if dir > 0 {
+		for i, h := range hits {
+			if c := cmp(h); c > 0 || (here && c == 0) {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			idx = 0
+		}
+	} else {
+		for i := len(hits) - 1; i >= 0; i-- {
+			if c := cmp(hits[i]); c < 0 || (here && c == 0) {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			idx = len(hits) - 1
+		}
+	}
+
+	h := hits[idx]
+	m.Mode = ModePattern
+	m.EditPos = h.Pos
+	m.CursorRow = h.Row
+	m.CursorCh = h.Ch
+	m.CursorCol = ColNote
+	m.ensureRowVisible()
+	m.StatusMsg = fmt.Sprintf("Match %d of %d for %q", idx+1, len(hits), q.String())
+}
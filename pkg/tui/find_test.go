@@ -0,0 +1,94 @@
+package tui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/anthropics/abytetracker/pkg/tracker"
+)
+
+// typeInput replaces the text of the open input and presses Enter
+func typeInput(t *testing.T, m *Model, text string) {
+	t.Helper()
+	if !m.Input.Active {
+		t.Fatalf("no input open (status %q)", m.StatusMsg)
+	}
+	m.Input.Value = text
+	m.handleInputKey(tea.KeyMsg{Type: tea.KeyEnter})
+}
+
+func TestFindReplaceUndo(t *testing.T) {
+	song := tracker.NewSong(2)
+	pat := song.Patterns[song.Order[0]]
+	pat.Notes[4][1] = tracker.Note{Pitch: 48, Instrument: 1, Volume: -1}
+	pat.Notes[9][0] = tracker.Note{Pitch: 50, Instrument: 1, Volume: -1}
+	m := NewModel(song, "")
+
+	m.startFind()
+	typeInput(t, &m, "* 01")
+	if m.CursorRow != 4 || m.CursorCh != 1 {
+		t.Errorf("find moved to row %d ch %d, want 4, 1", m.CursorRow, m.CursorCh)
+	}
+	m.findNext(1, false)
+	if m.CursorRow != 9 || m.CursorCh != 0 {
+		t.Errorf("find next moved to row %d ch %d, want 9, 0", m.CursorRow, m.CursorCh)
+	}
+
+	// A replacement matching nothing valid records no undo step
+	m.startReplace()
+	typeInput(t, &m, "* * 41")
+	if len(m.History.undo) != 0 {
+		t.Fatalf("replacement that changed nothing recorded %d undo steps", len(m.History.undo))
+	}
+
+	m.startReplace()
+	typeInput(t, &m, "??5 02")
+	if got := pat.Notes[4][1]; got.Pitch != 60 || got.Instrument != 2 {
+		t.Fatalf("replace gave %+v", got)
+	}
+	if len(m.History.undo) != 1 {
+		t.Fatalf("%d undo steps after replace, want 1", len(m.History.undo))
+	}
+
+	// The snapshot is a copy: later edits do not leak into it
+	pat.Notes[0][0].Pitch = 12
+	m.undo()
+	if m.Song != song {
+		t.Fatal("undo replaced the song pointer")
+	}
+	pat = song.Patterns[song.Order[0]]
+	if got := pat.Notes[4][1]; got.Pitch != 48 || got.Instrument != 1 {
+		t.Errorf("undo left %+v, want C-4 01", got)
+	}
+	if got := pat.Notes[9][0]; got.Pitch != 50 || got.Instrument != 1 {
+		t.Errorf("undo left %+v, want D-4 01", got)
+	}
+	if pat.Notes[0][0].Pitch != -1 {
+		t.Errorf("snapshot picked up a later edit: %+v", pat.Notes[0][0])
+	}
+
+	m.redo()
+	if got := song.Patterns[song.Order[0]].Notes[9][0]; got.Pitch != 62 || got.Instrument != 2 {
+		t.Errorf("redo left %+v, want D-5 02", got)
+	}
+}
+
+func TestFindErrors(t *testing.T) {
+	m := NewModel(tracker.NewSong(1), "")
+	m.startReplace()
+	if m.Input.Active || m.StatusMsg != "Find something first (Ctrl+F)" {
+		t.Errorf("replace without a search: input %v, status %q", m.Input.Active, m.StatusMsg)
+	}
+
+	m.Find = "C-4"
+	m.startFind()
+	typeInput(t, &m, "C-4 01 40 A04 x")
+	if m.Find != "C-4" || m.StatusMsg == "" {
+		t.Errorf("bad query kept as %q, status %q", m.Find, m.StatusMsg)
+	}
+	m.findNext(1, false)
+	if m.StatusMsg != `No match for "C-4"` {
+		t.Errorf("status %q", m.StatusMsg)
+	}
+}
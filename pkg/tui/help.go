@@ -0,0 +1,171 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// helpWidth is the inside width of the help box
+const helpWidth = 66
+
+// helpReference is the part of help that does not depend on the keymap
+var helpReference = []string{
+	"OSCILLATORS (set in instrument, shown in channel header)",
+	"  tri  Triangle wave       saw  Sawtooth wave",
+	"  squ  Square/pulse wave   swb  SawBig (11-bit bytebeat)",
+	"  noi  Noise               (use Kxx effect for duty cycle)",
+	"  fmo  FM (2-4 operators, [fm] section in .abt)",
+	"  wav  Wavetable (edited in the WAVE EDITOR, see above)",
+	"",
+	"EFFECTS (in effect column: Txx where T=type, xx=param)",
+	"  0xy  Arpeggio            Cxx  Set volume (00-40)",
+	"  1xx  Slide up            Fxx  Set speed (<20) or tempo (≥20)",
+	"  2xx  Slide down          Gxx  Set ornament",
+	"  4xy  Vibrato             Kxx  Set duty cycle (00-FF, 80=50%)",
+	"  Exy  Echo ch x delay y   Lxx  Filter cutoff (00-FF)",
+	"  Mxx  Filter resonance    Nxy  Cutoff sweep up x / down y",
+	"  Bxx  Jump to position xx Dxx  Next pattern from row xx",
+}
+
+// helpNotes explain sections whose keys alone do not tell the whole story
+var helpNotes = map[string][]string{
+	"EDITING": {
+		"  Block operations use the selection, else the cursor channel",
+		"  Commands: transpose ±N [iXX], interp vol|fx, replace XX YY,",
+		"  scale PCT, humanize N; end with sel, track, pattern or song",
+		"  hl BEAT [MEASURE] sets the song's row highlighting",
+	},
+	"SEARCH & UNDO": {
+		`  Queries look like cells, with wildcards: "C-? 01 * C??"`,
+	},
+	secInstrument: {
+		"  ↑↓ select, ←→ field, PgUp/PgDn change by 16, Shift+↑↓ move",
+	},
+	secWave: {
+		"  ←→ step, ↑↓ value, PgUp/PgDn max/min",
+	},
+	secOrnament: {
+		"  ←→ Home/End step, ↑↓ semitone, PgUp/PgDn octave",
+	},
+	secChannels: {
+		"  ↑↓ channel, ←→ setting, PgUp/PgDn change by 16, Shift+↑↓ move",
+	},
+	secOrder: {
+		"  ↑↓ position, 0-9 type the pattern number",
+	},
+	secPatterns: {
+		"  ↑↓ select, Shift+↑↓ move",
+	},
+	secInput: {
+		"  Backspace deletes the last character",
+	},
+}
+
+// helpView lists the active key bindings by section, two to a line
+func (m Model) helpView() string {
+	var lines []string
+	lines = append(lines, centre("ABYTETRACKER HELP"), "")
+
+	lines = append(lines, fmt.Sprintf("NOTE INPUT (%s layout)", m.Keys.Layout))
+	for i, row := range m.Keys.NoteRows() {
+		lines = append(lines, fmt.Sprintf("  %s  octave +%d", row, i))
+	}
+	lines = append(lines, "  Notes sound as typed, also in the instrument/ornament views", "")
+
+	var section string
+	var entries []string
+	flush := func() {
+		for i := 0; i < len(entries); i += 2 {
+			if i+1 < len(entries) {
+				lines = append(lines, "  "+entries[i]+" "+entries[i+1])
+			} else {
+				lines = append(lines, "  "+entries[i])
+			}
+		}
+		lines = append(lines, helpNotes[section]...)
+		lines = append(lines, "")
+		entries = nil
+	}
+	for _, b := range bindings {
+		if b.section != section {
+			if section != "" {
+				flush()
+			}
+			section = b.section
+			lines = append(lines, section)
+		}
+		entry := fmt.Sprintf("%-11s %s", m.Keys.Label(b.action), b.help)
+		entries = append(entries, fmt.Sprintf("%-*s", helpWidth/2-3, entry))
+	}
+	flush()
+
+	lines = append(lines, helpReference...)
+	lines = append(lines, "", centre(fmt.Sprintf("[%s] Close help", m.Keys.Label(ActHelp))))
+
+	var b strings.Builder
+	bar := strings.Repeat("═", helpWidth)
+	b.WriteString("\n╔" + bar + "╗\n")
+	for i, line := range lines {
+		if i == 1 {
+			b.WriteString("╠" + bar + "╣\n")
+			continue
+		}
+		pad := max(helpWidth-1-lipgloss.Width(line), 0)
+		b.WriteString("║ " + line + strings.Repeat(" ", pad) + "║\n")
+	}
+	b.WriteString("╚" + bar + "╝\n")
+	return m.Theme.Fg(m.Theme.Accent).Render(b.String())
+}
+
+// centre pads s to sit in the middle of the help box
+func centre(s string) string {
+	return strings.Repeat(" ", max((helpWidth-1-lipgloss.Width(s))/2, 0)) + s
+}
+
+// footerHint is one "[key]Name" entry in the footer
+type footerHint struct {
+	keys string
+	name string
+}
+
+func (m Model) footerView() string {
+	if m.Input.Active {
+		return m.Input.View(m.Theme, m.Keys)
+	}
+
+	key := m.Keys.shortLabel
+	var hints []footerHint
+	switch m.Mode {
+	case ModeOrder, ModePatterns:
+		hints = []footerHint{{key(ActOrderView), "Pattern"}, {key(ActInstrumentView), "Inst"}, {key(ActOrnamentView), "Orn"}}
+	case ModeInstrument:
+		hints = []footerHint{{key(ActOrderView), "Order"}, {key(ActInstrumentView), "Pattern"}, {key(ActOrnamentView), "Orn"}}
+	case ModeOrnament:
+		hints = []footerHint{{key(ActOrderView), "Order"}, {key(ActInstrumentView), "Inst"}, {key(ActOrnamentView), "Pattern"}}
+	case ModeChannels:
+		hints = []footerHint{{key(ActOrderView), "Order"}, {key(ActInstrumentView), "Inst"}, {key(ActOrnamentView), "Orn"}, {key(ActChannelView), "Pattern"}}
+	default:
+		hints = []footerHint{{key(ActOrderView), "Order"}, {key(ActInstrumentView), "Inst"}, {key(ActOrnamentView), "Orn"}}
+	}
+	hints = append(hints, footerHint{key(ActPlayStop), "Play"})
+	if m.Mode == ModePattern {
+		hints = append(hints,
+			footerHint{key(ActMute), "Mute"}, footerHint{key(ActSolo), "Solo"},
+			footerHint{key(ActExport), "Export"},
+			footerHint{key(ActNextPos) + "/" + key(ActPrevPos), "Pos"})
+	}
+	hints = append(hints, footerHint{key(ActHelp), "Help"}, footerHint{key(ActQuit), "Quit"})
+
+	var keys strings.Builder
+	for _, h := range hints {
+		fmt.Fprintf(&keys, " [%s]%s", h.keys, h.name)
+	}
+	footer := m.Theme.Fg(m.Theme.Dim).Render(keys.String())
+	if m.StatusMsg != "" {
+		status := m.Theme.Fg(m.Theme.Good).Render("\n " + m.StatusMsg)
+		footer += status
+	}
+	return footer
+}
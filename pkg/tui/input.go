@@ -0,0 +1,80 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// textInput edits one line of text (a name, a formula) in the footer.
+// While active it captures every key.
+type textInput struct {
+	Active bool
+	Label  string
+	Value  string
+	Max    int // Maximum length in characters (0 = unlimited)
+
+	apply func(m *Model, value string)
+}
+
+// startInput opens the text input with an initial value; apply is called
+// with the edited text on Enter
+func (m *Model) startInput(label, value string, max int, apply func(m *Model, value string)) {
+	m.Input = textInput{Active: true, Label: label, Value: value, Max: max, apply: apply}
+}
+
+// checkName rejects a name that cannot be saved in a column of an .abt
+// table, where "|" separates the columns
+func checkName(name string) error {
+	if strings.Contains(name, "|") {
+		return fmt.Errorf(`names cannot contain "|"`)
+	}
+	return nil
+}
+
+// handleInputKey edits the text input: the input actions apply, cancel
+// or clear it, and other keys type
+func (m *Model) handleInputKey(msg tea.KeyMsg) {
+	in := &m.Input
+	switch m.Keys.EditorAction(secInput, msg.String()) {
+	case ActInputOK:
+		in.Active = false
+		if in.apply != nil {
+			// Names, formulas and commands all go into the song
+			in.apply(m, in.Value)
+			m.edited()
+		}
+		return
+	case ActInputCancel:
+		in.Active = false
+		return
+	case ActInputClear:
+		in.Value = ""
+		return
+	}
+
+	switch msg.Type {
+	case tea.KeyBackspace:
+		if _, size := utf8.DecodeLastRuneInString(in.Value); size > 0 {
+			in.Value = in.Value[:len(in.Value)-size]
+		}
+	case tea.KeySpace, tea.KeyRunes:
+		text := string(msg.Runes)
+		if msg.Type == tea.KeySpace {
+			text = " "
+		}
+		if in.Max == 0 || utf8.RuneCountInString(in.Value+text) <= in.Max {
+			in.Value += text
+		}
+	}
+}
+
+func (t textInput) View(theme *Theme, km *Keymap) string {
+	label := theme.Fg(theme.Title).Render(" " + t.Label + ": ")
+	cursor := lipgloss.NewStyle().Reverse(true).Render(" ")
+	hint := fmt.Sprintf("  %s OK  %s Cancel", km.shortLabel(ActInputOK), km.shortLabel(ActInputCancel))
+	return label + t.Value + cursor + theme.Fg(theme.Dim).Render(hint)
+}
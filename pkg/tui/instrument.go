@@ -0,0 +1,478 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/anthropics/abytetracker/pkg/audio"
+	"github.com/anthropics/abytetracker/pkg/tracker"
+)
+
+// Instrument parameters, in editor order
+const (
+	instName = iota
+	instGenerator
+	instVolume
+	instAttack
+	instDecay
+	instSustain
+	instRelease
+	instLoop
+	instOrnament
+	instDetune
+	instDuty
+	instFormula
+	instFilter
+	instCutoff
+	instResonance
+	instFilterEnv
+	instFilterAttack
+	instFilterDecay
+	instFilterSustain
+	instFilterRelease
+	instAlgorithm
+	instOperators
+	instOperator // Selects the operator the fields after it edit
+	instOpRatio
+	instOpLevel
+	instOpFeedback
+	instOpAttack
+	instOpDecay
+	instOpSustain
+	instOpRelease
+	instFieldCount
+)
+
+var instFieldNames = [instFieldCount]string{
+	"Name", "Gen", "Vol", "Atk", "Dec", "Sus", "Rel", "Loop", "Orn", "Det", "Duty", "Formula",
+	"Filt", "Cut", "Res", "FEnv", "FAtk", "FDec", "FSus", "FRel",
+	"Alg", "Ops", "Op", "Mul", "Lvl", "Fb", "OAtk", "ODec", "OSus", "ORel",
+}
+
+// fmAlgNames show each FM algorithm's routing (operator 1 is the carrier)
+var fmAlgNames = [tracker.FMAlgCount]string{
+	tracker.FMAlgSerial:   "4>3>2>1",
+	tracker.FMAlgPairs:    "2>1+4>3",
+	tracker.FMAlgStack:    "234>1",
+	tracker.FMAlgAdditive: "1+2+3+4",
+}
+
+// fmMinOperators and fmMaxOperators bound the operators of an FM patch
+const (
+	fmMinOperators = 2
+	fmMaxOperators = 4
+)
+
+// genNames are the short generator names used in the editors
+var genNames = [tracker.GenCount]string{
+	tracker.GenTriangle: "tri", tracker.GenSawtooth: "saw",
+	tracker.GenSquare: "squ", tracker.GenSawBig: "swb", tracker.GenNoise: "noi",
+	tracker.GenSample: "sam", tracker.GenBytebeat: "bbt",
+	tracker.GenFM: "fmo", tracker.GenWavetable: "wav",
+}
+
+func genName(gen tracker.Generator) string {
+	if gen < tracker.GenCount {
+		return genNames[gen]
+	}
+	return "???"
+}
+
+// nextGenerator steps through the generators by delta, wrapping around.
+// GenSample is skipped: nothing loads samples yet, so it would only play
+// silence.
+func nextGenerator(gen tracker.Generator, delta int) tracker.Generator {
+	n := int(tracker.GenCount)
+	step := 1
+	if delta < 0 {
+		step, delta = -1, -delta
+	}
+	g := int(gen)
+	for ; delta > 0; delta-- {
+		g = ((g+step)%n + n) % n
+		if tracker.Generator(g) == tracker.GenSample {
+			g = ((g+step)%n + n) % n
+		}
+	}
+	return tracker.Generator(g)
+}
+
+// handleInstrumentKey handles the instrument editor's own keys, ahead of
+// the global bindings, and returns false for any other key
+func (m *Model) handleInstrumentKey(msg tea.KeyMsg) (tea.Cmd, bool) {
+	song := m.Song
+	if m.InstCursor >= len(song.Instruments) {
+		m.InstCursor = max(len(song.Instruments)-1, 0)
+	}
+	hasInst := len(song.Instruments) > 0
+
+	switch m.Keys.EditorAction(secInstrument, msg.String()) {
+	case ActInstIncrease:
+		m.adjustInstrument(1)
+	case ActInstDecrease:
+		m.adjustInstrument(-1)
+	case ActInstEdit:
+		// The input marks the song edited when applied
+		if !hasInst {
+			return nil, true
+		}
+		i := m.InstCursor
+		if m.InstField == instFormula {
+			m.startInput("Formula", song.Instruments[i].Formula, 0, func(m *Model, v string) {
+				if v != "" {
+					if err := audio.CheckFormula(v); err != nil {
+						m.StatusMsg = "Formula: " + err.Error()
+						return
+					}
+				}
+				m.Song.Instruments[i].Formula = v
+			})
+		} else {
+			m.startInput("Instrument name", song.Instruments[i].Name, 8, func(m *Model, v string) {
+				if err := checkName(v); err != nil {
+					m.StatusMsg = "Name: " + err.Error()
+					return
+				}
+				m.Song.Instruments[i].Name = v
+			})
+		}
+		return nil, true
+	case ActInstNew:
+		// New instrument after the cursor
+		at := min(m.InstCursor+1, len(song.Instruments))
+		if !song.InsertInstrument(at, tracker.DefaultInstrument()) {
+			m.StatusMsg = fmt.Sprintf("No instrument numbers left (01-%02X)", tracker.MaxInstruments)
+			break
+		}
+		m.InstCursor = at
+	case ActInstDuplicate:
+		// Duplicate after the cursor
+		if !hasInst {
+			break
+		}
+		if !song.InsertInstrument(m.InstCursor+1, song.Instruments[m.InstCursor].Clone()) {
+			m.StatusMsg = fmt.Sprintf("No instrument numbers left (01-%02X)", tracker.MaxInstruments)
+			break
+		}
+		m.InstCursor++
+	case ActInstDelete:
+		// Keep at least one instrument
+		if len(song.Instruments) > 1 {
+			song.DeleteInstrument(m.InstCursor)
+			m.StatusMsg = fmt.Sprintf("Deleted instrument %02d", m.InstCursor+1)
+			if m.InstCursor >= len(song.Instruments) {
+				m.InstCursor = len(song.Instruments) - 1
+			}
+		}
+	case ActWaveEditor:
+		m.openWaveEditor()
+	case ActInstGenerator:
+		// Cycle generator
+		if hasInst {
+			inst := &song.Instruments[m.InstCursor]
+			inst.Generator = nextGenerator(inst.Generator, 1)
+		}
+	default:
+		return nil, m.instrumentCursorKey(msg.String())
+	}
+	m.edited()
+	return nil, true
+}
+
+// instrumentCursorKey handles the instrument editor's fixed cursor keys
+func (m *Model) instrumentCursorKey(key string) bool {
+	song := m.Song
+	switch key {
+	case "up":
+		if m.InstCursor > 0 {
+			m.InstCursor--
+		}
+	case "down":
+		if m.InstCursor < len(song.Instruments)-1 {
+			m.InstCursor++
+		}
+	case "left":
+		if m.InstField > 0 {
+			m.InstField--
+		}
+	case "right":
+		if m.InstField < instFieldCount-1 {
+			m.InstField++
+		}
+	case "pgup":
+		m.adjustInstrument(16)
+	case "pgdown":
+		m.adjustInstrument(-16)
+	case "shift+up":
+		if m.InstCursor > 0 {
+			song.MoveInstrument(m.InstCursor, m.InstCursor-1)
+			m.InstCursor--
+			m.edited()
+		}
+	case "shift+down":
+		if m.InstCursor < len(song.Instruments)-1 {
+			song.MoveInstrument(m.InstCursor, m.InstCursor+1)
+			m.InstCursor++
+			m.edited()
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// adjustInstrument changes the selected parameter by delta, clamped to its range
+func (m *Model) adjustInstrument(delta int) {
+	if m.InstCursor >= len(m.Song.Instruments) {
+		return
+	}
+	inst := &m.Song.Instruments[m.InstCursor]
+	env := &inst.Envelope
+	filter := &inst.Filter
+	if m.InstField == instOperator {
+		// Choosing an operator is not an edit
+		m.InstOp = clamp(m.instOperator(inst)+delta, 0, len(instFMPatch(inst).Operators)-1)
+		return
+	}
+	if m.InstField >= instAlgorithm && len(inst.FM.Operators) == 0 {
+		// Editing the built-in patch starts from a copy of it
+		inst.FM = audio.DefaultFMPatch()
+	}
+	m.edited()
+
+	switch m.InstField {
+	case instGenerator:
+		inst.Generator = nextGenerator(inst.Generator, delta)
+	case instVolume:
+		inst.Volume = uint8(clamp(int(inst.Volume)+delta, 0, 64))
+	case instAttack:
+		env.Attack = uint8(clamp(int(env.Attack)+delta, 0, 255))
+	case instDecay:
+		env.Decay = uint8(clamp(int(env.Decay)+delta, 0, 255))
+	case instSustain:
+		env.Sustain = uint8(clamp(int(env.Sustain)+delta, 0, 64))
+	case instRelease:
+		env.Release = uint8(clamp(int(env.Release)+delta, 0, 255))
+	case instLoop:
+		env.Loop = !env.Loop
+	case instOrnament:
+		inst.Ornament = uint8(clamp(int(inst.Ornament)+delta, 0, len(m.Song.Ornaments)))
+	case instDetune:
+		inst.Detune = int8(clamp(int(inst.Detune)+delta, -64, 63))
+	case instDuty:
+		inst.Duty = uint8(clamp(int(inst.Duty)+delta, 0, 255))
+	case instFilter:
+		filter.Type = tracker.FilterType(((int(filter.Type)+delta)%4 + 4) % 4)
+	case instCutoff:
+		filter.Cutoff = uint8(clamp(int(filter.Cutoff)+delta, 0, 255))
+	case instResonance:
+		filter.Resonance = uint8(clamp(int(filter.Resonance)+delta, 0, 255))
+	case instFilterEnv:
+		filter.EnvAmount = int8(clamp(int(filter.EnvAmount)+delta, -128, 127))
+	case instFilterAttack:
+		filter.Envelope.Attack = uint8(clamp(int(filter.Envelope.Attack)+delta, 0, 255))
+	case instFilterDecay:
+		filter.Envelope.Decay = uint8(clamp(int(filter.Envelope.Decay)+delta, 0, 255))
+	case instFilterSustain:
+		filter.Envelope.Sustain = uint8(clamp(int(filter.Envelope.Sustain)+delta, 0, 64))
+	case instFilterRelease:
+		filter.Envelope.Release = uint8(clamp(int(filter.Envelope.Release)+delta, 0, 255))
+	case instAlgorithm:
+		n := int(tracker.FMAlgCount)
+		inst.FM.Algorithm = uint8(((int(inst.FM.Algorithm)+delta)%n + n) % n)
+	case instOperators:
+		// New operators start as a copy of the last one
+		ops := inst.FM.Operators
+		count := clamp(len(ops)+delta, fmMinOperators, fmMaxOperators)
+		for len(ops) < count {
+			ops = append(ops, ops[len(ops)-1])
+		}
+		inst.FM.Operators = ops[:count]
+		m.InstOp = min(m.InstOp, count-1)
+	default:
+		if m.InstField > instOperator {
+			m.adjustOperator(&inst.FM.Operators[m.instOperator(inst)], delta)
+		}
+	}
+}
+
+// adjustOperator changes the selected FM operator parameter by delta
+func (m *Model) adjustOperator(op *tracker.FMOperator, delta int) {
+	env := &op.Envelope
+	switch m.InstField {
+	case instOpRatio:
+		op.Ratio = uint8(clamp(int(op.Ratio)+delta, 0, 15))
+	case instOpLevel:
+		op.Level = uint8(clamp(int(op.Level)+delta, 0, 64))
+	case instOpFeedback:
+		op.Feedback = uint8(clamp(int(op.Feedback)+delta, 0, 7))
+	case instOpAttack:
+		env.Attack = uint8(clamp(int(env.Attack)+delta, 0, 255))
+	case instOpDecay:
+		env.Decay = uint8(clamp(int(env.Decay)+delta, 0, 255))
+	case instOpSustain:
+		env.Sustain = uint8(clamp(int(env.Sustain)+delta, 0, 64))
+	case instOpRelease:
+		env.Release = uint8(clamp(int(env.Release)+delta, 0, 255))
+	}
+}
+
+// instFMPatch returns the FM patch an instrument plays: its own, or the
+// built-in one when it defines no operators
+func instFMPatch(inst *tracker.Instrument) tracker.FMPatch {
+	if len(inst.FM.Operators) == 0 {
+		return audio.DefaultFMPatch()
+	}
+	return inst.FM
+}
+
+// instOperator returns the selected FM operator of inst
+func (m Model) instOperator(inst *tracker.Instrument) int {
+	return clamp(m.InstOp, 0, len(instFMPatch(inst).Operators)-1)
+}
+
+func clamp(v, lo, hi int) int {
+	return max(lo, min(v, hi))
+}
+
+func (m Model) instrumentView() string {
+	var b strings.Builder
+	title := m.Theme.Fg(m.Theme.Title).Bold(true).Render("INSTRUMENTS")
+	fmt.Fprintf(&b, "%s (%s to exit)\n\n", title, m.Keys.shortLabel(ActInstrumentView))
+
+	filterNames := map[tracker.FilterType]string{
+		tracker.FilterLowPass: "LP", tracker.FilterHighPass: "HP", tracker.FilterBandPass: "BP",
+	}
+
+	for i, inst := range m.Song.Instruments {
+		cursor := "  "
+		if i == m.InstCursor {
+			cursor = "> "
+		}
+		style := lipgloss.NewStyle()
+		if i == m.InstCursor {
+			style = m.Theme.Fg(m.Theme.Accent).Bold(true)
+		}
+
+		gen := genName(inst.Generator)
+		env := fmt.Sprintf("A%02d D%02d S%02d R%02d", inst.Envelope.Attack, inst.Envelope.Decay, inst.Envelope.Sustain, inst.Envelope.Release)
+		duty := ""
+		if inst.Generator == tracker.GenSquare && inst.Duty > 0 {
+			duty = fmt.Sprintf(" D%02X", inst.Duty)
+		}
+		if inst.Generator == tracker.GenFM && len(inst.FM.Operators) > 0 {
+			duty = fmt.Sprintf(" Alg%d %dop", inst.FM.Algorithm, len(inst.FM.Operators))
+		}
+		if inst.Generator == tracker.GenWavetable && len(inst.Wavetable.Waves) > 0 {
+			duty = fmt.Sprintf(" %dx%d", len(inst.Wavetable.Waves), inst.Wavetable.Length())
+		}
+		if inst.Filter.Type != tracker.FilterOff {
+			duty += fmt.Sprintf(" %s%02X/%02X", filterNames[inst.Filter.Type], inst.Filter.Cutoff, inst.Filter.Resonance)
+		}
+		line := fmt.Sprintf("%s%02d: %-8s %s Vol:%02d %s%s", cursor, i+1, inst.Name, gen, inst.Volume, env, duty)
+		b.WriteString(style.Render(line) + "\n")
+	}
+
+	if m.WaveEdit {
+		b.WriteString("\n" + m.waveEditorView())
+		return b.String()
+	}
+
+	if m.InstCursor < len(m.Song.Instruments) {
+		b.WriteString("\n" + m.instrumentFieldsView(&m.Song.Instruments[m.InstCursor]))
+	}
+	key := m.Keys.shortLabel
+	fmt.Fprintf(&b, "\n ↑↓ Select  ←→ Field  %s/%s PgUp/PgDn Change  %s Edit name/formula  %s Osc  %s Wave\n",
+		key(ActInstIncrease), key(ActInstDecrease), key(ActInstEdit), key(ActInstGenerator), key(ActWaveEditor))
+	fmt.Fprintf(&b, " %s New  %s Duplicate  %s Delete  Shift+↑↓ Move  Piano keys Play\n",
+		key(ActInstNew), key(ActInstDuplicate), key(ActInstDelete))
+	return b.String()
+}
+
+// instrumentFieldsView shows every parameter of an instrument, with the
+// selected one highlighted
+func (m Model) instrumentFieldsView(inst *tracker.Instrument) string {
+	loop := "off"
+	if inst.Envelope.Loop {
+		loop = "on"
+	}
+	orn := "--"
+	if inst.Ornament > 0 {
+		orn = fmt.Sprintf("%02d", inst.Ornament)
+		if int(inst.Ornament) <= len(m.Song.Ornaments) {
+			orn += " " + m.Song.Ornaments[inst.Ornament-1].Name
+		}
+	}
+	duty := "def"
+	if inst.Duty > 0 {
+		duty = fmt.Sprintf("%02X", inst.Duty)
+	}
+	formula := inst.Formula
+	if formula == "" {
+		formula = "-"
+	}
+	values := [instFieldCount]string{
+		instName:      inst.Name,
+		instGenerator: genName(inst.Generator),
+		instVolume:    fmt.Sprintf("%02d", inst.Volume),
+		instAttack:    fmt.Sprintf("%03d", inst.Envelope.Attack),
+		instDecay:     fmt.Sprintf("%03d", inst.Envelope.Decay),
+		instSustain:   fmt.Sprintf("%02d", inst.Envelope.Sustain),
+		instRelease:   fmt.Sprintf("%03d", inst.Envelope.Release),
+		instLoop:      loop,
+		instOrnament:  orn,
+		instDetune:    fmt.Sprintf("%+d", inst.Detune),
+		instDuty:      duty,
+		instFormula:   formula,
+	}
+
+	filter := &inst.Filter
+	values[instFilter] = filterTypeNames[filter.Type%4]
+	values[instCutoff] = fmt.Sprintf("%02X", filter.Cutoff)
+	values[instResonance] = fmt.Sprintf("%02X", filter.Resonance)
+	values[instFilterEnv] = fmt.Sprintf("%+d", filter.EnvAmount)
+	values[instFilterAttack] = fmt.Sprintf("%03d", filter.Envelope.Attack)
+	values[instFilterDecay] = fmt.Sprintf("%03d", filter.Envelope.Decay)
+	values[instFilterSustain] = fmt.Sprintf("%02d", filter.Envelope.Sustain)
+	values[instFilterRelease] = fmt.Sprintf("%03d", filter.Envelope.Release)
+
+	// An instrument without operators shows the built-in patch it plays
+	patch := instFMPatch(inst)
+	opIdx := m.instOperator(inst)
+	op := &patch.Operators[opIdx]
+	ratio := "0.5"
+	if op.Ratio > 0 {
+		ratio = fmt.Sprintf("%d", op.Ratio)
+	}
+	values[instAlgorithm] = fmAlgNames[patch.Algorithm%tracker.FMAlgCount]
+	values[instOperators] = fmt.Sprintf("%d", len(patch.Operators))
+	if len(inst.FM.Operators) == 0 {
+		values[instOperators] += " (def)"
+	}
+	values[instOperator] = fmt.Sprintf("%d", opIdx+1)
+	values[instOpRatio] = ratio
+	values[instOpLevel] = fmt.Sprintf("%02d", op.Level)
+	values[instOpFeedback] = fmt.Sprintf("%d", op.Feedback)
+	values[instOpAttack] = fmt.Sprintf("%03d", op.Envelope.Attack)
+	values[instOpDecay] = fmt.Sprintf("%03d", op.Envelope.Decay)
+	values[instOpSustain] = fmt.Sprintf("%02d", op.Envelope.Sustain)
+	values[instOpRelease] = fmt.Sprintf("%03d", op.Envelope.Release)
+
+	label := m.Theme.Fg(m.Theme.Dim)
+	selected := lipgloss.NewStyle().Reverse(true)
+	var b strings.Builder
+	for f := 0; f < instFieldCount; f++ {
+		if f == instOrnament || f == instFilter || f == instAlgorithm {
+			b.WriteString("\n")
+		}
+		value := values[f]
+		if f == m.InstField {
+			value = selected.Render(value)
+		}
+		b.WriteString(" " + label.Render(instFieldNames[f]+":") + value + " ")
+	}
+	return b.String() + "\n"
+}
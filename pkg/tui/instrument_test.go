@@ -0,0 +1,77 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/anthropics/abytetracker/pkg/audio"
+	"github.com/anthropics/abytetracker/pkg/tracker"
+)
+
+func TestInstrumentEditorFields(t *testing.T) {
+	// Every field after the formula edits the filter or the FM patch
+	m := NewModel(tracker.NewSong(2), "")
+	m.Mode = ModeInstrument
+	inst := &m.Song.Instruments[0]
+	for f := instFilter; f < instFieldCount; f++ {
+		m.InstField = f
+		m.adjustInstrument(1)
+	}
+	want := tracker.Filter{Type: tracker.FilterLowPass, Cutoff: 1, Resonance: 1, EnvAmount: 1,
+		Envelope: tracker.Envelope{Attack: 1, Decay: 1, Sustain: 1, Release: 1}}
+	if inst.Filter != want {
+		t.Errorf("filter = %+v, want %+v", inst.Filter, want)
+	}
+
+	// The first FM edit copies the built-in patch; the operator count adds
+	// a copy of the last operator, which the "Op" field then selects
+	def := audio.DefaultFMPatch()
+	if inst.FM.Algorithm != def.Algorithm+1 || len(inst.FM.Operators) != len(def.Operators)+1 {
+		t.Fatalf("FM patch = %+v, want algorithm %d with %d operators",
+			inst.FM, def.Algorithm+1, len(def.Operators)+1)
+	}
+	op, last := inst.FM.Operators[1], def.Operators[1]
+	if op.Ratio != last.Ratio+1 || op.Level != last.Level+1 || op.Feedback != last.Feedback+1 ||
+		op.Envelope.Attack != last.Envelope.Attack+1 || op.Envelope.Release != last.Envelope.Release+1 {
+		t.Errorf("operator 2 = %+v, want %+v raised by one", op, last)
+	}
+	if inst.FM.Operators[2] != last {
+		t.Errorf("new operator 3 = %+v, want a copy of %+v", inst.FM.Operators[2], last)
+	}
+
+	m.InstField = instOperators
+	m.adjustInstrument(-16)
+	if len(inst.FM.Operators) != fmMinOperators || m.InstOp != fmMinOperators-1 {
+		t.Errorf("%d operators with operator %d selected, want %d and %d",
+			len(inst.FM.Operators), m.InstOp+1, fmMinOperators, fmMinOperators)
+	}
+}
+
+func TestGeneratorSkipsSample(t *testing.T) {
+	for gen := tracker.Generator(0); gen < tracker.GenCount; gen++ {
+		for _, delta := range []int{1, -1, 16} {
+			if next := nextGenerator(gen, delta); next == tracker.GenSample {
+				t.Errorf("%s %+d reaches the sample generator", genName(gen), delta)
+			}
+		}
+	}
+	if got := nextGenerator(tracker.GenSample-1, 1); got != tracker.GenSample+1 {
+		t.Errorf("after %s comes %s, want %s", genName(tracker.GenSample-1), genName(got), genName(tracker.GenSample+1))
+	}
+}
+
+func TestNameRejectsColumnSeparator(t *testing.T) {
+	m := NewModel(tracker.NewSong(2), "")
+	m.Mode = ModeInstrument
+	m.InstField = instName
+	next, _ := m.handleKey(keyMsg("enter"))
+	m = next.(Model)
+	m.Input.Value = "a|b"
+	next, _ = m.handleKey(keyMsg("enter"))
+	m = next.(Model)
+	if got := m.Song.Instruments[0].Name; got == "a|b" {
+		t.Errorf("instrument renamed to %q", got)
+	}
+	if m.StatusMsg == "" {
+		t.Error("no message for the rejected name")
+	}
+}
@@ -0,0 +1,495 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Action is something a key can be bound to. Its value is the name used
+// in the config file.
+type Action string
+
+// Actions, in the order they are listed in help
+const (
+	ActUp          Action = "up"
+	ActDown        Action = "down"
+	ActLeft        Action = "left"
+	ActRight       Action = "right"
+	ActNextChannel Action = "next_channel"
+	ActPrevChannel Action = "prev_channel"
+	ActPageUp      Action = "page_up"
+	ActPageDown    Action = "page_down"
+	ActFirstRow    Action = "first_row"
+	ActLastRow     Action = "last_row"
+	ActNextPos     Action = "next_pos"
+	ActPrevPos     Action = "prev_pos"
+	ActOctaveUp    Action = "octave_up"
+	ActOctaveDown  Action = "octave_down"
+
+	ActNoteOff         Action = "note_off"
+	ActClear           Action = "clear"
+	ActSelectUp        Action = "select_up"
+	ActSelectDown      Action = "select_down"
+	ActSelectLeft      Action = "select_left"
+	ActSelectRight     Action = "select_right"
+	ActClearSelection  Action = "clear_selection"
+	ActTransposeUp     Action = "transpose_up"
+	ActTransposeDown   Action = "transpose_down"
+	ActOctaveShiftUp   Action = "transpose_octave_up"
+	ActOctaveShiftDown Action = "transpose_octave_down"
+	ActCommand         Action = "command"
+
+	ActPlayStop       Action = "play_stop"
+	ActPlayFromRow    Action = "play_from_row"
+	ActStop           Action = "stop"
+	ActPlayRow        Action = "play_row"
+	ActPlayChannelRow Action = "play_channel_row"
+	ActMute           Action = "mute"
+	ActSolo           Action = "solo"
+	ActFollow         Action = "follow"
+	ActLoopPattern    Action = "loop_pattern"
+	ActExport         Action = "export"
+	ActExportStems    Action = "export_stems"
+
+	ActFind     Action = "find"
+	ActFindNext Action = "find_next"
+	ActFindPrev Action = "find_prev"
+	ActReplace  Action = "replace"
+	ActUndo     Action = "undo"
+	ActRedo     Action = "redo"
+
+	ActOrderView      Action = "order_view"
+	ActInstrumentView Action = "instrument_view"
+	ActOrnamentView   Action = "ornament_view"
+	ActChannelView    Action = "channel_view"
+	ActHelp           Action = "help"
+	ActQuit           Action = "quit"
+
+	ActInstIncrease  Action = "instrument_increase"
+	ActInstDecrease  Action = "instrument_decrease"
+	ActInstEdit      Action = "instrument_edit"
+	ActInstNew       Action = "instrument_new"
+	ActInstDuplicate Action = "instrument_duplicate"
+	ActInstDelete    Action = "instrument_delete"
+	ActInstGenerator Action = "instrument_generator"
+	ActWaveEditor    Action = "wave_editor"
+
+	ActWaveClose    Action = "wave_close"
+	ActWavePrev     Action = "wave_prev"
+	ActWaveNext     Action = "wave_next"
+	ActWaveAdd      Action = "wave_add"
+	ActWaveDelete   Action = "wave_delete"
+	ActWaveLength   Action = "wave_length"
+	ActWaveBits     Action = "wave_bits"
+	ActWaveMorphUp  Action = "wave_morph_up"
+	ActWaveMorphDn  Action = "wave_morph_down"
+	ActWaveSine     Action = "wave_sine"
+	ActWaveTriangle Action = "wave_triangle"
+	ActWaveSaw      Action = "wave_saw"
+	ActWaveSquare   Action = "wave_square"
+
+	ActOrnPrev       Action = "ornament_prev"
+	ActOrnNext       Action = "ornament_next"
+	ActOrnNew        Action = "ornament_new"
+	ActOrnDuplicate  Action = "ornament_duplicate"
+	ActOrnDelete     Action = "ornament_delete"
+	ActOrnRename     Action = "ornament_rename"
+	ActOrnArpeggio   Action = "ornament_arpeggio"
+	ActOrnInsertStep Action = "ornament_insert_step"
+	ActOrnDeleteStep Action = "ornament_delete_step"
+	ActOrnLoop       Action = "ornament_loop"
+
+	ActChanIncrease Action = "channel_increase"
+	ActChanDecrease Action = "channel_decrease"
+	ActChanRename   Action = "channel_rename"
+	ActChanNew      Action = "channel_new"
+	ActChanDelete   Action = "channel_delete"
+
+	ActOrderInsert     Action = "order_insert"
+	ActOrderRemove     Action = "order_remove"
+	ActOrderGoto       Action = "order_goto"
+	ActOrderNewPattern Action = "order_new_pattern"
+	ActPatternList     Action = "pattern_list"
+
+	ActPatternsBack   Action = "patterns_back"
+	ActPatternsUse    Action = "patterns_use"
+	ActPatternsNew    Action = "patterns_new"
+	ActPatternsClone  Action = "patterns_clone"
+	ActPatternsDelete Action = "patterns_delete"
+	ActPatternsUnused Action = "patterns_remove_unused"
+
+	ActInputOK     Action = "input_ok"
+	ActInputCancel Action = "input_cancel"
+	ActInputClear  Action = "input_clear"
+)
+
+// Editor help sections. Their bindings apply only in that editor, which
+// looks them up before the global ones, so they may reuse global keys.
+const (
+	secInstrument = "INSTRUMENT EDITOR"
+	secWave       = "WAVE EDITOR"
+	secOrnament   = "ORNAMENT EDITOR"
+	secChannels   = "CHANNEL EDITOR"
+	secOrder      = "ORDER LIST"
+	secPatterns   = "PATTERN LIST"
+	secInput      = "TEXT INPUT"
+)
+
+// editorSections lists the editor sections; those set to true also play
+// notes, so their defaults give way to note keys like the global ones
+var editorSections = map[string]bool{
+	secInstrument: true, secWave: true, secOrnament: true,
+	secChannels: false, secOrder: false, secPatterns: false, secInput: false,
+}
+
+// binding is an action's default keys and its help entry
+type binding struct {
+	action  Action
+	keys    []string
+	help    string
+	section string
+}
+
+// bindings lists every action with its defaults, grouped by help section.
+// The defaults keep clear of the QWERTY note keys; see NewKeymap for how
+// other layouts resolve overlaps. The editors' cursor keys (arrows,
+// PgUp/PgDn, Home/End and Shift+arrows) are fixed and not listed.
+var bindings = []binding{
+	{ActUp, []string{"up"}, "Cursor up", "NAVIGATION"},
+	{ActDown, []string{"down"}, "Cursor down", "NAVIGATION"},
+	{ActLeft, []string{"left"}, "Cursor left", "NAVIGATION"},
+	{ActRight, []string{"right"}, "Cursor right", "NAVIGATION"},
+	{ActNextChannel, []string{"tab"}, "Next channel", "NAVIGATION"},
+	{ActPrevChannel, []string{"shift+tab"}, "Previous channel", "NAVIGATION"},
+	{ActPageUp, []string{"pgup"}, "Up 16 rows", "NAVIGATION"},
+	{ActPageDown, []string{"pgdown"}, "Down 16 rows", "NAVIGATION"},
+	{ActFirstRow, []string{"home"}, "First row", "NAVIGATION"},
+	{ActLastRow, []string{"end"}, "Last row", "NAVIGATION"},
+	{ActNextPos, []string{"+", "="}, "Next position", "NAVIGATION"},
+	{ActPrevPos, []string{"-", "_"}, "Previous position", "NAVIGATION"},
+	{ActOctaveUp, []string{"*"}, "Octave up", "NAVIGATION"},
+	{ActOctaveDown, []string{"/"}, "Octave down", "NAVIGATION"},
+
+	{ActNoteOff, []string{".", "1"}, "Note off", "EDITING"},
+	{ActClear, []string{"delete", "backspace"}, "Clear cell", "EDITING"},
+	{ActSelectUp, []string{"shift+up"}, "Select up", "EDITING"},
+	{ActSelectDown, []string{"shift+down"}, "Select down", "EDITING"},
+	{ActSelectLeft, []string{"shift+left"}, "Select left", "EDITING"},
+	{ActSelectRight, []string{"shift+right"}, "Select right", "EDITING"},
+	{ActClearSelection, []string{"esc"}, "Clear selection", "EDITING"},
+	{ActTransposeUp, []string{"ctrl+up"}, "Transpose +1", "EDITING"},
+	{ActTransposeDown, []string{"ctrl+down"}, "Transpose -1", "EDITING"},
+	{ActOctaveShiftUp, []string{"ctrl+pgup"}, "Transpose +12", "EDITING"},
+	{ActOctaveShiftDown, []string{"ctrl+pgdown"}, "Transpose -12", "EDITING"},
+	{ActCommand, []string{":"}, "Command line", "EDITING"},
+
+	{ActPlayStop, []string{" "}, "Play/stop", "PLAYBACK & EXPORT"},
+	{ActPlayFromRow, []string{"f5"}, "Play from row", "PLAYBACK & EXPORT"},
+	{ActStop, []string{"f8"}, "Stop", "PLAYBACK & EXPORT"},
+	{ActPlayRow, []string{"enter"}, "Play row, go down", "PLAYBACK & EXPORT"},
+	{ActPlayChannelRow, []string{"'", "alt+enter"}, "Play channel only", "PLAYBACK & EXPORT"},
+	{ActMute, []string{"f6"}, "Mute channel", "PLAYBACK & EXPORT"},
+	{ActSolo, []string{"f7"}, "Solo channel", "PLAYBACK & EXPORT"},
+	{ActFollow, []string{"f11"}, "Follow playback", "PLAYBACK & EXPORT"},
+	{ActLoopPattern, []string{"f12"}, "Loop pattern", "PLAYBACK & EXPORT"},
+	{ActExport, []string{"f9"}, "Export audio", "PLAYBACK & EXPORT"},
+	{ActExportStems, []string{"shift+f9"}, "Export stems", "PLAYBACK & EXPORT"},
+
+	{ActFind, []string{"ctrl+f"}, "Find", "SEARCH & UNDO"},
+	{ActFindNext, []string{"ctrl+g"}, "Next match", "SEARCH & UNDO"},
+	{ActFindPrev, []string{"alt+g"}, "Previous match", "SEARCH & UNDO"},
+	{ActReplace, []string{"ctrl+r"}, "Replace all", "SEARCH & UNDO"},
+	{ActUndo, []string{"ctrl+z"}, "Undo", "SEARCH & UNDO"},
+	{ActRedo, []string{"ctrl+y"}, "Redo", "SEARCH & UNDO"},
+
+	{ActOrderView, []string{"f2"}, "Order list", "VIEWS"},
+	{ActInstrumentView, []string{"f3"}, "Instruments", "VIEWS"},
+	{ActOrnamentView, []string{"f4"}, "Ornaments", "VIEWS"},
+	{ActChannelView, []string{"f10"}, "Channel settings", "VIEWS"},
+	{ActHelp, []string{"f1"}, "Help", "VIEWS"},
+	{ActQuit, []string{"ctrl+c", "ctrl+q"}, "Quit", "VIEWS"},
+
+	{ActInstIncrease, []string{"+", "="}, "Increase value", secInstrument},
+	{ActInstDecrease, []string{"-", "_"}, "Decrease value", secInstrument},
+	{ActInstEdit, []string{"enter"}, "Edit name/formula", secInstrument},
+	{ActInstNew, []string{"insert", "ctrl+n"}, "New instrument", secInstrument},
+	{ActInstDuplicate, []string{"ctrl+d"}, "Duplicate", secInstrument},
+	{ActInstDelete, []string{"delete"}, "Delete", secInstrument},
+	{ActInstGenerator, []string{"ctrl+o"}, "Next generator", secInstrument},
+	{ActWaveEditor, []string{"ctrl+w"}, "Wave editor", secInstrument},
+
+	{ActWaveClose, []string{"esc", "ctrl+w"}, "Close", secWave},
+	{ActWavePrev, []string{"["}, "Previous wave", secWave},
+	{ActWaveNext, []string{"]"}, "Next wave", secWave},
+	{ActWaveAdd, []string{"insert", "a"}, "Add wave", secWave},
+	{ActWaveDelete, []string{"delete"}, "Delete wave", secWave},
+	{ActWaveLength, []string{"ctrl+l"}, "32/64 steps", secWave},
+	{ActWaveBits, []string{"ctrl+b"}, "4/8 bits", secWave},
+	{ActWaveMorphUp, []string{">"}, "Morph slower", secWave},
+	{ActWaveMorphDn, []string{"<"}, "Morph faster", secWave},
+	{ActWaveSine, []string{"alt+1"}, "Sine", secWave},
+	{ActWaveTriangle, []string{"alt+2"}, "Triangle", secWave},
+	{ActWaveSaw, []string{"alt+3"}, "Saw", secWave},
+	{ActWaveSquare, []string{"alt+4"}, "Square", secWave},
+
+	{ActOrnPrev, []string{"["}, "Previous ornament", secOrnament},
+	{ActOrnNext, []string{"]"}, "Next ornament", secOrnament},
+	{ActOrnNew, []string{"insert", "ctrl+n"}, "New ornament", secOrnament},
+	{ActOrnDuplicate, []string{"ctrl+d"}, "Duplicate", secOrnament},
+	{ActOrnDelete, []string{"delete"}, "Delete", secOrnament},
+	{ActOrnRename, []string{"enter"}, "Rename", secOrnament},
+	{ActOrnArpeggio, []string{"a", "ctrl+a"}, "Arpeggio", secOrnament},
+	{ActOrnInsertStep, []string{"+", "="}, "Insert step", secOrnament},
+	{ActOrnDeleteStep, []string{"-", "_"}, "Delete step", secOrnament},
+	{ActOrnLoop, []string{"l", "ctrl+l"}, "Loop here", secOrnament},
+
+	{ActChanIncrease, []string{"+", "="}, "Increase value", secChannels},
+	{ActChanDecrease, []string{"-", "_"}, "Decrease value", secChannels},
+	{ActChanRename, []string{"enter"}, "Rename", secChannels},
+	{ActChanNew, []string{"insert", "ctrl+n"}, "New channel", secChannels},
+	{ActChanDelete, []string{"delete"}, "Delete", secChannels},
+
+	{ActOrderInsert, []string{"+", "="}, "Insert position", secOrder},
+	{ActOrderRemove, []string{"-", "_"}, "Remove position", secOrder},
+	{ActOrderGoto, []string{"enter"}, "Edit position", secOrder},
+	{ActOrderNewPattern, []string{"n"}, "New pattern here", secOrder},
+	{ActPatternList, []string{"p"}, "Pattern list", secOrder},
+
+	{ActPatternsBack, []string{"p", "esc"}, "Back to order", secPatterns},
+	{ActPatternsUse, []string{"enter"}, "Use at position", secPatterns},
+	{ActPatternsNew, []string{"insert", "n"}, "New pattern", secPatterns},
+	{ActPatternsClone, []string{"c", "ctrl+d"}, "Clone", secPatterns},
+	{ActPatternsDelete, []string{"delete"}, "Delete", secPatterns},
+	{ActPatternsUnused, []string{"u"}, "Remove unused", secPatterns},
+
+	{ActInputOK, []string{"enter"}, "Apply", secInput},
+	{ActInputCancel, []string{"esc", "ctrl+c"}, "Cancel", secInput},
+	{ActInputClear, []string{"ctrl+u"}, "Clear text", secInput},
+}
+
+// Note key layouts: each row plays semitones upward from C, the first row
+// in the current octave and the second an octave up. Rows follow the same
+// physical keys on every layout.
+var noteLayouts = map[string][]string{
+	"qwerty": {"zsxdcvgbhnjm", "q2w3er5t6y7ui9o0p"},
+	"azerty": {"wsxdcvgbhnj,", "aéz\"er(t-yèuiçoàp"},
+	"dvorak": {";oqejkixdbhm", "'2,3.p5y6f7gc9r0l"},
+}
+
+// Keymap maps keys to actions and notes. Keys are written the way
+// bubbletea names them: "ctrl+f", "shift+up", "f5", " " (or "space") and
+// single characters.
+type Keymap struct {
+	Layout  string
+	rows    []string
+	actions map[string]map[string]Action // Section ("" = global) -> key -> action
+	keys    map[Action][]string
+	notes   map[string]int
+	unbound []Action
+}
+
+// DefaultKeymap returns the default bindings with the QWERTY note layout
+func DefaultKeymap() *Keymap {
+	km, _ := NewKeymap(Config{})
+	return km
+}
+
+// NewKeymap builds a keymap from the config. Keys bound in the config win
+// over note keys, and note keys win over default bindings, so a layout
+// never loses a note to a default it happens to share a key with. Editor
+// bindings are kept per editor: a key may be bound once in each editor
+// and once globally, and only global config keys displace notes.
+func NewKeymap(cfg Config) (*Keymap, error) {
+	layout := strings.ToLower(cfg.Layout)
+	if layout == "" {
+		layout = "qwerty"
+	}
+	rows := cfg.NoteKeys
+	if len(rows) == 0 {
+		var ok bool
+		if rows, ok = noteLayouts[layout]; !ok {
+			return nil, fmt.Errorf("unknown keyboard layout %q (qwerty, azerty, dvorak)", cfg.Layout)
+		}
+	} else {
+		layout = "custom"
+	}
+
+	km := &Keymap{
+		Layout:  layout,
+		rows:    rows,
+		actions: make(map[string]map[string]Action),
+		keys:    make(map[Action][]string),
+		notes:   make(map[string]int),
+	}
+
+	// Keys taken by the config, by section
+	scope := make(map[Action]string)
+	for _, b := range bindings {
+		scope[b.action] = bindingScope(b.section)
+	}
+	custom := make(map[string]map[string]Action)
+	names := make([]string, 0, len(cfg.Keys))
+	for name := range cfg.Keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		act := Action(name)
+		sec, known := scope[act]
+		if !known {
+			return nil, fmt.Errorf("unknown action %q in key bindings", name)
+		}
+		if custom[sec] == nil {
+			custom[sec] = make(map[string]Action)
+		}
+		for _, key := range cfg.Keys[name] {
+			key = normalizeKey(key)
+			if prev, dup := custom[sec][key]; dup && prev != act {
+				return nil, fmt.Errorf("key %q is bound to both %s and %s", key, prev, act)
+			}
+			custom[sec][key] = act
+		}
+	}
+
+	for octave, row := range rows {
+		semitone := 0
+		for _, r := range row {
+			if _, taken := custom[""][string(r)]; !taken {
+				km.notes[string(r)] = octave*12 + semitone
+			}
+			semitone++
+		}
+	}
+
+	for _, b := range bindings {
+		sec := scope[b.action]
+		keys, set := cfg.Keys[string(b.action)]
+		if !set {
+			keys = b.keys
+		}
+		for _, key := range keys {
+			key = normalizeKey(key)
+			if !set {
+				if _, taken := custom[sec][key]; taken {
+					continue
+				}
+				if _, note := km.notes[key]; note && (sec == "" || editorSections[sec]) {
+					continue
+				}
+			}
+			if km.actions[sec] == nil {
+				km.actions[sec] = make(map[string]Action)
+			}
+			km.actions[sec][key] = b.action
+			km.keys[b.action] = append(km.keys[b.action], key)
+		}
+		if !set && len(km.keys[b.action]) == 0 {
+			km.unbound = append(km.unbound, b.action)
+		}
+	}
+	return km, nil
+}
+
+// Unbound returns the actions that lost every default key to note keys
+// or config bindings, in help order. Actions unbound in the config on
+// purpose are not included.
+func (km *Keymap) Unbound() []Action {
+	return km.unbound
+}
+
+// bindingScope returns the editor a help section's bindings apply in,
+// or "" for the global ones
+func bindingScope(section string) string {
+	if _, ok := editorSections[section]; ok {
+		return section
+	}
+	return ""
+}
+
+// normalizeKey accepts "space" for the space bar
+func normalizeKey(key string) string {
+	if key == "space" {
+		return " "
+	}
+	return key
+}
+
+// Action returns the global action bound to a key, or "" if none
+func (km *Keymap) Action(key string) Action {
+	return km.actions[""][key]
+}
+
+// EditorAction returns the action a key is bound to in an editor section
+// (secInstrument...), or "" if none
+func (km *Keymap) EditorAction(section, key string) Action {
+	return km.actions[section][key]
+}
+
+// Note returns the pitch a key plays in octave, or -1 if it is not a
+// note key
+func (km *Keymap) Note(key string, octave int) int8 {
+	if n, ok := km.notes[key]; ok {
+		return int8(min(octave*12+n, 95))
+	}
+	return -1
+}
+
+// Label returns the keys bound to an action for display, such as
+// "Ctrl+F" or "Del/Bksp"
+func (km *Keymap) Label(act Action) string {
+	keys := km.keys[act]
+	if len(keys) == 0 {
+		return "-"
+	}
+	labels := make([]string, len(keys))
+	for i, k := range keys {
+		labels[i] = keyLabel(k)
+	}
+	return strings.Join(labels, "/")
+}
+
+var keyLabels = map[string]string{
+	"up": "↑", "down": "↓", "left": "←", "right": "→",
+	"pgup": "PgUp", "pgdown": "PgDn", "home": "Home", "end": "End",
+	"delete": "Del", "backspace": "Bksp", "insert": "Ins",
+	"enter": "Enter", "esc": "Esc", "tab": "Tab", " ": "Space",
+}
+
+// keyLabel formats a bubbletea key name for help text
+func keyLabel(key string) string {
+	if l, ok := keyLabels[key]; ok {
+		return l
+	}
+	if utf8.RuneCountInString(key) == 1 {
+		return strings.ToUpper(key)
+	}
+	// Modifiers and function keys: "ctrl+pgup" -> "Ctrl+PgUp"
+	parts := strings.Split(key, "+")
+	for i, p := range parts {
+		if l, ok := keyLabels[p]; ok {
+			parts[i] = l
+		} else {
+			parts[i] = strings.ToUpper(p[:1]) + p[1:]
+		}
+	}
+	return strings.Join(parts, "+")
+}
+
+// NoteRows returns the note key rows for help, upper-cased
+func (km *Keymap) NoteRows() []string {
+	rows := make([]string, len(km.rows))
+	for i, row := range km.rows {
+		rows[i] = strings.ToUpper(strings.Join(strings.Split(row, ""), " "))
+	}
+	return rows
+}
+
+// shortLabel returns the first key bound to an action, for the footer
+func (km *Keymap) shortLabel(act Action) string {
+	if keys := km.keys[act]; len(keys) > 0 {
+		return keyLabel(keys[0])
+	}
+	return "-"
+}
@@ -0,0 +1,199 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/anthropics/abytetracker/pkg/tracker"
+)
+
+func TestKeymapPrecedence(t *testing.T) {
+	// Config bindings beat note keys, and note keys beat defaults
+	km, err := NewKeymap(Config{
+		Layout: "dvorak",
+		Keys:   map[string][]string{"quit": {"q"}, "play_row": {"space"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key    string
+		action Action
+		note   int8
+	}{
+		{"q", ActQuit, -1},    // Bound in the config over Dvorak's D
+		{".", "", 48 + 16},    // Dvorak E; the note-off default gives way
+		{"1", ActNoteOff, -1}, // The other note-off default stays
+		{" ", ActPlayRow, -1}, // "space" is the space bar
+		{"f1", ActHelp, -1},   // Untouched defaults remain
+		{";", "", 48},         // C on the lower row
+		{"z", "", -1},         // QWERTY keys are not notes on Dvorak
+	}
+	for _, tt := range tests {
+		if got := km.Action(tt.key); got != tt.action {
+			t.Errorf("Action(%q) = %q, want %q", tt.key, got, tt.action)
+		}
+		if got := km.Note(tt.key, 4); got != tt.note {
+			t.Errorf("Note(%q) = %d, want %d", tt.key, got, tt.note)
+		}
+	}
+	if km.Label(ActPlayStop) != "-" {
+		t.Errorf("play_stop still bound to %s after space was rebound", km.Label(ActPlayStop))
+	}
+
+	if _, err := NewKeymap(Config{Keys: map[string][]string{"quit": {"x"}, "help": {"x"}}}); err == nil {
+		t.Error("key bound to two actions accepted")
+	}
+	if _, err := NewKeymap(Config{Keys: map[string][]string{"fly": {"x"}}}); err == nil {
+		t.Error("unknown action accepted")
+	}
+}
+
+func TestEditorKeymap(t *testing.T) {
+	// Editors that play notes give their defaults up to note keys; the
+	// others keep them, and editor keys may repeat global ones
+	azerty, err := NewKeymap(Config{Layout: "azerty"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dvorak, err := NewKeymap(Config{Layout: "dvorak"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	qwerty := DefaultKeymap()
+
+	tests := []struct {
+		km      *Keymap
+		section string
+		key     string
+		action  Action
+	}{
+		{azerty, secOrnament, "a", ""},                  // AZERTY C#
+		{azerty, secOrnament, "ctrl+a", ActOrnArpeggio}, // The other default stays
+		{azerty, secWave, "a", ""},
+		{azerty, secWave, "insert", ActWaveAdd},
+		{qwerty, secOrnament, "a", ActOrnArpeggio},
+		{qwerty, secWave, "a", ActWaveAdd},
+		{dvorak, secOrnament, "l", ""}, // Dvorak F
+		{dvorak, secOrnament, "ctrl+l", ActOrnLoop},
+		{dvorak, secOrder, "p", ActPatternList}, // No notes in the order list
+		{qwerty, secPatterns, "u", ActPatternsUnused},
+		{qwerty, secInstrument, "delete", ActInstDelete},
+		{qwerty, secInstrument, "ctrl+w", ActWaveEditor},
+		{qwerty, secInstrument, "[", ""}, // Bound in other editors only
+	}
+	for _, tt := range tests {
+		if got := tt.km.EditorAction(tt.section, tt.key); got != tt.action {
+			t.Errorf("%s: EditorAction(%s, %q) = %q, want %q", tt.km.Layout, tt.section, tt.key, got, tt.action)
+		}
+	}
+	if qwerty.Action("delete") != ActClear {
+		t.Error("an editor binding displaced the global clear")
+	}
+
+	// Editor keys in the config are checked per editor and leave notes alone
+	km, err := NewKeymap(Config{Keys: map[string][]string{
+		"ornament_arpeggio": {"x"}, "patterns_new": {"x"}, "help": {"f2"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if km.EditorAction(secOrnament, "x") != ActOrnArpeggio || km.EditorAction(secPatterns, "x") != ActPatternsNew {
+		t.Error("a key bound in two editors did not reach both")
+	}
+	if km.EditorAction(secOrnament, "a") != "" || km.Label(ActOrnArpeggio) != "X" {
+		t.Errorf("arpeggio bound to %s, want only X", km.Label(ActOrnArpeggio))
+	}
+	if km.Note("x", 4) < 0 {
+		t.Error("an editor binding took a note key away from the pattern editor")
+	}
+	if _, err := NewKeymap(Config{Keys: map[string][]string{"ornament_prev": {"x"}, "ornament_next": {"x"}}}); err == nil {
+		t.Error("key bound to two actions of one editor accepted")
+	}
+}
+
+func TestOrnamentEditorNoteKeys(t *testing.T) {
+	// On AZERTY, A plays C# in the ornament editor; on QWERTY it asks
+	// for an arpeggio
+	for _, layout := range []string{"azerty", "qwerty"} {
+		m := NewModel(tracker.NewSong(2), "")
+		km, err := NewKeymap(Config{Layout: layout})
+		if err != nil {
+			t.Fatal(err)
+		}
+		m.Keys = km
+		m.Mode = ModeOrnament
+		next, _ := m.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+		m = next.(Model)
+		if want := layout == "qwerty"; m.Input.Active != want {
+			t.Errorf("%s: arpeggio input open = %v, want %v", layout, m.Input.Active, want)
+		}
+	}
+}
+
+func TestEveryActionBound(t *testing.T) {
+	for layout := range noteLayouts {
+		km, err := NewKeymap(Config{Layout: layout})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, b := range bindings {
+			if km.Label(b.action) == "-" {
+				t.Errorf("%s: %s has no key", layout, b.action)
+			}
+		}
+		if len(km.Unbound()) > 0 {
+			t.Errorf("%s: Unbound() = %v", layout, km.Unbound())
+		}
+	}
+
+	// Actions losing their keys to custom note rows are reported; ones
+	// unbound in the config are not
+	km, err := NewKeymap(Config{NoteKeys: []string{"*z"}, Keys: map[string][]string{"help": {}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := km.Unbound(); len(got) != 1 || got[0] != ActOctaveUp {
+		t.Errorf("Unbound() = %v, want [octave_up]", got)
+	}
+}
+
+func TestHintsFollowKeymap(t *testing.T) {
+	km, err := NewKeymap(Config{Keys: map[string][]string{
+		"order_view": {"alt+o"}, "patterns_back": {"backspace"}, "input_ok": {"tab"}, "find": {"alt+f"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewModel(tracker.NewSong(2), "")
+	m.Keys = km
+
+	for _, tt := range []struct{ view, want string }{
+		{m.orderView(), "(Alt+O to exit)"},
+		{m.patternListView(), "(Bksp back to order, Alt+O to exit)"},
+	} {
+		if !strings.Contains(tt.view, tt.want) {
+			t.Errorf("view lacks %q:\n%s", tt.want, tt.view)
+		}
+	}
+
+	m.findNext(1, false)
+	if m.StatusMsg != "Find something first (Alt+F)" {
+		t.Errorf("status %q", m.StatusMsg)
+	}
+	m.startInput("Name", "", 8, nil)
+	if view := m.footerView(); !strings.Contains(view, "Tab OK  Esc Cancel") {
+		t.Errorf("input hint: %q", view)
+	}
+	m.handleInputKey(tea.KeyMsg{Type: tea.KeyEnter})
+	if !m.Input.Active {
+		t.Error("Enter still applies the input after input_ok was rebound")
+	}
+	m.handleInputKey(tea.KeyMsg{Type: tea.KeyTab})
+	if m.Input.Active {
+		t.Error("Tab did not apply the input")
+	}
+}
@@ -3,8 +3,6 @@ package tui
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
 	"strings"
 	"time"
 
@@ -23,6 +21,8 @@ const (
 	ModeInstrument
 	ModeOrnament
 	ModeOrder
+	ModeChannels
+	ModePatterns
 )
 
 // Column within a cell
@@ -40,7 +40,7 @@ const (
 type Model struct {
 	Song   *tracker.Song
 	Player *audio.Player
-	Audio  *audio.RealtimeOutput
+	Audio  audio.AudioSink // Drives playback; nil falls back to AdvanceTime
 
 	// View state
 	Width       int
@@ -55,44 +55,86 @@ type Model struct {
 	ViewRow     int  // Top visible row
 	EditPos     int  // Current position in order list
 	Octave      int  // Current input octave
+	Selecting   bool // Block selection active, from SelRow/SelCh to the cursor
+	SelRow      int
+	SelCh       int
 
 	// Editor cursors for other modes
 	OrderCursor int  // Selected position in order editor
 	InstCursor  int  // Selected instrument
+	InstField   int  // Selected parameter in the instrument editor
+	InstOp      int  // Selected FM operator in the instrument editor
 	OrnCursor   int  // Selected ornament
+	OrnStep     int  // Selected step in the ornament editor
+	ChanCursor  int  // Selected channel in the channel editor
+	ChanField   int  // Selected setting in the channel editor
+	PatCursor   int  // Selected pattern in the pattern list
+
+	// Wave editor state (instrument view)
+	WaveEdit    bool // Wave editor open for the selected instrument
+	WaveIdx     int  // Selected wave within the wavetable
+	WaveCursor  int  // Selected step within the wave
+
+	// Note preview: typed notes sound until released after PreviewHold
+	PreviewHold time.Duration
+	previewKey  int8 // Note sounding on the preview voice (-1 = none)
+	previewSeq  int  // Bumped per press; stale release timers are ignored
 
 	// Playback display
 	PlayPos     int
 	PlayPat     int
 	PlayRow     int
 	Playing     bool
+	Meters      audio.MeterReading
+	Follow      bool // Cursor follows playback
+	LoopPattern bool // Playback repeats the pattern at EditPos
 
 	// Status message
 	StatusMsg   string
 
+	// Text entry (names, formulas), shown in the footer
+	Input       textInput
+
+	// Key bindings, note layout and colours
+	Keys        *Keymap
+	Theme       *Theme
+
+	// Search and undo
+	Find        string  // Last find query, as typed
+	History     history // Snapshots for undo/redo of bulk edits
+
+	// The song changed since the player's last snapshot
+	dirty bool
+
 	// File info
 	Filename    string
+
+	// Export settings
+	ExportFormat audio.Format // File format for F9 exports
+	ExportStemMaster bool     // Write the full mix alongside stems
+	ExportLUFS  float64 // Normalize exports to this loudness (0 = off)
+	ExportLoops int     // Extra passes through the loop for looping songs
 }
 
 // NewModel creates a new TUI model
 func NewModel(song *tracker.Song, filename string) Model {
-	player := audio.NewPlayer(song)
-
-	// Initialize real-time audio
-	rtAudio, err := audio.NewRealtimeOutput(player)
-	if err != nil {
-		// Audio init failed - continue without sound
-		rtAudio = nil
-	}
+	// The player gets its own copy; edits are handed over with UpdateSong.
+	// The caller attaches an audio sink to Audio.
+	player := audio.NewPlayer(song.Clone())
 
 	return Model{
 		Song:     song,
 		Player:   player,
-		Audio:    rtAudio,
 		Filename: filename,
 		Octave:   4,
 		Width:    120,
 		Height:   30,
+
+		PreviewHold: 500 * time.Millisecond,
+		previewKey:  -1,
+		Follow:      true,
+		Keys:        DefaultKeymap(),
+		Theme:       DefaultTheme(),
 	}
 }
 
@@ -107,6 +149,9 @@ func (m Model) Init() tea.Cmd {
 // tickMsg is sent periodically for playback updates
 type tickMsg struct{}
 
+// previewOffMsg releases the preview note started by press seq
+type previewOffMsg struct{ seq int }
+
 func tickCmd() tea.Cmd {
 	return tea.Tick(16_666_666, func(_ time.Time) tea.Msg {
 		return tickMsg{}
@@ -122,7 +167,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tickMsg:
-		// If no real-time audio, use simulation
+		// Without a sink, advance playback on the wall clock
 		if m.Audio == nil {
 			m.Player.AdvanceTime()
 		}
@@ -133,17 +178,32 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.PlayPat = pat
 		m.PlayRow = row
 		m.Playing = playing
+		m.Meters = m.Player.Meters()
 
-		// Follow playback - move cursor and switch patterns
-		if playing {
+		// Follow playback - move cursor and switch patterns. A row
+		// audition leaves the cursor where it was.
+		if playing && m.Follow && !m.Player.RowPreview() {
 			m.EditPos = pos
 			m.CursorRow = row
 			m.ensureRowVisible()
 		}
 		return m, tickCmd()
 
+	case previewOffMsg:
+		if msg.seq == m.previewSeq {
+			m.Player.PreviewRelease()
+			m.previewKey = -1
+		}
+		return m, nil
+
 	case tea.KeyMsg:
-		return m.handleKey(msg)
+		model, cmd := m.handleKey(msg)
+		// The audio thread plays its own copy; hand it the edited song
+		if mm, ok := model.(Model); ok && mm.dirty {
+			mm.syncPlayer()
+			return mm, cmd
+		}
+		return model, cmd
 	}
 
 	return m, nil
@@ -151,7 +211,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 
 func (m *Model) ensureRowVisible() {
-	visibleRows := m.Height - 12
+	visibleRows := m.Height - 13
 	if visibleRows < 8 {
 		visibleRows = 8
 	}
@@ -165,40 +225,108 @@ func (m *Model) ensureRowVisible() {
 }
 
 func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.String() {
-	case "ctrl+c", "q":
+	// Text entry captures every key
+	if m.Input.Active {
+		m.handleInputKey(msg)
+		return m, nil
+	}
+
+	// The wave editor captures navigation keys while open
+	if m.Mode == ModeInstrument && m.WaveEdit {
+		if handled := m.handleWaveKey(msg); handled {
+			return m, nil
+		}
+	}
+
+	// The instrument and ornament editors take their keys before the
+	// global bindings
+	if m.Mode == ModeInstrument && !m.WaveEdit {
+		if cmd, handled := m.handleInstrumentKey(msg); handled {
+			return m, cmd
+		}
+	}
+	if m.Mode == ModeOrnament {
+		if handled := m.handleOrnamentKey(msg); handled {
+			return m, nil
+		}
+	}
+	if m.Mode == ModeChannels {
+		if handled := m.handleChannelKey(msg); handled {
+			return m, nil
+		}
+	}
+	if m.Mode == ModeOrder {
+		if handled := m.handleOrderKey(msg); handled {
+			return m, nil
+		}
+	}
+	if m.Mode == ModePatterns {
+		if handled := m.handlePatternListKey(msg); handled {
+			return m, nil
+		}
+	}
+
+	switch m.Keys.Action(msg.String()) {
+	case ActQuit:
 		m.Player.Stop()
 		if m.Audio != nil {
 			m.Audio.Close()
 		}
 		return m, tea.Quit
 
-	case "f1", "h":
+	case ActHelp:
 		m.ShowHelp = !m.ShowHelp
 
-	case "f2":
-		if m.Mode == ModeOrder {
+	// Search and undo
+	case ActFind:
+		m.startFind()
+
+	case ActFindNext:
+		m.findNext(1, false)
+
+	case ActFindPrev:
+		m.findNext(-1, false)
+
+	case ActReplace:
+		m.startReplace()
+
+	case ActUndo:
+		m.undo()
+
+	case ActRedo:
+		m.redo()
+
+	case ActOrderView:
+		if m.Mode == ModeOrder || m.Mode == ModePatterns {
 			m.Mode = ModePattern
 		} else {
 			m.Mode = ModeOrder
 		}
 
-	case "f3":
+	case ActInstrumentView:
 		if m.Mode == ModeInstrument {
 			m.Mode = ModePattern
 		} else {
 			m.Mode = ModeInstrument
 		}
 
-	case "f4":
+	case ActOrnamentView:
 		if m.Mode == ModeOrnament {
 			m.Mode = ModePattern
 		} else {
 			m.Mode = ModeOrnament
 		}
 
+	case ActChannelView:
+		if m.Mode == ModeChannels {
+			m.Mode = ModePattern
+		} else {
+			m.Mode = ModeChannels
+			m.ChanCursor = m.CursorCh
+		}
+
 	// Playback controls
-	case " ":
+	case ActPlayStop:
 		if m.Playing {
 			m.Player.Stop()
 		} else {
@@ -206,33 +334,62 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.Player.Play()
 		}
 
-	case "f5":
+	case ActPlayFromRow:
 		// Play from current position
 		m.Player.SetPosition(m.EditPos, m.CursorRow)
 		m.Player.Play()
 
-	case "f8":
+	case ActMute:
+		// Toggle mute on cursor channel
+		if m.CursorCh < len(m.Song.ChanConfig) {
+			cfg := &m.Song.ChanConfig[m.CursorCh]
+			cfg.Muted = !cfg.Muted
+			m.edited()
+		}
+
+	case ActSolo:
+		// Toggle solo on cursor channel
+		if m.CursorCh < len(m.Song.ChanConfig) {
+			cfg := &m.Song.ChanConfig[m.CursorCh]
+			cfg.Solo = !cfg.Solo
+			m.edited()
+		}
+
+	case ActStop:
 		m.Player.Stop()
 
-	case "f9":
-		// Export to WAV
-		m.exportWAV()
+	case ActFollow:
+		m.Follow = !m.Follow
+		m.StatusMsg = "Follow playback " + onOff(m.Follow)
+
+	case ActLoopPattern:
+		m.LoopPattern = !m.LoopPattern
+		m.syncLoop()
+		m.StatusMsg = "Loop pattern " + onOff(m.LoopPattern)
+
+	case ActExport:
+		// Export in the configured format
+		m.exportSong(false)
+
+	case ActExportStems:
+		// Export one file per channel
+		m.exportSong(true)
 
 	// Navigation
-	case "up":
+	case ActUp:
 		if m.CursorRow > 0 {
 			m.CursorRow--
 			m.ensureRowVisible()
 		}
 
-	case "down":
+	case ActDown:
 		pat := m.currentPattern()
 		if pat != nil && m.CursorRow < pat.Rows-1 {
 			m.CursorRow++
 			m.ensureRowVisible()
 		}
 
-	case "left":
+	case ActLeft:
 		if m.CursorCol > ColNote {
 			m.CursorCol--
 		} else if m.CursorCh > 0 {
@@ -240,7 +397,7 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.CursorCol = ColEffectParam
 		}
 
-	case "right":
+	case ActRight:
 		if m.CursorCol < ColEffectParam {
 			m.CursorCol++
 		} else if m.CursorCh < m.Song.Channels-1 {
@@ -248,25 +405,25 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.CursorCol = ColNote
 		}
 
-	case "tab":
+	case ActNextChannel:
 		m.CursorCh = (m.CursorCh + 1) % m.Song.Channels
 		m.CursorCol = ColNote
 
-	case "shift+tab":
+	case ActPrevChannel:
 		m.CursorCh--
 		if m.CursorCh < 0 {
 			m.CursorCh = m.Song.Channels - 1
 		}
 		m.CursorCol = ColNote
 
-	case "pgup":
+	case ActPageUp:
 		m.CursorRow -= 16
 		if m.CursorRow < 0 {
 			m.CursorRow = 0
 		}
 		m.ensureRowVisible()
 
-	case "pgdown":
+	case ActPageDown:
 		pat := m.currentPattern()
 		if pat != nil {
 			m.CursorRow += 16
@@ -276,11 +433,11 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.ensureRowVisible()
 		}
 
-	case "home":
+	case ActFirstRow:
 		m.CursorRow = 0
 		m.ensureRowVisible()
 
-	case "end":
+	case ActLastRow:
 		pat := m.currentPattern()
 		if pat != nil {
 			m.CursorRow = pat.Rows - 1
@@ -288,27 +445,29 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 
 	// Order position navigation
-	case "+", "=":
+	case ActNextPos:
 		if m.EditPos < len(m.Song.Order)-1 {
 			m.EditPos++
 			m.CursorRow = 0
 			m.ViewRow = 0
+			m.syncLoop()
 		}
 
-	case "-", "_":
+	case ActPrevPos:
 		if m.EditPos > 0 {
 			m.EditPos--
 			m.CursorRow = 0
 			m.ViewRow = 0
+			m.syncLoop()
 		}
 
 	// Octave
-	case "*":
+	case ActOctaveUp:
 		if m.Octave < 8 {
 			m.Octave++
 		}
 
-	case "/":
+	case ActOctaveDown:
 		if m.Octave > 0 {
 			m.Octave--
 		}
@@ -316,12 +475,20 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	default:
 		// Mode-specific handling
 		switch m.Mode {
-		case ModeOrder:
-			return m.handleOrderKey(msg)
+		case ModeOrder, ModePatterns:
+			// Keys are handled by the order editor and pattern list
 		case ModeInstrument:
-			return m.handleInstrumentKey(msg)
+			// Audition the selected instrument
+			if note := m.Keys.Note(msg.String(), m.Octave); note >= 0 {
+				return m, m.previewNote(note, m.InstCursor, -1)
+			}
 		case ModeOrnament:
-			return m.handleOrnamentKey(msg)
+			// Audition the selected ornament on the selected instrument
+			if note := m.Keys.Note(msg.String(), m.Octave); note >= 0 {
+				return m, m.previewNote(note, m.InstCursor, m.OrnCursor+1)
+			}
+		case ModeChannels:
+			// Keys are handled by the channel editor
 		default:
 			// Pattern mode
 			return m.handlePatternKey(msg)
@@ -332,30 +499,98 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 }
 
 func (m Model) handlePatternKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.String() {
-	case "delete", "backspace":
+	switch m.Keys.Action(msg.String()) {
+	case ActClear:
 		m.clearCell()
-	case ".":
+	case ActNoteOff:
 		m.noteOff()
+
+	// Block selection and pattern operations
+	case ActSelectUp:
+		m.extendSelection()
+		m.CursorRow = max(m.CursorRow-1, 0)
+		m.ensureRowVisible()
+	case ActSelectDown:
+		m.extendSelection()
+		if pat := m.currentPattern(); pat != nil {
+			m.CursorRow = min(m.CursorRow+1, pat.Rows-1)
+			m.ensureRowVisible()
+		}
+	case ActSelectLeft:
+		m.extendSelection()
+		m.CursorCh = max(m.CursorCh-1, 0)
+	case ActSelectRight:
+		m.extendSelection()
+		m.CursorCh = min(m.CursorCh+1, m.Song.Channels-1)
+	case ActClearSelection:
+		m.Selecting = false
+	case ActTransposeUp:
+		m.transpose(1)
+	case ActTransposeDown:
+		m.transpose(-1)
+	case ActOctaveShiftUp:
+		m.transpose(12)
+	case ActOctaveShiftDown:
+		m.transpose(-12)
+	case ActCommand:
+		m.startInput("Command", "", 64, (*Model).runCommand)
+
+	// Row audition: play the row under the cursor and step down
+	case ActPlayRow:
+		m.playRow(-1)
+	case ActPlayChannelRow:
+		m.playRow(m.CursorCh)
 	default:
-		if note := keyToNote(msg.String(), m.Octave); note >= 0 {
-			m.enterNote(note)
+		if note := m.Keys.Note(msg.String(), m.Octave); note >= 0 {
+			if inst := m.enterNote(note); inst > 0 {
+				return m, m.previewNote(note, int(inst)-1, -1)
+			}
 		}
 	}
 	return m, nil
 }
 
-func (m Model) handleOrderKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.String() {
-	case "up":
-		if m.OrderCursor > 0 {
-			m.OrderCursor--
-		}
-	case "down":
-		if m.OrderCursor < len(m.Song.Order)-1 {
-			m.OrderCursor++
-		}
-	case "+", "=":
+// playRow auditions the cursor row, only on channel ch if ch >= 0, and
+// moves the cursor down so repeated presses step through the pattern
+func (m *Model) playRow(ch int) {
+	m.syncPlayer()
+	m.Player.PlayRow(m.EditPos, m.CursorRow, ch)
+	if pat := m.currentPattern(); pat != nil && m.CursorRow < pat.Rows-1 {
+		m.CursorRow++
+		m.ensureRowVisible()
+	}
+}
+
+// edited marks the song changed, so the player gets a new snapshot once
+// the key is handled
+func (m *Model) edited() {
+	m.dirty = true
+}
+
+// syncPlayer hands the player a copy of the song if it changed since the
+// last one. Copying on every key would clone the whole song per cursor
+// move.
+func (m *Model) syncPlayer() {
+	if m.dirty {
+		m.Player.UpdateSong(m.Song)
+		m.dirty = false
+	}
+}
+
+// syncLoop points the player's loop at the edited pattern, or clears it
+func (m *Model) syncLoop() {
+	if m.LoopPattern {
+		m.Player.SetLoop(m.EditPos, m.EditPos)
+	} else {
+		m.Player.ClearLoop()
+	}
+}
+
+// handleOrderKey handles the order editor's own keys, ahead of the
+// global bindings, and returns false for any other key
+func (m *Model) handleOrderKey(msg tea.KeyMsg) bool {
+	switch m.Keys.EditorAction(secOrder, msg.String()) {
+	case ActOrderInsert:
 		// Add pattern after current position
 		newOrder := make([]uint8, len(m.Song.Order)+1)
 		copy(newOrder[:m.OrderCursor+1], m.Song.Order[:m.OrderCursor+1])
@@ -363,7 +598,8 @@ func (m Model) handleOrderKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		copy(newOrder[m.OrderCursor+2:], m.Song.Order[m.OrderCursor+1:])
 		m.Song.Order = newOrder
 		m.OrderCursor++
-	case "-", "_":
+		m.edited()
+	case ActOrderRemove:
 		// Remove current position (keep at least 1)
 		if len(m.Song.Order) > 1 {
 			newOrder := make([]uint8, len(m.Song.Order)-1)
@@ -373,82 +609,76 @@ func (m Model) handleOrderKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			if m.OrderCursor >= len(m.Song.Order) {
 				m.OrderCursor = len(m.Song.Order) - 1
 			}
+			m.edited()
 		}
-	case "enter":
+	case ActOrderGoto:
 		// Go to this position in pattern mode
 		m.EditPos = m.OrderCursor
 		m.CursorRow = 0
 		m.ViewRow = 0
 		m.Mode = ModePattern
-	case "0", "1", "2", "3", "4", "5", "6", "7", "8", "9":
-		// Set pattern number
-		digit := uint8(msg.String()[0] - '0')
-		current := m.Song.Order[m.OrderCursor]
-		newVal := (current%10)*10 + digit
-		if int(newVal) < len(m.Song.Patterns) {
-			m.Song.Order[m.OrderCursor] = newVal
-		}
-	case "n":
+	case ActOrderNewPattern:
 		// Create new pattern and assign it
 		newPat := tracker.NewPattern(64, m.Song.Channels)
-		m.Song.Patterns = append(m.Song.Patterns, newPat)
+		if !m.Song.InsertPattern(len(m.Song.Patterns), newPat) {
+			m.StatusMsg = fmt.Sprintf("Song already has %d patterns", tracker.MaxPatterns)
+			break
+		}
 		m.Song.Order[m.OrderCursor] = uint8(len(m.Song.Patterns) - 1)
+		m.edited()
+	case ActPatternList:
+		// Pattern list, starting at this entry's pattern
+		m.Mode = ModePatterns
+		m.PatCursor = int(m.Song.Order[m.OrderCursor])
+	default:
+		return m.orderCursorKey(msg.String())
 	}
-	return m, nil
+	return true
 }
 
-func (m Model) handleInstrumentKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.String() {
+// orderCursorKey handles the order list's fixed cursor and digit keys
+func (m *Model) orderCursorKey(key string) bool {
+	switch key {
 	case "up":
-		if m.InstCursor > 0 {
-			m.InstCursor--
+		if m.OrderCursor > 0 {
+			m.OrderCursor--
 		}
 	case "down":
-		if m.InstCursor < len(m.Song.Instruments)-1 {
-			m.InstCursor++
-		}
-	case "g":
-		// Cycle generator
-		inst := &m.Song.Instruments[m.InstCursor]
-		inst.Generator = (inst.Generator + 1) % 5
-	case "+", "=":
-		// Increase volume
-		inst := &m.Song.Instruments[m.InstCursor]
-		if inst.Volume < 64 {
-			inst.Volume++
-		}
-	case "-", "_":
-		// Decrease volume
-		inst := &m.Song.Instruments[m.InstCursor]
-		if inst.Volume > 0 {
-			inst.Volume--
+		if m.OrderCursor < len(m.Song.Order)-1 {
+			m.OrderCursor++
+		}
+	case "0", "1", "2", "3", "4", "5", "6", "7", "8", "9":
+		// Set pattern number: digits shift in from the right, keeping
+		// as many of the previous ones as still give a valid pattern
+		digit := int(key[0] - '0')
+		current := int(m.Song.Order[m.OrderCursor])
+		for _, v := range []int{current%100*10 + digit, current%10*10 + digit, digit} {
+			if v < len(m.Song.Patterns) {
+				m.Song.Order[m.OrderCursor] = uint8(v)
+				m.edited()
+				break
+			}
 		}
+	default:
+		return false
 	}
-	return m, nil
+	return true
 }
 
-func (m Model) handleOrnamentKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.String() {
-	case "up":
-		if m.OrnCursor > 0 {
-			m.OrnCursor--
-		}
-	case "down":
-		if m.OrnCursor < len(m.Song.Ornaments)-1 {
-			m.OrnCursor++
-		}
-	case "+", "=":
-		// Add value to ornament
-		orn := &m.Song.Ornaments[m.OrnCursor]
-		orn.Values = append(orn.Values, 0)
-	case "-", "_":
-		// Remove last value
-		orn := &m.Song.Ornaments[m.OrnCursor]
-		if len(orn.Values) > 1 {
-			orn.Values = orn.Values[:len(orn.Values)-1]
-		}
+// previewNote plays a note on the preview voice and schedules its
+// release. Terminals report no key-up, so a held key is recognised by its
+// auto-repeat: pressing the sounding note again extends it instead of
+// retriggering.
+func (m *Model) previewNote(note int8, inst, ornament int) tea.Cmd {
+	if note != m.previewKey {
+		m.Player.PreviewNote(note, inst, ornament)
+		m.previewKey = note
 	}
-	return m, nil
+	m.previewSeq++
+	seq := m.previewSeq
+	return tea.Tick(m.PreviewHold, func(_ time.Time) tea.Msg {
+		return previewOffMsg{seq: seq}
+	})
 }
 
 func (m *Model) currentPattern() *tracker.Pattern {
@@ -469,23 +699,30 @@ func (m *Model) currentPatternNum() int {
 	return 0
 }
 
-func (m *Model) enterNote(note int8) {
+// enterNote writes a note at the cursor and returns its instrument
+// number (0 if nothing was written)
+func (m *Model) enterNote(note int8) uint8 {
 	pat := m.currentPattern()
 	if pat == nil {
-		return
+		return 0
 	}
 
 	if m.CursorRow < pat.Rows && m.CursorCh < pat.Channels {
-		pat.Notes[m.CursorRow][m.CursorCh].Pitch = note
-		if pat.Notes[m.CursorRow][m.CursorCh].Instrument == 0 {
-			pat.Notes[m.CursorRow][m.CursorCh].Instrument = 1 // Default instrument
+		cell := &pat.Notes[m.CursorRow][m.CursorCh]
+		cell.Pitch = note
+		if cell.Instrument == 0 {
+			cell.Instrument = 1 // Default instrument
 		}
+		m.edited()
+		inst := cell.Instrument
 		// Move down
 		if m.CursorRow < pat.Rows-1 {
 			m.CursorRow++
 			m.ensureRowVisible()
 		}
+		return inst
 	}
+	return 0
 }
 
 func (m *Model) noteOff() {
@@ -496,6 +733,7 @@ func (m *Model) noteOff() {
 
 	if m.CursorRow < pat.Rows && m.CursorCh < pat.Channels {
 		pat.Notes[m.CursorRow][m.CursorCh].Pitch = -2 // Note off
+		m.edited()
 		if m.CursorRow < pat.Rows-1 {
 			m.CursorRow++
 			m.ensureRowVisible()
@@ -521,83 +759,10 @@ func (m *Model) clearCell() {
 		case ColEffect, ColEffectParam:
 			pat.Notes[m.CursorRow][m.CursorCh].Effect = tracker.Effect{}
 		}
+		m.edited()
 	}
 }
 
-func (m *Model) exportWAV() {
-	// Determine output filename based on source file
-	baseName := "output"
-	if m.Filename != "" {
-		baseName = strings.TrimSuffix(filepath.Base(m.Filename), filepath.Ext(m.Filename))
-	} else if m.Song.Title != "" {
-		baseName = m.Song.Title
-	}
-
-	// Create _export directory if needed
-	exportDir := "_export"
-	if err := os.MkdirAll(exportDir, 0755); err != nil {
-		m.StatusMsg = "Export failed: " + err.Error()
-		return
-	}
-
-	outputPath := filepath.Join(exportDir, baseName+".wav")
-
-	// Calculate duration based on song length
-	// Each row = speed ticks, each tick = tempo-based duration
-	totalRows := 0
-	for _, patIdx := range m.Song.Order {
-		if int(patIdx) < len(m.Song.Patterns) {
-			totalRows += m.Song.Patterns[patIdx].Rows
-		}
-	}
-	ticksPerSecond := float64(m.Song.Tempo) * 2.0 / 5.0
-	secondsPerRow := float64(m.Song.Speed) / ticksPerSecond
-	duration := float64(totalRows) * secondsPerRow
-	if duration < 1 {
-		duration = 10 // Default 10 seconds
-	}
-
-	m.StatusMsg = fmt.Sprintf("Exporting %.1fs to %s...", duration, outputPath)
-
-	// Create file
-	f, err := os.Create(outputPath)
-	if err != nil {
-		m.StatusMsg = "Export failed: " + err.Error()
-		return
-	}
-	defer f.Close()
-
-	// Export
-	err = audio.ExportWAV(m.Player, f, duration)
-	if err != nil {
-		m.StatusMsg = "Export failed: " + err.Error()
-		return
-	}
-
-	m.StatusMsg = fmt.Sprintf("Exported to %s (%.1fs)", outputPath, duration)
-}
-
-// keyToNote converts keyboard key to MIDI note
-func keyToNote(key string, octave int) int8 {
-	// Piano-style keyboard layout:
-	// Lower row: Z S X D C V G B H N J M (white + black keys)
-	// Upper row: Q 2 W 3 E R 5 T 6 Y 7 U
-	notes := map[string]int{
-		// Lower octave
-		"z": 0, "s": 1, "x": 2, "d": 3, "c": 4, "v": 5,
-		"g": 6, "b": 7, "h": 8, "n": 9, "j": 10, "m": 11,
-		// Upper octave
-		"q": 12, "2": 13, "w": 14, "3": 15, "e": 16, "r": 17,
-		"5": 18, "t": 19, "6": 20, "y": 21, "7": 22, "u": 23,
-		"i": 24, "9": 25, "o": 26, "0": 27, "p": 28,
-	}
-
-	if n, ok := notes[key]; ok {
-		return int8(octave*12 + n)
-	}
-	return -1
-}
-
 // View implements tea.Model
 func (m Model) View() string {
 	if m.ShowHelp {
@@ -609,6 +774,8 @@ func (m Model) View() string {
 	// Header
 	b.WriteString(m.headerView())
 	b.WriteString("\n")
+	b.WriteString(m.meterView())
+	b.WriteString("\n")
 
 	switch m.Mode {
 	case ModeOrder:
@@ -617,6 +784,10 @@ func (m Model) View() string {
 		b.WriteString(m.instrumentView())
 	case ModeOrnament:
 		b.WriteString(m.ornamentView())
+	case ModeChannels:
+		b.WriteString(m.channelView())
+	case ModePatterns:
+		b.WriteString(m.patternListView())
 	default:
 		// Pattern mode
 		b.WriteString(m.channelHeaderView())
@@ -631,16 +802,17 @@ func (m Model) View() string {
 }
 
 func (m Model) headerView() string {
-	title := lipgloss.NewStyle().
-		Bold(true).
-		Foreground(lipgloss.Color("14")).
-		Render("ABYTETRACKER")
+	title := m.Theme.Fg(m.Theme.Accent).Bold(true).Render("ABYTETRACKER")
 
 	status := "STOPPED"
 	if m.Playing {
-		status = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("10")).
-			Render(fmt.Sprintf("PLAYING %02d:%02d", m.PlayPos, m.PlayRow))
+		status = m.Theme.Fg(m.Theme.Good).Render(fmt.Sprintf("PLAYING %02d:%02d", m.PlayPos, m.PlayRow))
+	}
+	if m.LoopPattern {
+		status += fmt.Sprintf(" LOOP %02d", m.EditPos)
+	}
+	if !m.Follow {
+		status += " NOFOLLOW"
 	}
 
 	info := fmt.Sprintf(" │ Pos:%02d/%02d Pat:%02d Row:%02d │ Spd:%d BPM:%d │ Oct:%d │ %s",
@@ -650,6 +822,49 @@ func (m Model) headerView() string {
 	return title + info
 }
 
+func (m Model) meterView() string {
+	// Bar covers -48 dB to 0 dB
+	bar := func(level float64) string {
+		const width = 16
+		db := audio.LinearToDB(level)
+		n := int((db + 48) / 48 * width)
+		if n < 0 {
+			n = 0
+		}
+		if n > width {
+			n = width
+		}
+		color := m.Theme.Good
+		if db > -1 {
+			color = m.Theme.Bad
+		} else if db > -6 {
+			color = m.Theme.Warn
+		}
+		return m.Theme.Fg(color).Render(strings.Repeat("█", n)) +
+			m.Theme.Fg(m.Theme.Dim).Render(strings.Repeat("░", width-n))
+	}
+	db := func(level float64) string {
+		v := audio.LinearToDB(level)
+		if v < -99 {
+			return "  -∞ "
+		}
+		return fmt.Sprintf("%5.1f", v)
+	}
+
+	lufs := "  -∞ "
+	if m.Meters.LUFS > -99 {
+		lufs = fmt.Sprintf("%5.1f", m.Meters.LUFS)
+	}
+	limiter := ""
+	if m.Song.Master.Limiter {
+		limiter = " LIM"
+	}
+
+	return fmt.Sprintf(" L %s%s  R %s%s │ RMS %s/%s │ LUFS %s │ Gain %d%s",
+		bar(m.Meters.PeakL), db(m.Meters.PeakL), bar(m.Meters.PeakR), db(m.Meters.PeakR),
+		db(m.Meters.RMSL), db(m.Meters.RMSR), lufs, m.Song.Master.Gain, limiter)
+}
+
 func (m Model) channelHeaderView() string {
 	var parts []string
 	parts = append(parts, "   │")
@@ -669,17 +884,39 @@ func (m Model) channelHeaderView() string {
 				gen = "squ"
 			case tracker.GenNoise:
 				gen = "noi"
+			case tracker.GenFM:
+				gen = "fmo"
+			case tracker.GenWavetable:
+				gen = "wav"
 			}
 		}
 
-		style := lipgloss.NewStyle()
+		// Separator shows mute/solo state
+		sep := ":"
+		muted, solo := false, false
+		if ch < len(m.Song.ChanConfig) {
+			muted = m.Song.ChanConfig[ch].Muted
+			solo = m.Song.ChanConfig[ch].Solo
+		}
+		if solo {
+			sep = "S"
+		} else if muted {
+			sep = "M"
+		}
+
+		style := m.Theme.Fg(m.Theme.Dim)
+		if solo {
+			style = m.Theme.Fg(m.Theme.Good)
+		} else if muted {
+			style = m.Theme.Fg(m.Theme.Bad).Strikethrough(true)
+		} else if ch == m.CursorCh {
+			style = m.Theme.Fg(m.Theme.Title)
+		}
 		if ch == m.CursorCh {
-			style = style.Foreground(lipgloss.Color("11")).Bold(true)
-		} else {
-			style = style.Foreground(lipgloss.Color("8"))
+			style = style.Bold(true)
 		}
 
-		header := fmt.Sprintf(" %-6s:%s│", name, gen)
+		header := fmt.Sprintf(" %-6s%s%s│", name, sep, gen)
 		parts = append(parts, style.Render(header))
 	}
 
@@ -692,29 +929,53 @@ func (m Model) patternView() string {
 		return "No pattern"
 	}
 
-	visibleRows := m.Height - 10
+	visibleRows := m.Height - 11
 	if visibleRows < 8 {
 		visibleRows = 8
 	}
 
 	var lines []string
 
-	for row := m.ViewRow; row < m.ViewRow+visibleRows && row < pat.Rows; row++ {
-		line := m.renderRow(pat, row)
-		lines = append(lines, line)
+	// Notes take the colour of the instrument playing them, which
+	// carries down from the last row that set one
+	insts := make([]uint8, pat.Channels)
+	for row := 0; row < m.ViewRow+visibleRows && row < pat.Rows; row++ {
+		for ch := range insts {
+			if inst := pat.Notes[row][ch].Instrument; inst > 0 {
+				insts[ch] = inst
+			}
+		}
+		if row >= m.ViewRow {
+			lines = append(lines, m.renderRow(pat, row, insts))
+		}
 	}
 
 	return strings.Join(lines, "\n")
 }
 
-func (m Model) renderRow(pat *tracker.Pattern, row int) string {
+// rowHighlight reports whether row starts a beat or a measure, using the
+// song's highlight spacing (0 = off)
+func (m Model) rowHighlight(row int) (beat, measure bool) {
+	if n := int(m.Song.RowsPerBeat); n > 0 {
+		beat = row%n == 0
+	}
+	if n := int(m.Song.RowsPerMeasure); n > 0 {
+		measure = row%n == 0
+	}
+	return beat, measure
+}
+
+func (m Model) renderRow(pat *tracker.Pattern, row int, insts []uint8) string {
 	// Row number
-	rowStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
-	if row%4 == 0 {
-		rowStyle = rowStyle.Foreground(lipgloss.Color("14"))
+	rowStyle := m.Theme.Fg(m.Theme.Dim)
+	beat, measure := m.rowHighlight(row)
+	if measure {
+		rowStyle = m.Theme.Fg(m.Theme.Measure).Bold(true)
+	} else if beat {
+		rowStyle = m.Theme.Fg(m.Theme.Beat)
 	}
 	if row == m.PlayRow && m.Playing {
-		rowStyle = rowStyle.Background(lipgloss.Color("4"))
+		rowStyle = m.Theme.Bg(rowStyle, m.Theme.PlayBg)
 	}
 
 	cursor := " "
@@ -726,60 +987,84 @@ func (m Model) renderRow(pat *tracker.Pattern, row int) string {
 
 	for ch := 0; ch < pat.Channels; ch++ {
 		note := pat.Notes[row][ch]
-		cell := m.renderCell(note, row, ch)
+		cell := m.renderCell(note, row, ch, insts[ch])
 		line += cell + "│"
 	}
 
 	return line
 }
 
-func (m Model) renderCell(note tracker.Note, row, ch int) string {
+// renderCell draws one cell; inst is the instrument playing in the
+// channel at this row, which picks the note colour
+func (m Model) renderCell(note tracker.Note, row, ch int, inst uint8) string {
 	isCursor := row == m.CursorRow && ch == m.CursorCh
+	selected := m.inSelection(row, ch)
 	isEmpty := note.Pitch == -1 && note.Instrument == 0 && note.Effect.Type == 0 && note.Effect.Param == 0
 
+	// Measure rows are shaded across the pattern; monochrome themes
+	// would show that as reverse video, so they keep to the row number
+	base := lipgloss.NewStyle()
+	sep := " "
+	if _, measure := m.rowHighlight(row); measure && !m.Theme.Mono && m.Theme.MeasureBg != "" {
+		base = m.Theme.Bg(base, m.Theme.MeasureBg)
+		sep = base.Render(" ")
+	}
+
 	// Note
 	noteStr := tracker.NoteToString(note.Pitch)
-	noteStyle := lipgloss.NewStyle()
+	noteStyle := base.Inherit(m.Theme.Fg(m.Theme.Dim))
 	if note.Pitch >= 0 {
-		noteStyle = noteStyle.Foreground(lipgloss.Color("15"))
+		noteStyle = base.Inherit(m.Theme.Fg(m.Theme.InstrumentNote(inst)))
 	} else if note.Pitch == -2 {
-		noteStyle = noteStyle.Foreground(lipgloss.Color("9"))
-	} else {
-		noteStyle = noteStyle.Foreground(lipgloss.Color("8"))
+		noteStyle = base.Inherit(m.Theme.Fg(m.Theme.Bad))
 	}
 	if isCursor && m.CursorCol == ColNote {
-		noteStyle = noteStyle.Background(lipgloss.Color("6"))
+		noteStyle = m.Theme.Bg(noteStyle, m.Theme.CursorBg)
 	}
 
 	// Instrument - only show if note is present or instrument explicitly set
 	instStr := "--"
-	instStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	instStyle := base.Inherit(m.Theme.Fg(m.Theme.Dim))
 	if note.Instrument > 0 && (note.Pitch >= 0 || note.Pitch == -2 || !isEmpty) {
 		instStr = fmt.Sprintf("%02X", note.Instrument)
-		instStyle = instStyle.Foreground(lipgloss.Color("11"))
+		instStyle = base.Inherit(m.Theme.Fg(m.Theme.Instrument))
 	}
 	if isCursor && m.CursorCol == ColInstrument {
-		instStyle = instStyle.Background(lipgloss.Color("6"))
+		instStyle = m.Theme.Bg(instStyle, m.Theme.CursorBg)
 	}
 
 	// Effect
 	fxStr := "..."
-	fxStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	fxStyle := base.Inherit(m.Theme.Fg(m.Theme.Dim))
 	if note.Effect.Type != 0 || note.Effect.Param != 0 {
-		fxStr = fmt.Sprintf("%X%02X", note.Effect.Type, note.Effect.Param)
-		fxStyle = fxStyle.Foreground(lipgloss.Color("13"))
+		fxStr = fmt.Sprintf("%c%02X", tracker.EffectChar(note.Effect.Type), note.Effect.Param)
+		fxStyle = base.Inherit(m.Theme.Fg(m.Theme.Effect))
 	}
 	if isCursor && (m.CursorCol == ColEffect || m.CursorCol == ColEffectParam) {
-		fxStyle = fxStyle.Background(lipgloss.Color("6"))
+		fxStyle = m.Theme.Bg(fxStyle, m.Theme.CursorBg)
 	}
 
-	return " " + noteStyle.Render(noteStr) + " " + instStyle.Render(instStr) + " " + fxStyle.Render(fxStr)
+	if selected {
+		sel := m.Theme.SelectionBg
+		if !isCursor || m.CursorCol != ColNote {
+			noteStyle = m.Theme.Bg(noteStyle, sel)
+		}
+		if !isCursor || m.CursorCol != ColInstrument {
+			instStyle = m.Theme.Bg(instStyle, sel)
+		}
+		if !isCursor || (m.CursorCol != ColEffect && m.CursorCol != ColEffectParam) {
+			fxStyle = m.Theme.Bg(fxStyle, sel)
+		}
+		sep = m.Theme.Bg(lipgloss.NewStyle(), sel).Render(" ")
+	}
+
+	return " " + noteStyle.Render(noteStr) + sep + instStyle.Render(instStr) + sep + fxStyle.Render(fxStr)
 }
 
 func (m Model) orderView() string {
 	var b strings.Builder
-	title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("11")).Render("ORDER LIST")
-	b.WriteString(title + " (F2 to exit)\n\n")
+	title := m.Theme.Fg(m.Theme.Title).Bold(true).Render("ORDER LIST")
+	fmt.Fprintf(&b, "%s (%s to exit)\n\n", title, m.Keys.shortLabel(ActOrderView))
 
 	for i, patIdx := range m.Song.Order {
 		cursor := "  "
@@ -788,150 +1073,23 @@ func (m Model) orderView() string {
 		}
 		playing := "  "
 		if i == m.PlayPos && m.Playing {
-			playing = lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Render("▶ ")
+			playing = m.Theme.Fg(m.Theme.Good).Render("▶ ")
 		}
 
 		style := lipgloss.NewStyle()
 		if i == m.OrderCursor {
-			style = style.Bold(true).Foreground(lipgloss.Color("14"))
+			style = m.Theme.Fg(m.Theme.Accent).Bold(true)
 		}
 		if i == m.EditPos {
-			style = style.Background(lipgloss.Color("8"))
-		}
-
-		line := fmt.Sprintf("%s%s%02d: Pattern %02d", cursor, playing, i, patIdx)
-		b.WriteString(style.Render(line) + "\n")
-	}
-
-	b.WriteString("\n ↑↓ Navigate  +/= Add  - Remove  0-9 Set pattern  Enter Go to pattern\n")
-	return b.String()
-}
-
-func (m Model) instrumentView() string {
-	var b strings.Builder
-	title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("11")).Render("INSTRUMENTS")
-	b.WriteString(title + " (F3 to exit)\n\n")
-
-	genNames := map[tracker.Generator]string{
-		tracker.GenTriangle: "tri", tracker.GenSawtooth: "saw",
-		tracker.GenSquare: "squ", tracker.GenSawBig: "swb", tracker.GenNoise: "noi",
-	}
-
-	for i, inst := range m.Song.Instruments {
-		cursor := "  "
-		if i == m.InstCursor {
-			cursor = "> "
-		}
-		style := lipgloss.NewStyle()
-		if i == m.InstCursor {
-			style = style.Bold(true).Foreground(lipgloss.Color("14"))
+			style = m.Theme.Bg(style, m.Theme.MarkBg)
 		}
 
-		gen := genNames[inst.Generator]
-		env := fmt.Sprintf("A%02d D%02d S%02d R%02d", inst.Envelope.Attack, inst.Envelope.Decay, inst.Envelope.Sustain, inst.Envelope.Release)
-		duty := ""
-		if inst.Generator == tracker.GenSquare && inst.Duty > 0 {
-			duty = fmt.Sprintf(" D%02X", inst.Duty)
-		}
-		line := fmt.Sprintf("%s%02d: %-8s %s Vol:%02d %s%s", cursor, i+1, inst.Name, gen, inst.Volume, env, duty)
+		line := fmt.Sprintf("%s%s%02d: Pattern %03d", cursor, playing, i, patIdx)
 		b.WriteString(style.Render(line) + "\n")
 	}
 
-	b.WriteString("\n ↑↓ Select  ←→ Field  0-9 Edit  G Osc  Enter Edit name\n")
+	key := m.Keys.shortLabel
+	fmt.Fprintf(&b, "\n ↑↓ Navigate  %s Add  %s Remove  0-9 Set pattern  %s New pattern  %s Go to pattern  %s Pattern list\n",
+		m.Keys.Label(ActOrderInsert), key(ActOrderRemove), key(ActOrderNewPattern), key(ActOrderGoto), key(ActPatternList))
 	return b.String()
 }
-
-func (m Model) ornamentView() string {
-	var b strings.Builder
-	title := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("11")).Render("ORNAMENTS")
-	b.WriteString(title + " (F4 to exit)\n\n")
-
-	for i, orn := range m.Song.Ornaments {
-		cursor := "  "
-		if i == m.OrnCursor {
-			cursor = "> "
-		}
-		style := lipgloss.NewStyle()
-		if i == m.OrnCursor {
-			style = style.Bold(true).Foreground(lipgloss.Color("14"))
-		}
-
-		values := ""
-		for j, v := range orn.Values {
-			if j > 0 {
-				values += " "
-			}
-			if v >= 0 {
-				values += fmt.Sprintf("+%d", v)
-			} else {
-				values += fmt.Sprintf("%d", v)
-			}
-		}
-		loop := ""
-		if orn.Loop >= 0 {
-			loop = fmt.Sprintf(" L%d", orn.Loop)
-		}
-		line := fmt.Sprintf("%s%02d: %-8s [%s]%s", cursor, i+1, orn.Name, values, loop)
-		b.WriteString(style.Render(line) + "\n")
-	}
-
-	b.WriteString("\n ↑↓ Select  Enter Edit  + Add value  - Remove value\n")
-	return b.String()
-}
-
-func (m Model) footerView() string {
-	var keys string
-	switch m.Mode {
-	case ModeOrder:
-		keys = " [F2]Pattern [F3]Inst [F4]Orn [Space]Play [H]Help [Q]Quit"
-	case ModeInstrument:
-		keys = " [F2]Order [F3]Pattern [F4]Orn [Space]Play [H]Help [Q]Quit"
-	case ModeOrnament:
-		keys = " [F2]Order [F3]Inst [F4]Pattern [Space]Play [H]Help [Q]Quit"
-	default:
-		keys = " [F2]Order [F3]Inst [F4]Orn [Space]Play [F9]Export [+/-]Pos [H]Help [Q]Quit"
-	}
-	footer := lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(keys)
-	if m.StatusMsg != "" {
-		status := lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Render("\n " + m.StatusMsg)
-		footer += status
-	}
-	return footer
-}
-
-func (m Model) helpView() string {
-	help := `
-╔══════════════════════════════════════════════════════════════════╗
-║                    ABYTETRACKER HELP                             ║
-╠══════════════════════════════════════════════════════════════════╣
-║ NAVIGATION                                                       ║
-║   ↑↓←→      Move cursor          Tab       Next channel          ║
-║   PgUp/Dn   Move 16 rows         +/-       Next/prev pattern     ║
-║   Home/End  First/last row       */        Octave up/down        ║
-║                                                                  ║
-║ NOTE INPUT (piano keyboard)                                      ║
-║   Z S X D C V G B H N J M  - Lower octave (C to B)              ║
-║   Q 2 W 3 E R 5 T 6 Y 7 U  - Upper octave                       ║
-║   .         Note off             Del       Clear cell            ║
-║                                                                  ║
-║ PLAYBACK & EXPORT                                                ║
-║   Space     Play/Stop            F9        Export WAV            ║
-║   F5        Play from row        F8        Stop                  ║
-║                                                                  ║
-║ OSCILLATORS (set in instrument, shown in channel header)         ║
-║   tri  Triangle wave       saw  Sawtooth wave                    ║
-║   squ  Square/pulse wave   swb  SawBig (11-bit bytebeat)        ║
-║   noi  Noise               (use Kxx effect for duty cycle)       ║
-║                                                                  ║
-║ EFFECTS (in effect column: Txx where T=type, xx=param)           ║
-║   0xy  Arpeggio            Cxx  Set volume (00-40)               ║
-║   1xx  Slide up            Fxx  Set speed (<20) or tempo (≥20)   ║
-║   2xx  Slide down          Gxx  Set ornament                     ║
-║   4xy  Vibrato             Kxx  Set duty cycle (00-FF, 80=50%)   ║
-║   Exy  Echo ch x delay y                                         ║
-║                                                                  ║
-║                              [H/F1] Close help                   ║
-╚══════════════════════════════════════════════════════════════════╝
-`
-	return lipgloss.NewStyle().Foreground(lipgloss.Color("14")).Render(help)
-}
@@ -0,0 +1,81 @@
+package tui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/anthropics/abytetracker/pkg/tracker"
+)
+
+// keyMsg returns the message bubbletea sends for a key name
+func keyMsg(name string) tea.KeyMsg {
+	switch name {
+	case "up":
+		return tea.KeyMsg{Type: tea.KeyUp}
+	case "down":
+		return tea.KeyMsg{Type: tea.KeyDown}
+	case "right":
+		return tea.KeyMsg{Type: tea.KeyRight}
+	case "pgup":
+		return tea.KeyMsg{Type: tea.KeyPgUp}
+	case "f3":
+		return tea.KeyMsg{Type: tea.KeyF3}
+	case "f6":
+		return tea.KeyMsg{Type: tea.KeyF6}
+	case "delete":
+		return tea.KeyMsg{Type: tea.KeyDelete}
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(name)}
+}
+
+func TestOnlyEditsSnapshotTheSong(t *testing.T) {
+	// Cursor moves and view switches leave the player's copy alone; edits
+	// mark the song for a new snapshot
+	tests := []struct {
+		mode  EditMode
+		keys  []string
+		dirty bool
+	}{
+		{ModePattern, []string{"down", "up", "pgup"}, false},
+		{ModePattern, []string{"f3"}, false},
+		{ModePattern, []string{"q"}, true},
+		{ModePattern, []string{"delete"}, true},
+		{ModePattern, []string{"f6"}, true}, // Mute is saved with the song
+		{ModeInstrument, []string{"down", "up"}, false},
+		{ModeInstrument, []string{"+"}, true},
+		{ModeInstrument, []string{"z"}, false}, // Preview only
+		{ModeOrnament, []string{"]", "["}, false},
+		{ModeOrnament, []string{"up"}, true},
+		{ModeOrder, []string{"down"}, false},
+		{ModeOrder, []string{"+"}, true},
+		{ModeChannels, []string{"down"}, false},
+		{ModeChannels, []string{"pgup"}, false}, // The name has no value to change
+		{ModeChannels, []string{"right", "pgup"}, true},
+	}
+	for _, tt := range tests {
+		m := NewModel(tracker.NewSong(2), "")
+		m.Mode = tt.mode
+		for _, k := range tt.keys {
+			next, _ := m.handleKey(keyMsg(k))
+			m = next.(Model)
+		}
+		if m.dirty != tt.dirty {
+			t.Errorf("mode %d, keys %v: dirty = %v, want %v", tt.mode, tt.keys, m.dirty, tt.dirty)
+		}
+	}
+
+	// Update hands the edit over and clears the flag
+	m := NewModel(tracker.NewSong(2), "")
+	next, _ := m.Update(keyMsg("q"))
+	m = next.(Model)
+	if m.dirty {
+		t.Error("still dirty after Update")
+	}
+	m.Player.SetPosition(0, 0)
+	if got := m.Player.Song.Patterns[0].Notes[0][0].Pitch; got != 4*12+12 {
+		t.Errorf("player plays pitch %d, want the typed note", got)
+	}
+}
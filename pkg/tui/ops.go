@@ -0,0 +1,279 @@
+package tui
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anthropics/abytetracker/pkg/tracker"
+)
+
+// opTarget is a block of one pattern that an operation applies to
+type opTarget struct {
+	pat   *tracker.Pattern
+	block tracker.Block
+}
+
+// selection returns the selected block of the current pattern
+func (m *Model) selection() (tracker.Block, bool) {
+	if !m.Selecting {
+		return tracker.Block{}, false
+	}
+	return tracker.Block{Row0: m.SelRow, Row1: m.CursorRow, Ch0: m.SelCh, Ch1: m.CursorCh}, true
+}
+
+// inSelection reports whether a cell of the current pattern is selected
+func (m Model) inSelection(row, ch int) bool {
+	b, ok := m.selection()
+	if !ok {
+		return false
+	}
+	return row >= min(b.Row0, b.Row1) && row <= max(b.Row0, b.Row1) &&
+		ch >= min(b.Ch0, b.Ch1) && ch <= max(b.Ch0, b.Ch1)
+}
+
+// extendSelection starts a selection at the cursor if none is active
+func (m *Model) extendSelection() {
+	if !m.Selecting {
+		m.Selecting = true
+		m.SelRow = m.CursorRow
+		m.SelCh = m.CursorCh
+	}
+}
+
+// opTargets resolves a scope name to the blocks it covers:
+// sel (the selection), track (the cursor channel), pattern or song.
+// An empty scope means the selection if there is one, else the track.
+func (m *Model) opTargets(scope string) ([]opTarget, error) {
+	pat := m.currentPattern()
+	if scope == "" {
+		scope = "track"
+		if m.Selecting {
+			scope = "sel"
+		}
+	}
+	if pat == nil && scope != "song" {
+		return nil, fmt.Errorf("no pattern")
+	}
+
+	switch scope {
+	case "sel", "selection":
+		b, ok := m.selection()
+		if !ok {
+			return nil, fmt.Errorf("nothing selected")
+		}
+		return []opTarget{{pat, b}}, nil
+	case "track":
+		return []opTarget{{pat, pat.Track(m.CursorCh)}}, nil
+	case "pattern":
+		return []opTarget{{pat, pat.Whole()}}, nil
+	case "song":
+		var targets []opTarget
+		for _, p := range m.Song.Patterns {
+			targets = append(targets, opTarget{p, p.Whole()})
+		}
+		return targets, nil
+	}
+	return nil, fmt.Errorf("unknown scope %q (sel, track, pattern, song)", scope)
+}
+
+// transpose shifts notes in the default scope and reports the result
+func (m *Model) transpose(semitones int) {
+	targets, err := m.opTargets("")
+	if err != nil {
+		m.StatusMsg = err.Error()
+		return
+	}
+	before := m.Song.Clone()
+	n := 0
+	for _, t := range targets {
+		n += t.pat.Transpose(t.block, semitones, 0)
+	}
+	if n > 0 {
+		m.pushUndo(before)
+	}
+	m.StatusMsg = fmt.Sprintf("Transposed %d notes by %+d", n, semitones)
+}
+
+// commandHelp lists the pattern commands for the command line
+const commandHelp = "transpose ±N [iXX] [scope] | interp vol|fx | replace XX YY [scope] | scale PCT [scope] | humanize N [scope] | hl BEAT [MEASURE]"
+
+// runCommand runs a pattern command line such as "transpose -12 song i03".
+// Scopes are sel, track, pattern and song, given anywhere after the
+// command name; instruments are hex as shown in the pattern. A command
+// that changes anything can be undone.
+func (m *Model) runCommand(line string) {
+	before := m.Song.Clone()
+	n, err := m.execCommand(strings.Fields(strings.ToLower(line)))
+	if err != nil {
+		m.StatusMsg = "Error: " + err.Error()
+	}
+	if n > 0 {
+		m.pushUndo(before)
+	}
+}
+
+// execCommand runs a parsed command and returns the number of changes
+func (m *Model) execCommand(args []string) (int, error) {
+	if len(args) == 0 {
+		return 0, nil
+	}
+	cmd, args := args[0], args[1:]
+
+	// The scope is an optional word anywhere after the command
+	scope := ""
+	rest := args[:0:0]
+	for _, a := range args {
+		switch a {
+		case "sel", "selection", "track", "pattern", "song":
+			if scope != "" {
+				return 0, fmt.Errorf("more than one scope")
+			}
+			scope = a
+		default:
+			rest = append(rest, a)
+		}
+	}
+	args = rest
+
+	n := 0
+	switch cmd {
+	case "transpose", "tr":
+		// transpose ±N [iXX] [scope]
+		var inst uint8
+		if len(args) == 2 && strings.HasPrefix(args[1], "i") {
+			v, err := parseInstrument(args[1][1:])
+			if err != nil {
+				return 0, err
+			}
+			inst = v
+			args = args[:1]
+		}
+		if len(args) != 1 {
+			return 0, fmt.Errorf("usage: transpose ±N [iXX] [sel|track|pattern|song]")
+		}
+		semitones, err := strconv.Atoi(args[0])
+		if err != nil {
+			return 0, fmt.Errorf("bad semitones %q", args[0])
+		}
+		targets, err := m.opTargets(scope)
+		if err != nil {
+			return 0, err
+		}
+		for _, t := range targets {
+			n += t.pat.Transpose(t.block, semitones, inst)
+		}
+		m.StatusMsg = fmt.Sprintf("Transposed %d notes by %+d", n, semitones)
+
+	case "interp", "interpolate":
+		if len(args) != 1 {
+			return 0, fmt.Errorf("usage: interp vol|fx [sel|track]")
+		}
+		targets, err := m.opTargets(scope)
+		if err != nil {
+			return 0, err
+		}
+		for _, t := range targets {
+			switch args[0] {
+			case "vol", "volume":
+				n += t.pat.InterpolateVolume(t.block)
+			case "fx", "effect":
+				n += t.pat.InterpolateEffect(t.block)
+			default:
+				return 0, fmt.Errorf("interpolate vol or fx, not %q", args[0])
+			}
+		}
+		m.StatusMsg = fmt.Sprintf("Interpolated %d rows", n)
+
+	case "replace", "ri":
+		if len(args) != 2 {
+			return 0, fmt.Errorf("usage: replace XX YY [sel|track|pattern|song]")
+		}
+		from, err := parseInstrument(args[0])
+		if err != nil {
+			return 0, err
+		}
+		to, err := parseInstrument(args[1])
+		if err != nil {
+			return 0, err
+		}
+		targets, err := m.opTargets(scope)
+		if err != nil {
+			return 0, err
+		}
+		for _, t := range targets {
+			n += t.pat.ReplaceInstrument(t.block, from, to)
+		}
+		m.StatusMsg = fmt.Sprintf("Replaced instrument %02X with %02X in %d notes", from, to, n)
+
+	case "scale":
+		if len(args) != 1 {
+			return 0, fmt.Errorf("usage: scale PERCENT [sel|track|pattern|song]")
+		}
+		percent, err := strconv.Atoi(strings.TrimSuffix(args[0], "%"))
+		if err != nil || percent < 0 {
+			return 0, fmt.Errorf("bad percentage %q", args[0])
+		}
+		targets, err := m.opTargets(scope)
+		if err != nil {
+			return 0, err
+		}
+		for _, t := range targets {
+			n += t.pat.ScaleVolume(t.block, percent)
+		}
+		m.StatusMsg = fmt.Sprintf("Scaled %d volumes to %d%%", n, percent)
+
+	case "humanize", "hu":
+		if len(args) != 1 {
+			return 0, fmt.Errorf("usage: humanize N [sel|track|pattern|song]")
+		}
+		amount, err := strconv.Atoi(args[0])
+		if err != nil || amount < 1 || amount > 64 {
+			return 0, fmt.Errorf("bad amount %q (1-64)", args[0])
+		}
+		targets, err := m.opTargets(scope)
+		if err != nil {
+			return 0, err
+		}
+		rng := rand.New(rand.NewPCG(uint64(time.Now().UnixNano()), 0))
+		for _, t := range targets {
+			n += t.pat.Humanize(t.block, amount, rng)
+		}
+		m.StatusMsg = fmt.Sprintf("Humanized %d volumes by up to ±%d", n, amount)
+
+	case "highlight", "hl":
+		// Row highlight spacing is saved with the song; 0 turns it off
+		if len(args) < 1 || len(args) > 2 {
+			return 0, fmt.Errorf("usage: hl BEAT [MEASURE] (rows, 0 = off)")
+		}
+		var rows [2]uint8
+		rows[1] = m.Song.RowsPerMeasure
+		for i, arg := range args {
+			v, err := strconv.ParseUint(arg, 10, 8)
+			if err != nil {
+				return 0, fmt.Errorf("bad row count %q", arg)
+			}
+			rows[i] = uint8(v)
+		}
+		if rows[0] != m.Song.RowsPerBeat || rows[1] != m.Song.RowsPerMeasure {
+			m.Song.RowsPerBeat, m.Song.RowsPerMeasure = rows[0], rows[1]
+			n = 1
+		}
+		m.StatusMsg = fmt.Sprintf("Row highlight: beat %d, measure %d", rows[0], rows[1])
+
+	default:
+		return 0, fmt.Errorf("unknown command %q (%s)", cmd, commandHelp)
+	}
+	return n, nil
+}
+
+// parseInstrument parses a hex instrument number (01-FF)
+func parseInstrument(s string) (uint8, error) {
+	v, err := strconv.ParseUint(s, 16, 8)
+	if err != nil || v == 0 {
+		return 0, fmt.Errorf("bad instrument %q (01-FF)", s)
+	}
+	return uint8(v), nil
+}
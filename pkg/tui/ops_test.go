@@ -0,0 +1,36 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/anthropics/abytetracker/pkg/tracker"
+)
+
+func TestRunCommandScope(t *testing.T) {
+	// The scope may come before or after the instrument, as documented
+	for _, line := range []string{"transpose -12 song i03", "transpose -12 i03 song", "tr -12 i03 sel"} {
+		song := tracker.NewSong(2)
+		song.Patterns = append(song.Patterns, tracker.NewPattern(4, 2))
+		song.Patterns[0].Notes[2][1] = tracker.Note{Pitch: 48, Instrument: 3, Volume: -1}
+		song.Patterns[1].Notes[0][0] = tracker.Note{Pitch: 50, Instrument: 3, Volume: -1}
+		song.Patterns[1].Notes[1][0] = tracker.Note{Pitch: 52, Instrument: 1, Volume: -1}
+		m := NewModel(song, "")
+		m.Selecting = true // Rows 0-0 of channel 0, which is empty
+
+		m.runCommand(line)
+		want := [3]int8{36, 38, 52}
+		if line == "tr -12 i03 sel" {
+			want = [3]int8{48, 50, 52}
+		}
+		got := [3]int8{song.Patterns[0].Notes[2][1].Pitch, song.Patterns[1].Notes[0][0].Pitch, song.Patterns[1].Notes[1][0].Pitch}
+		if got != want {
+			t.Errorf("%q: pitches %v, want %v (status %q)", line, got, want, m.StatusMsg)
+		}
+	}
+
+	m := NewModel(tracker.NewSong(2), "")
+	m.runCommand("transpose 1 song track")
+	if m.StatusMsg != "Error: more than one scope" {
+		t.Errorf("two scopes: status %q", m.StatusMsg)
+	}
+}
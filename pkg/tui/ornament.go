@@ -0,0 +1,284 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/anthropics/abytetracker/pkg/tracker"
+)
+
+// ornamentStepsPerLine is how many steps the step editor shows per line
+const ornamentStepsPerLine = 16
+
+// Semitone range of an ornament step
+const (
+	ornMinValue = -96
+	ornMaxValue = 96
+)
+
+// currentOrnament returns the selected ornament, keeping the cursors in range
+func (m *Model) currentOrnament() *tracker.Ornament {
+	if len(m.Song.Ornaments) == 0 {
+		return nil
+	}
+	m.OrnCursor = clamp(m.OrnCursor, 0, len(m.Song.Ornaments)-1)
+	orn := &m.Song.Ornaments[m.OrnCursor]
+	m.OrnStep = clamp(m.OrnStep, 0, max(len(orn.Values)-1, 0))
+	return orn
+}
+
+// handleOrnamentKey handles the ornament editor's own keys, ahead of the
+// global bindings, and returns false for any other key
+func (m *Model) handleOrnamentKey(msg tea.KeyMsg) bool {
+	song := m.Song
+	orn := m.currentOrnament()
+
+	switch m.Keys.EditorAction(secOrnament, msg.String()) {
+	case ActOrnPrev:
+		if m.OrnCursor > 0 {
+			m.OrnCursor--
+			m.OrnStep = 0
+		}
+	case ActOrnNext:
+		if m.OrnCursor < len(song.Ornaments)-1 {
+			m.OrnCursor++
+			m.OrnStep = 0
+		}
+	case ActOrnNew:
+		m.insertOrnament(tracker.Ornament{Name: "New", Loop: -1, Values: []int8{0}})
+	case ActOrnDuplicate:
+		if orn != nil {
+			m.insertOrnament(orn.Clone())
+		}
+	case ActOrnDelete:
+		// Keep at least one ornament
+		if len(song.Ornaments) > 1 {
+			song.DeleteOrnament(m.OrnCursor)
+			m.StatusMsg = fmt.Sprintf("Deleted ornament %02d", m.OrnCursor+1)
+			m.currentOrnament()
+			m.edited()
+		}
+	case ActOrnArpeggio:
+		// Generate a chord arpeggio
+		names := make([]string, len(tracker.Chords))
+		for i, c := range tracker.Chords {
+			names[i] = c.Name
+		}
+		m.startInput("Chord ("+strings.Join(names, " ")+") [ticks]", "", 0, func(m *Model, v string) {
+			orn, err := parseArpeggio(v)
+			if err != nil {
+				m.StatusMsg = err.Error()
+				return
+			}
+			m.insertOrnament(orn)
+		})
+	case ActOrnRename:
+		if orn != nil {
+			i := m.OrnCursor
+			m.startInput("Ornament name", orn.Name, 8, func(m *Model, v string) {
+				if err := checkName(v); err != nil {
+					m.StatusMsg = "Name: " + err.Error()
+					return
+				}
+				m.Song.Ornaments[i].Name = v
+			})
+		}
+	default:
+		if orn == nil {
+			return false
+		}
+		return m.editOrnamentStep(orn, msg)
+	}
+	return true
+}
+
+// editOrnamentStep handles the step editor keys for orn
+func (m *Model) editOrnamentStep(orn *tracker.Ornament, msg tea.KeyMsg) bool {
+	if len(orn.Values) == 0 {
+		orn.Values = []int8{0}
+		m.edited()
+	}
+	step := &orn.Values[m.OrnStep]
+
+	switch m.Keys.EditorAction(secOrnament, msg.String()) {
+	case ActOrnInsertStep:
+		// Insert a copy of the step after it
+		at := m.OrnStep + 1
+		orn.Values = append(orn.Values[:at], append([]int8{*step}, orn.Values[at:]...)...)
+		if int(orn.Loop) >= at {
+			orn.Loop++
+		}
+		m.OrnStep = at
+		m.edited()
+		return true
+	case ActOrnDeleteStep:
+		// Delete the step (keep at least 1)
+		if len(orn.Values) > 1 {
+			at := m.OrnStep
+			orn.Values = append(orn.Values[:at], orn.Values[at+1:]...)
+			if int(orn.Loop) > at || int(orn.Loop) >= len(orn.Values) {
+				orn.Loop--
+			}
+			m.OrnStep = min(at, len(orn.Values)-1)
+			m.edited()
+		}
+		return true
+	case ActOrnLoop:
+		// Loop from this step, or stop looping if it already does
+		if int(orn.Loop) == m.OrnStep {
+			orn.Loop = -1
+		} else {
+			orn.Loop = int8(m.OrnStep)
+		}
+		m.edited()
+		return true
+	}
+
+	// Fixed cursor keys
+	switch msg.String() {
+	case "left":
+		if m.OrnStep > 0 {
+			m.OrnStep--
+		}
+	case "right":
+		if m.OrnStep < len(orn.Values)-1 {
+			m.OrnStep++
+		}
+	case "home":
+		m.OrnStep = 0
+	case "end":
+		m.OrnStep = len(orn.Values) - 1
+	case "up":
+		*step = int8(clamp(int(*step)+1, ornMinValue, ornMaxValue))
+		m.edited()
+	case "down":
+		*step = int8(clamp(int(*step)-1, ornMinValue, ornMaxValue))
+		m.edited()
+	case "pgup":
+		*step = int8(clamp(int(*step)+12, ornMinValue, ornMaxValue))
+		m.edited()
+	case "pgdown":
+		*step = int8(clamp(int(*step)-12, ornMinValue, ornMaxValue))
+		m.edited()
+	default:
+		return false
+	}
+	return true
+}
+
+// insertOrnament adds orn after the selected ornament and selects it
+func (m *Model) insertOrnament(orn tracker.Ornament) {
+	at := min(m.OrnCursor+1, len(m.Song.Ornaments))
+	if !m.Song.InsertOrnament(at, orn) {
+		m.StatusMsg = fmt.Sprintf("No ornament numbers left (01-%02X)", tracker.MaxOrnaments)
+		return
+	}
+	m.OrnCursor = at
+	m.OrnStep = 0
+	m.edited()
+}
+
+// parseArpeggio builds an arpeggio ornament from "<chord> [ticks]"
+func parseArpeggio(s string) (tracker.Ornament, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return tracker.Ornament{}, fmt.Errorf("no chord given")
+	}
+	ticks := 1
+	if len(fields) > 1 {
+		n, err := strconv.Atoi(fields[1])
+		if err != nil || n < 1 || n > 16 {
+			return tracker.Ornament{}, fmt.Errorf("ticks per note must be 1-16")
+		}
+		ticks = n
+	}
+	for _, c := range tracker.Chords {
+		if strings.EqualFold(c.Name, fields[0]) {
+			return tracker.ArpeggioOrnament(c, ticks), nil
+		}
+	}
+	return tracker.Ornament{}, fmt.Errorf("unknown chord %q", fields[0])
+}
+
+func (m Model) ornamentView() string {
+	var b strings.Builder
+	title := m.Theme.Fg(m.Theme.Title).Bold(true).Render("ORNAMENTS")
+	fmt.Fprintf(&b, "%s (%s to exit)\n\n", title, m.Keys.shortLabel(ActOrnamentView))
+
+	for i, orn := range m.Song.Ornaments {
+		cursor := "  "
+		if i == m.OrnCursor {
+			cursor = "> "
+		}
+		style := lipgloss.NewStyle()
+		if i == m.OrnCursor {
+			style = m.Theme.Fg(m.Theme.Accent).Bold(true)
+		}
+
+		values := ""
+		for j, v := range orn.Values {
+			if j > 0 {
+				values += " "
+			}
+			if v >= 0 {
+				values += fmt.Sprintf("+%d", v)
+			} else {
+				values += fmt.Sprintf("%d", v)
+			}
+		}
+		loop := ""
+		if orn.Loop >= 0 {
+			loop = fmt.Sprintf(" L%d", orn.Loop)
+		}
+		line := fmt.Sprintf("%s%02d: %-8s [%s]%s", cursor, i+1, orn.Name, values, loop)
+		b.WriteString(style.Render(line) + "\n")
+	}
+
+	if m.OrnCursor < len(m.Song.Ornaments) {
+		b.WriteString("\n" + m.ornamentStepsView(&m.Song.Ornaments[m.OrnCursor]))
+	}
+	key := m.Keys.shortLabel
+	fmt.Fprintf(&b, "\n %s %s Select  ←→ Home/End Step  ↑↓ Semitone  PgUp/PgDn Octave  %s Insert step  %s Delete step\n",
+		key(ActOrnPrev), key(ActOrnNext), key(ActOrnInsertStep), key(ActOrnDeleteStep))
+	fmt.Fprintf(&b, " %s Loop here  %s Arpeggio  %s Rename  %s New  %s Duplicate  %s Delete  Piano keys Play\n",
+		key(ActOrnLoop), key(ActOrnArpeggio), key(ActOrnRename), key(ActOrnNew), key(ActOrnDuplicate), key(ActOrnDelete))
+	return b.String()
+}
+
+// ornamentStepsView draws the step editor: step numbers, values with the
+// cursor, and the looped span
+func (m Model) ornamentStepsView(orn *tracker.Ornament) string {
+	label := m.Theme.Fg(m.Theme.Dim)
+	selected := lipgloss.NewStyle().Reverse(true)
+	loopStyle := m.Theme.Fg(m.Theme.Title)
+
+	var b strings.Builder
+	for start := 0; start < len(orn.Values); start += ornamentStepsPerLine {
+		end := min(start+ornamentStepsPerLine, len(orn.Values))
+		var steps, values, loop strings.Builder
+		for i := start; i < end; i++ {
+			steps.WriteString(fmt.Sprintf(" %02d ", i))
+			v := fmt.Sprintf("%+3d", orn.Values[i])
+			if i == m.OrnStep {
+				v = selected.Render(v)
+			}
+			values.WriteString(" " + v)
+			switch {
+			case orn.Loop < 0 || i < int(orn.Loop):
+				loop.WriteString("    ")
+			case i == int(orn.Loop):
+				loop.WriteString(loopStyle.Render(" L──"))
+			default:
+				loop.WriteString(loopStyle.Render("────"))
+			}
+		}
+		b.WriteString(label.Render(" Step ") + label.Render(steps.String()) + "\n")
+		b.WriteString(label.Render(" Semi ") + values.String() + "\n")
+		b.WriteString("      " + loop.String() + "\n")
+	}
+	return b.String()
+}
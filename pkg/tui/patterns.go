@@ -0,0 +1,142 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/anthropics/abytetracker/pkg/tracker"
+)
+
+// handlePatternListKey handles the pattern list's own keys, ahead of the
+// global bindings, and returns false for any other key
+func (m *Model) handlePatternListKey(msg tea.KeyMsg) bool {
+	song := m.Song
+	m.PatCursor = clamp(m.PatCursor, 0, len(song.Patterns)-1)
+
+	switch m.Keys.EditorAction(secPatterns, msg.String()) {
+	case ActPatternsBack:
+		m.Mode = ModeOrder
+		return true
+	case ActPatternsUse:
+		// Use the pattern at the selected order position
+		if m.OrderCursor < len(song.Order) {
+			song.Order[m.OrderCursor] = uint8(m.PatCursor)
+		}
+		m.Mode = ModeOrder
+	case ActPatternsNew:
+		// New empty pattern after the cursor
+		at := m.PatCursor + 1
+		if !song.InsertPattern(at, tracker.NewPattern(64, song.Channels)) {
+			m.StatusMsg = fmt.Sprintf("Song already has %d patterns", tracker.MaxPatterns)
+			break
+		}
+		m.PatCursor = at
+	case ActPatternsClone:
+		if at := song.ClonePattern(m.PatCursor); at >= 0 {
+			m.PatCursor = at
+		} else {
+			m.StatusMsg = fmt.Sprintf("Song already has %d patterns", tracker.MaxPatterns)
+		}
+	case ActPatternsDelete:
+		if len(song.Patterns) > 1 {
+			song.DeletePattern(m.PatCursor)
+			m.StatusMsg = fmt.Sprintf("Deleted pattern %03d", m.PatCursor)
+			m.PatCursor = min(m.PatCursor, len(song.Patterns)-1)
+			m.clampOrderCursors()
+		}
+	case ActPatternsUnused:
+		n := song.RemoveUnusedPatterns()
+		m.StatusMsg = fmt.Sprintf("Removed %d unused patterns", n)
+		m.PatCursor = min(m.PatCursor, len(song.Patterns)-1)
+	default:
+		return m.patternListCursorKey(msg.String())
+	}
+	m.edited()
+	return true
+}
+
+// patternListCursorKey handles the pattern list's fixed cursor keys
+func (m *Model) patternListCursorKey(key string) bool {
+	song := m.Song
+	switch key {
+	case "up":
+		if m.PatCursor > 0 {
+			m.PatCursor--
+		}
+	case "down":
+		if m.PatCursor < len(song.Patterns)-1 {
+			m.PatCursor++
+		}
+	case "shift+up":
+		if m.PatCursor > 0 {
+			song.MovePattern(m.PatCursor, m.PatCursor-1)
+			m.PatCursor--
+			m.edited()
+		}
+	case "shift+down":
+		if m.PatCursor < len(song.Patterns)-1 {
+			song.MovePattern(m.PatCursor, m.PatCursor+1)
+			m.PatCursor++
+			m.edited()
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// clampOrderCursors keeps the order positions in range after the order shrinks
+func (m *Model) clampOrderCursors() {
+	last := len(m.Song.Order) - 1
+	m.OrderCursor = min(m.OrderCursor, last)
+	if m.EditPos > last {
+		m.EditPos = last
+		m.CursorRow = 0
+		m.ViewRow = 0
+	}
+}
+
+func (m Model) patternListView() string {
+	var b strings.Builder
+	title := m.Theme.Fg(m.Theme.Title).Bold(true).Render("PATTERNS")
+	fmt.Fprintf(&b, "%s (%s back to order, %s to exit)\n\n", title, m.Keys.shortLabel(ActPatternsBack), m.Keys.shortLabel(ActOrderView))
+
+	// Order positions using each pattern
+	uses := make([][]string, len(m.Song.Patterns))
+	for pos, n := range m.Song.Order {
+		if int(n) < len(uses) {
+			uses[n] = append(uses[n], fmt.Sprintf("%02d", pos))
+		}
+	}
+
+	// Keep the cursor in view
+	visible := max(m.Height-12, 8)
+	first := max(0, min(m.PatCursor-visible/2, len(m.Song.Patterns)-visible))
+
+	unused := m.Theme.Fg(m.Theme.Dim)
+	for i := first; i < len(m.Song.Patterns) && i < first+visible; i++ {
+		pat := m.Song.Patterns[i]
+		cursor := "  "
+		style := lipgloss.NewStyle()
+		if i == m.PatCursor {
+			cursor = "> "
+			style = m.Theme.Fg(m.Theme.Accent).Bold(true)
+		}
+		used := "unused"
+		if len(uses[i]) > 0 {
+			used = "at " + strings.Join(uses[i], " ")
+		} else if i != m.PatCursor {
+			style = unused
+		}
+		line := fmt.Sprintf("%s%03d: %3d rows  %s", cursor, i, pat.Rows, used)
+		b.WriteString(style.Render(line) + "\n")
+	}
+
+	key := m.Keys.shortLabel
+	fmt.Fprintf(&b, "\n ↑↓ Select  %s Use at order position  %s New  %s Clone  %s Delete  Shift+↑↓ Move  %s Remove unused  %s Back\n",
+		key(ActPatternsUse), key(ActPatternsNew), key(ActPatternsClone), key(ActPatternsDelete), key(ActPatternsUnused), m.Keys.Label(ActPatternsBack))
+	return b.String()
+}
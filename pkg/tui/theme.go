@@ -0,0 +1,161 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// Color is a terminal colour: an ANSI number ("11"), a 256-colour index
+// ("238") or a hex value ("#ff8800"). Empty leaves the terminal default.
+type Color string
+
+// Theme is a named palette for the editor. Foreground colours are used
+// for text; the *Bg colours mark rows and cells. A Mono theme ignores the
+// colours and marks the cursor and selections in reverse video instead.
+type Theme struct {
+	Name string `json:"-"`
+	Mono bool   `json:"mono"`
+
+	Title      Color `json:"title"`      // View titles, input labels
+	Accent     Color `json:"accent"`     // Selected list entries, help text
+	Dim        Color `json:"dim"`        // Empty cells, labels, hints
+	Good       Color `json:"good"`       // Status messages, playback, solo
+	Warn       Color `json:"warn"`       // Meters nearing full scale
+	Bad        Color `json:"bad"`        // Note off, mute, clipping
+	Note       Color `json:"note"`       // Notes without an instrument colour
+	Instrument Color `json:"instrument"` // Instrument column
+	Effect     Color `json:"effect"`     // Effect column
+	Beat       Color `json:"beat"`       // Row numbers on beats
+	Measure    Color `json:"measure"`    // Row numbers on measures
+
+	CursorBg    Color `json:"cursor_bg"`    // Cell under the cursor
+	SelectionBg Color `json:"selection_bg"` // Block selection
+	PlayBg      Color `json:"play_bg"`      // Row being played
+	MarkBg      Color `json:"mark_bg"`      // Edited order position, wave cursor
+	MeasureBg   Color `json:"measure_bg"`   // Whole rows on measures
+
+	// Notes take their instrument's colour, cycling through the list
+	// (empty = all notes in Note)
+	InstrumentColors []Color `json:"instrument_colors"`
+}
+
+// Themes are the built-in palettes
+var Themes = map[string]Theme{
+	"default": {
+		Title: "11", Accent: "14", Dim: "8", Good: "10", Warn: "11", Bad: "9",
+		Note: "15", Instrument: "11", Effect: "13", Beat: "14", Measure: "14",
+		CursorBg: "6", SelectionBg: "238", PlayBg: "4", MarkBg: "8", MeasureBg: "235",
+		InstrumentColors: []Color{"15", "117", "229", "157", "219", "153", "223", "189"},
+	},
+	"high-contrast": {
+		Title: "15", Accent: "15", Dim: "7", Good: "10", Warn: "11", Bad: "9",
+		Note: "15", Instrument: "11", Effect: "14", Beat: "11", Measure: "15",
+		CursorBg: "12", SelectionBg: "5", PlayBg: "4", MarkBg: "8",
+		InstrumentColors: []Color{"15", "11", "14", "10", "13", "9"},
+	},
+	"monochrome": {
+		Mono: true,
+	},
+}
+
+// ThemeNames lists the built-in themes for messages
+func ThemeNames() string {
+	names := make([]string, 0, len(Themes))
+	for name := range Themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// NewTheme picks the theme named in the config, which may be built in
+// or defined under "themes" on top of a built-in one ("base", default
+// "default"). NO_COLOR in the environment always gives monochrome.
+func NewTheme(cfg Config) (*Theme, error) {
+	name := cfg.Theme
+	if os.Getenv("NO_COLOR") != "" {
+		name = "monochrome"
+	}
+	if name == "" {
+		name = "default"
+	}
+
+	if raw, ok := cfg.Themes[name]; ok && os.Getenv("NO_COLOR") == "" {
+		var custom struct {
+			Base string `json:"base"`
+		}
+		if err := json.Unmarshal(raw, &custom); err != nil {
+			return nil, fmt.Errorf("theme %s: %w", name, err)
+		}
+		if custom.Base == "" {
+			custom.Base = "default"
+		}
+		base, ok := Themes[custom.Base]
+		if !ok {
+			return nil, fmt.Errorf("theme %s: unknown base theme %q (%s)", name, custom.Base, ThemeNames())
+		}
+		t := base
+		t.InstrumentColors = append([]Color(nil), base.InstrumentColors...)
+		if err := json.Unmarshal(raw, &t); err != nil {
+			return nil, fmt.Errorf("theme %s: %w", name, err)
+		}
+		t.Name = name
+		return &t, nil
+	}
+
+	t, ok := Themes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown theme %q (%s)", name, ThemeNames())
+	}
+	t.Name = name
+	return &t, nil
+}
+
+// DefaultTheme returns the default palette, or monochrome under NO_COLOR
+func DefaultTheme() *Theme {
+	t, _ := NewTheme(Config{})
+	return t
+}
+
+// Apply sets up the terminal output for the theme. Under NO_COLOR the
+// terminal is detected as plain ASCII, which would also drop the reverse
+// video that monochrome relies on for the cursor.
+func (t *Theme) Apply() {
+	if t.Mono {
+		lipgloss.SetColorProfile(termenv.ANSI)
+	}
+}
+
+// Fg returns a style with text in c
+func (t *Theme) Fg(c Color) lipgloss.Style {
+	if t.Mono || c == "" {
+		return lipgloss.NewStyle()
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(c))
+}
+
+// Bg marks s with background c; monochrome themes use reverse video
+func (t *Theme) Bg(s lipgloss.Style, c Color) lipgloss.Style {
+	if t.Mono {
+		return s.Reverse(true)
+	}
+	if c == "" {
+		return s
+	}
+	return s.Background(lipgloss.Color(c))
+}
+
+// InstrumentNote returns the colour for notes played by instrument inst
+// (1-based, 0 = none)
+func (t *Theme) InstrumentNote(inst uint8) Color {
+	if inst == 0 || len(t.InstrumentColors) == 0 {
+		return t.Note
+	}
+	return t.InstrumentColors[int(inst-1)%len(t.InstrumentColors)]
+}
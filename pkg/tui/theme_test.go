@@ -0,0 +1,35 @@
+package tui
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNewTheme(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+
+	// A custom theme changes only the colours it names in its base
+	th, err := NewTheme(Config{
+		Theme:  "mine",
+		Themes: map[string]json.RawMessage{"mine": json.RawMessage(`{"base": "high-contrast", "note": "#ffd700"}`)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := Themes["high-contrast"]
+	if th.Note != "#ffd700" || th.Effect != base.Effect || th.Name != "mine" {
+		t.Errorf("custom theme = %+v", th)
+	}
+
+	if _, err := NewTheme(Config{Theme: "nope"}); err == nil {
+		t.Error("unknown theme accepted")
+	}
+	if _, err := NewTheme(Config{Theme: "mine", Themes: map[string]json.RawMessage{"mine": json.RawMessage(`{"base": "nope"}`)}}); err == nil {
+		t.Error("unknown base theme accepted")
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if th, _ := NewTheme(Config{Theme: "default"}); !th.Mono {
+		t.Error("NO_COLOR did not give a monochrome theme")
+	}
+}
@@ -0,0 +1,67 @@
+package tui
+
+import "github.com/anthropics/abytetracker/pkg/tracker"
+
+// maxUndo is how many edits can be undone
+const maxUndo = 64
+
+// history holds whole-song snapshots taken before bulk edits
+type history struct {
+	undo []*tracker.Song
+	redo []*tracker.Song
+}
+
+// pushUndo records the song as it was before an edit. before must be a
+// copy taken with Song.Clone.
+func (m *Model) pushUndo(before *tracker.Song) {
+	h := &m.History
+	h.undo = append(h.undo, before)
+	if len(h.undo) > maxUndo {
+		h.undo = h.undo[1:]
+	}
+	h.redo = nil
+	m.edited()
+}
+
+// undo restores the song to before the last recorded edit
+func (m *Model) undo() {
+	h := &m.History
+	if len(h.undo) == 0 {
+		m.StatusMsg = "Nothing to undo"
+		return
+	}
+	snap := h.undo[len(h.undo)-1]
+	h.undo = h.undo[:len(h.undo)-1]
+	h.redo = append(h.redo, m.Song.Clone())
+	m.restore(snap)
+	m.StatusMsg = "Undone"
+}
+
+// redo reapplies the last undone edit
+func (m *Model) redo() {
+	h := &m.History
+	if len(h.redo) == 0 {
+		m.StatusMsg = "Nothing to redo"
+		return
+	}
+	snap := h.redo[len(h.redo)-1]
+	h.redo = h.redo[:len(h.redo)-1]
+	h.undo = append(h.undo, m.Song.Clone())
+	m.restore(snap)
+	m.StatusMsg = "Redone"
+}
+
+// restore replaces the song's contents with a snapshot, in place so that
+// everything holding the song pointer sees it, and keeps the cursors valid
+func (m *Model) restore(snap *tracker.Song) {
+	*m.Song = *snap
+	m.edited()
+	m.clampOrderCursors()
+	m.CursorCh = min(m.CursorCh, m.Song.Channels-1)
+	if pat := m.currentPattern(); pat != nil && m.CursorRow >= pat.Rows {
+		m.CursorRow = pat.Rows - 1
+		m.ensureRowVisible()
+	}
+	m.InstCursor = clamp(m.InstCursor, 0, len(m.Song.Instruments)-1)
+	m.OrnCursor = clamp(m.OrnCursor, 0, len(m.Song.Ornaments)-1)
+}
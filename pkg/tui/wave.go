@@ -0,0 +1,223 @@
+package tui
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/anthropics/abytetracker/pkg/tracker"
+)
+
+// waveEditorHeight is the number of text rows used to draw a wave
+const waveEditorHeight = 16
+
+// openWaveEditor opens the wave editor for the selected instrument,
+// creating a default wave if the instrument has none
+func (m *Model) openWaveEditor() {
+	if m.InstCursor >= len(m.Song.Instruments) {
+		return
+	}
+	inst := &m.Song.Instruments[m.InstCursor]
+	if len(inst.Wavetable.Waves) == 0 {
+		inst.Wavetable = tracker.NewWavetable(32, 4)
+	}
+	m.WaveEdit = true
+	m.WaveIdx = 0
+	m.WaveCursor = 0
+}
+
+// currentWave returns the wavetable and wave being edited
+func (m *Model) currentWave() (*tracker.Wavetable, []uint8) {
+	if m.InstCursor >= len(m.Song.Instruments) {
+		return nil, nil
+	}
+	wt := &m.Song.Instruments[m.InstCursor].Wavetable
+	if len(wt.Waves) == 0 {
+		return wt, nil
+	}
+	if m.WaveIdx >= len(wt.Waves) {
+		m.WaveIdx = len(wt.Waves) - 1
+	}
+	return wt, wt.Waves[m.WaveIdx]
+}
+
+// handleWaveKey handles keys in the wave editor, returning true if handled
+func (m *Model) handleWaveKey(msg tea.KeyMsg) bool {
+	wt, wave := m.currentWave()
+	if wave == nil {
+		m.WaveEdit = false
+		return false
+	}
+	max := wt.MaxValue()
+
+	switch m.Keys.EditorAction(secWave, msg.String()) {
+	case ActWaveClose:
+		m.WaveEdit = false
+		return true
+	case ActWavePrev:
+		if m.WaveIdx > 0 {
+			m.WaveIdx--
+		}
+		return true
+	case ActWaveNext:
+		if m.WaveIdx < len(wt.Waves)-1 {
+			m.WaveIdx++
+		}
+		return true
+	case ActWaveAdd:
+		// Add a copy of the current wave after it
+		dup := append([]uint8(nil), wave...)
+		wt.Waves = append(wt.Waves[:m.WaveIdx+1], append([][]uint8{dup}, wt.Waves[m.WaveIdx+1:]...)...)
+		m.WaveIdx++
+	case ActWaveDelete:
+		// Delete current wave (keep at least 1)
+		if len(wt.Waves) > 1 {
+			wt.Waves = append(wt.Waves[:m.WaveIdx], wt.Waves[m.WaveIdx+1:]...)
+			if m.WaveIdx >= len(wt.Waves) {
+				m.WaveIdx = len(wt.Waves) - 1
+			}
+		}
+	case ActWaveLength:
+		// Toggle 32/64 steps
+		length := tracker.WaveLengths[0]
+		if len(wave) == length {
+			length = tracker.WaveLengths[1]
+		}
+		wt.Resize(length)
+		m.WaveCursor = m.WaveCursor * length / len(wave)
+	case ActWaveBits:
+		// Toggle 4/8-bit depth
+		depth := tracker.WaveDepths[0]
+		if wt.Depth == depth {
+			depth = tracker.WaveDepths[1]
+		}
+		wt.SetDepth(depth)
+	case ActWaveMorphUp:
+		if wt.Morph < 255 {
+			wt.Morph++
+		}
+	case ActWaveMorphDn:
+		if wt.Morph > 0 {
+			wt.Morph--
+		}
+	case ActWaveSine:
+		fillWave(wave, max, 0)
+	case ActWaveTriangle:
+		fillWave(wave, max, 1)
+	case ActWaveSaw:
+		fillWave(wave, max, 2)
+	case ActWaveSquare:
+		fillWave(wave, max, 3)
+	default:
+		return m.waveCursorKey(wave, max, msg.String())
+	}
+	m.edited()
+	return true
+}
+
+// waveCursorKey handles the wave editor's fixed cursor and value keys
+func (m *Model) waveCursorKey(wave []uint8, max uint8, key string) bool {
+	switch key {
+	case "left":
+		if m.WaveCursor > 0 {
+			m.WaveCursor--
+		}
+	case "right":
+		if m.WaveCursor < len(wave)-1 {
+			m.WaveCursor++
+		}
+	case "up":
+		if wave[m.WaveCursor] < max {
+			wave[m.WaveCursor]++
+		}
+		m.edited()
+	case "down":
+		if wave[m.WaveCursor] > 0 {
+			wave[m.WaveCursor]--
+		}
+		m.edited()
+	case "pgup":
+		wave[m.WaveCursor] = max
+		m.edited()
+	case "pgdown":
+		wave[m.WaveCursor] = 0
+		m.edited()
+	default:
+		return false
+	}
+	return true
+}
+
+// fillWave replaces a wave with a preset shape (0=sine, 1=triangle, 2=saw, 3=square)
+func fillWave(wave []uint8, max uint8, shape byte) {
+	n := len(wave)
+	for i := range wave {
+		p := float64(i) / float64(n)
+		var v float64 // 0.0-1.0
+		switch shape {
+		case 0:
+			v = 0.5 + 0.5*math.Sin(2*math.Pi*p)
+		case 1:
+			v = 1 - math.Abs(2*p-1)
+		case 2:
+			v = p
+		default:
+			if p < 0.5 {
+				v = 1
+			}
+		}
+		wave[i] = uint8(math.Round(v * float64(max)))
+	}
+}
+
+func (m Model) waveEditorView() string {
+	wt, wave := m.currentWave()
+	if wave == nil {
+		return ""
+	}
+	max := float64(wt.MaxValue())
+	bits := 4
+	if wt.Depth == 8 {
+		bits = 8
+	}
+
+	var b strings.Builder
+	title := m.Theme.Fg(m.Theme.Title).Bold(true).Render("WAVE EDITOR")
+	b.WriteString(fmt.Sprintf("%s  Wave %d/%d  Step %02d = %X  %d steps  %d-bit  Morph %d\n",
+		title, m.WaveIdx+1, len(wt.Waves), m.WaveCursor, wave[m.WaveCursor],
+		len(wave), bits, wt.Morph))
+
+	barStyle := m.Theme.Fg(m.Theme.Accent)
+	cursorStyle := m.Theme.Bg(m.Theme.Fg(m.Theme.Title), m.Theme.MarkBg)
+
+	b.WriteString("┌" + strings.Repeat("─", len(wave)) + "┐\n")
+	for row := waveEditorHeight - 1; row >= 0; row-- {
+		b.WriteString("│")
+		for i, v := range wave {
+			level := float64(v) / max * waveEditorHeight
+			ch := " "
+			if level > float64(row)+0.5 {
+				ch = "█"
+			} else if level > float64(row) {
+				ch = "▄"
+			}
+			if i == m.WaveCursor {
+				b.WriteString(cursorStyle.Render(ch))
+			} else {
+				b.WriteString(barStyle.Render(ch))
+			}
+		}
+		b.WriteString("│\n")
+	}
+	b.WriteString("└" + strings.Repeat("─", len(wave)) + "┘\n")
+
+	key := m.Keys.shortLabel
+	fmt.Fprintf(&b, " ←→ Step  ↑↓ Value  PgUp/Dn Max/Min  %s %s Wave  %s Add  %s Delete\n",
+		key(ActWavePrev), key(ActWaveNext), key(ActWaveAdd), key(ActWaveDelete))
+	fmt.Fprintf(&b, " %s Length  %s Bits  %s/%s Morph  %s/%s/%s/%s Sine/Tri/Saw/Square  %s Close\n",
+		key(ActWaveLength), key(ActWaveBits), key(ActWaveMorphUp), key(ActWaveMorphDn),
+		key(ActWaveSine), key(ActWaveTriangle), key(ActWaveSaw), key(ActWaveSquare), m.Keys.Label(ActWaveClose))
+	return b.String()
+}